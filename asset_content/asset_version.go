@@ -0,0 +1,316 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultAssetVersionListLimit = 50
+)
+
+type ListAssetVersionsReq struct {
+	AssetId         string `json:"assetId" binding:"required"`
+	BeforeVersionId uint   `json:"beforeVersionId" binding:"omitempty"` // the versionId of the last listed version for the next page
+	Limit           int    `json:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+type ReadAssetVersionReq struct {
+	AssetId      string `json:"assetId" binding:"required"`
+	AssetVersion string `json:"assetVersion" binding:"required"`
+}
+
+type RestoreAssetVersionReq struct {
+	AssetId          string `json:"assetId" binding:"required"`
+	CurrentVersion   string `json:"currentVersion" binding:"required"`
+	RestoringVersion string `json:"restoringVersion" binding:"required"`
+	Author           string `json:"author" binding:"max=64"`
+}
+
+type AssetVersionRestoration struct {
+	PrevVersion         string                  `json:"prevVersion" binding:"required"`
+	NewVersion          string                  `json:"newVersion" binding:"required"`
+	RestoredFromVersion string                  `json:"restoredFromVersion" binding:"required"`
+	AssetDocument       *common.AssetDetailInfo `json:"assetDocument" binding:"required"`
+
+	ValidationIssues   []content_modifier.BehaviourTreeValidationIssue `json:"validationIssues,omitempty"`   // the issues of the behaviour tree introduced by the restoring when it's rejected
+	DanglingReferences []BlackBoardDanglingReference                   `json:"danglingReferences,omitempty"` // the references of the bound behaviour trees broken by restoring the black board
+}
+
+// RecordAssetVersion keeps the committed content of the asset, it's called in the transaction after the asset is written
+func RecordAssetVersion(repo db.Repository, assetDetail *common.AssetDetailInfo, prevVersion string, restoredFromVersion string, author string) error {
+	versionItem := common.AssetVersionDetailInfo{
+		AssetId:             assetDetail.AssetId,
+		AssetVersion:        assetDetail.AssetVersion,
+		PrevVersion:         prevVersion,
+		RestoredFromVersion: restoredFromVersion,
+		Author:              author,
+		CreatedAt:           time.Now().UnixMilli(),
+		AssetContent:        assetDetail.AssetContent,
+	}
+	return repo.CreateAssetVersion(&versionItem)
+}
+
+func ListAssetVersionsAPI(context *gin.Context) {
+	var req ListAssetVersionsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultAssetVersionListLimit
+	}
+
+	_, err = db.Storage.GetAsset(req.AssetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAsset,
+			"errMessage": common.InvalidAsset.GetMsgFormat(req.AssetId),
+		})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	versionItems, err := db.Storage.ListAssetVersions(req.AssetId, req.BeforeVersionId, req.Limit)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":       common.Success,
+		"errMessage":    "",
+		"assetVersions": versionItems,
+	})
+}
+
+func ReadAssetVersionAPI(context *gin.Context) {
+	var req ReadAssetVersionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	versionDetail, err := db.Storage.GetAssetVersion(req.AssetId, req.AssetVersion)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.UnrecordedAssetVersion,
+			"errMessage": common.UnrecordedAssetVersion.GetMsgFormat(req.AssetId, req.AssetVersion),
+		})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":      common.Success,
+		"errMessage":   "",
+		"assetVersion": versionDetail,
+	})
+}
+
+func RestoreAssetVersionAPI(context *gin.Context) {
+	var req RestoreAssetVersionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, restoration := passAssetVersionRestoring(&req)
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":     errCode,
+		"errMessage":  errMsg,
+		"restoration": restoration,
+	})
+}
+
+// passAssetVersionRestoring writes the content of the restoring version as a new version, the history is never rewritten.
+// the referred assets may be changed since the version was committed, so the restored behaviour tree is validated again
+// and rejected with the new issues like a modification, the references broken by restoring a black board are reported but not rejected.
+// the undo records of the behaviour tree can't be applied to the restored content, so they are all discarded,
+// and the subscribers are dropped to reload the asset since the restoring isn't described by the diff infos
+func passAssetVersionRestoring(req *RestoreAssetVersionReq) (common.ErrorCode, string, *AssetVersionRestoration) {
+	var errCode = common.Success
+	var errMsg = ""
+	var restoration *AssetVersionRestoration = nil
+
+	err := db.Storage.Transaction(func(repo db.Repository) error {
+		var assetDetail *common.AssetDetailInfo
+		//Querying Pass
+		{
+			var err error
+			assetDetail, err = repo.GetAssetForUpdate(req.AssetId)
+			if errors.Is(err, db.ErrRecordNotFound) {
+				eMsg := common.InvalidAsset.GetMsgFormat(req.AssetId)
+				errCode, errMsg = common.InvalidAsset, eMsg
+				return errors.New(eMsg)
+			}
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+		}
+
+		//Version Checking Pass
+		{
+			if assetDetail.AssetVersion != req.CurrentVersion {
+				eMsg := common.InvalidAssetVersion.GetMsgFormat(assetDetail.AssetVersion, req.CurrentVersion)
+				errCode, errMsg = common.InvalidAssetVersion, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Querying Restoring Version Pass
+		var versionDetail *common.AssetVersionDetailInfo
+		{
+			var err error
+			versionDetail, err = repo.GetAssetVersion(req.AssetId, req.RestoringVersion)
+			if errors.Is(err, db.ErrRecordNotFound) {
+				eMsg := common.UnrecordedAssetVersion.GetMsgFormat(req.AssetId, req.RestoringVersion)
+				errCode, errMsg = common.UnrecordedAssetVersion, eMsg
+				return errors.New(eMsg)
+			}
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+		}
+
+		//Validation Pass, Only The Issues Introduced By The Restoring Are Rejected
+		if assetDetail.AssetType == "BehaviourTree" && versionDetail.AssetContent != assetDetail.AssetContent {
+			var currentDoc, restoringDoc content_modifier.BehaviourTreeDocumentation
+			if json.Unmarshal([]byte(assetDetail.AssetContent), &currentDoc) != nil || json.Unmarshal([]byte(versionDetail.AssetContent), &restoringDoc) != nil {
+				eMsg := common.DeserializationError.GetMsg()
+				errCode, errMsg = common.DeserializationError, eMsg
+				return errors.New(eMsg)
+			}
+			prevIssues, err := checkBehaviourTreeDocument(repo, req.AssetId, &currentDoc)
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+			postIssues, err := checkBehaviourTreeDocument(repo, req.AssetId, &restoringDoc)
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+			newIssues := content_modifier.BehaviourTreeFilterNewIssues(prevIssues, postIssues)
+			if len(newIssues) > 0 {
+				errCode = common.BtValidationFailed
+				errMsg = errCode.GetMsgFormat(len(newIssues), newIssues[0].ErrMessage)
+				restoration = &AssetVersionRestoration{
+					PrevVersion:         assetDetail.AssetVersion,
+					NewVersion:          assetDetail.AssetVersion,
+					RestoredFromVersion: req.RestoringVersion,
+					ValidationIssues:    newIssues,
+				}
+				return errors.New(errMsg)
+			}
+		}
+
+		//Write Restoring
+		prevVersion := assetDetail.AssetVersion
+		prevContent := assetDetail.AssetContent
+		if versionDetail.AssetContent != assetDetail.AssetContent { // just need real write data when the content is changed
+			assetDetail.AssetVersion = uuid.New().String()
+			assetDetail.AssetContent = versionDetail.AssetContent
+
+			err := repo.SaveAsset(assetDetail)
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+
+			//Version Recording
+			err = RecordAssetVersion(repo, assetDetail, prevVersion, req.RestoringVersion, req.Author)
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+
+			//Discard The Undo Records
+			if assetDetail.AssetType == "BehaviourTree" {
+				for _, undoState := range []string{common.HistoryUndoState_Applied, common.HistoryUndoState_Undone} {
+					err = repo.UpdateModificationHistoriesUndoState(req.AssetId, common.HistoryOperation_Modify, undoState, common.HistoryUndoState_Discarded)
+					if err != nil {
+						errCode, errMsg = common.DataBaseError, err.Error()
+						return err
+					}
+				}
+			}
+		}
+
+		//Dangling Reference Pass, The Broken References Are Reported But Not Rejected
+		var danglingReferences []BlackBoardDanglingReference
+		if assetDetail.AssetType == "BlackBoard" && assetDetail.AssetContent != prevContent {
+			var prevDoc, restoredDoc content_modifier.BlackBoardDocumentation
+			if content_modifier.BlackBoardDeserialize(prevContent, &prevDoc) != nil || content_modifier.BlackBoardDeserialize(assetDetail.AssetContent, &restoredDoc) != nil {
+				eMsg := common.DeserializationError.GetMsg()
+				errCode, errMsg = common.DeserializationError, eMsg
+				return errors.New(eMsg)
+			}
+			var err error
+			danglingReferences, err = collectBlackBoardDanglingReferences(repo, req.AssetId, &restoredDoc, content_modifier.BlackBoardDiffKeys(&prevDoc, &restoredDoc))
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+		}
+
+		//All Pass
+		restoration = &AssetVersionRestoration{
+			PrevVersion:         prevVersion,
+			NewVersion:          assetDetail.AssetVersion,
+			RestoredFromVersion: req.RestoringVersion,
+			AssetDocument:       assetDetail,
+			DanglingReferences:  danglingReferences,
+		}
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+		return errCode, errMsg, restoration // Only Carries The Validation Issues When Failed
+	}
+
+	//Drop The Subscribers After The Restoring Is Committed
+	if restoration.NewVersion != restoration.PrevVersion && restoration.AssetDocument.AssetType == "BehaviourTree" {
+		modificationBroker.Drop(req.AssetId)
+	}
+	return common.Success, "", restoration
+}
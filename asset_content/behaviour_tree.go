@@ -3,25 +3,26 @@ package asset_content
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
 	"github.com/xxponline/messy-monster-ai-editor/common"
 	"github.com/xxponline/messy-monster-ai-editor/db"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
+	"golang.org/x/exp/slices"
 	"net/http"
 )
 
 type BaseBehaviourTreeModificationReq struct {
 	AssetId        string `json:"assetId" binding:"required"`
 	CurrentVersion string `json:"currentVersion" binding:"required"`
+	Author         string `json:"author" binding:"max=64"` // optional, it's kept in the version of the asset
 }
 
 type AssetModifier interface {
 	GetAssetID() string
 	GetCurrentVersion() string
+	GetAuthor() string
 }
 
 func (req *BaseBehaviourTreeModificationReq) GetAssetID() string {
@@ -32,6 +33,10 @@ func (req *BaseBehaviourTreeModificationReq) GetCurrentVersion() string {
 	return req.CurrentVersion
 }
 
+func (req *BaseBehaviourTreeModificationReq) GetAuthor() string {
+	return req.Author
+}
+
 type CreateBehaviourTreeNodeReq struct {
 	BaseBehaviourTreeModificationReq
 	Position        content_modifier.XYPosition `json:"position" binding:"required"`
@@ -71,10 +76,22 @@ type GetDetailInfoAboutBehaviourTreeNode struct {
 	NodeId  string `json:"nodeId" binding:"required"`
 }
 
+type ValidateBehaviourTreeReq struct {
+	AssetId string `json:"assetId" binding:"required"`
+}
+
 type BehaviourTreeNodeModification struct {
-	DiffNodesInfos []content_modifier.BehaviourTreeNodeDiffInfo `json:"diffNodesInfos" binding:"required"`
-	PrevVersion    string                                       `json:"prevVersion" binding:"required"`
-	NewVersion     string                                       `json:"newVersion" binding:"required"`
+	DiffNodesInfos       []content_modifier.BehaviourTreeNodeDiffInfo       `json:"diffNodesInfos" binding:"required"`
+	DiffDescriptorsInfos []content_modifier.BehaviourTreeDescriptorDiffInfo `json:"diffDescriptorsInfos" binding:"required"`
+	DiffServicesInfos    []content_modifier.BehaviourTreeServiceDiffInfo    `json:"diffServicesInfos" binding:"required"`
+	DiffBlackBoardInfo   *content_modifier.BehaviourTreeBlackBoardDiffInfo  `json:"diffBlackBoardInfo,omitempty"`
+	PrevVersion          string                                             `json:"prevVersion" binding:"required"`
+	NewVersion           string                                             `json:"newVersion" binding:"required"`
+
+	ValidationIssues []content_modifier.BehaviourTreeValidationIssue `json:"validationIssues,omitempty"`
+
+	RebasedFromVersion string                                    `json:"rebasedFromVersion,omitempty"` // the request version when the modification is rebased onto prevVersion
+	ConflictReport     *content_modifier.BehaviourTreeTouchedIds `json:"conflictReport,omitempty"`     // the contested ids when the rebasing is failed
 }
 
 type ArchivedBehaviourTree struct {
@@ -85,6 +102,9 @@ type ArchivedBehaviourTree struct {
 	BehaviourTreeNodes       string `json:"behaviourTreeNodes" binding:"required"`
 	BehaviourTreeDescriptors string `json:"behaviourTreeDescriptors" binding:"required"`
 	BehaviourTreeServices    string `json:"behaviourTreeServices" binding:"required"`
+
+	SubtreeAssetIds   []string `json:"subtreeAssetIds" binding:"required"` // the assets referred by the run subtree nodes, the runtime needs to load them as well
+	BlackBoardAssetId string   `json:"blackBoardAssetId" binding:"omitempty"`
 }
 
 func CreateBehaviourTreeNodeAPI(context *gin.Context) {
@@ -98,8 +118,8 @@ func CreateBehaviourTreeNodeAPI(context *gin.Context) {
 		return
 	}
 
-	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *CreateBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo) {
-		return content_modifier.BehaviourTreeCreateNode(req.NodeType, req.Position, req.InitialSettings, btDoc)
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *CreateBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithNodeDiffInfos(content_modifier.BehaviourTreeCreateNode(req.NodeType, req.Position, req.InitialSettings, btDoc))
 	})
 
 	context.JSON(http.StatusOK, gin.H{
@@ -120,8 +140,8 @@ func MoveBehaviourTreeNodeAPI(context *gin.Context) {
 		return
 	}
 
-	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *MoveBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo) {
-		return content_modifier.BehaviourTreeMoveNode(req.MovementItems, btDoc)
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *MoveBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithNodeDiffInfos(content_modifier.BehaviourTreeMoveNode(req.MovementItems, btDoc))
 	})
 
 	context.JSON(http.StatusOK, gin.H{
@@ -142,8 +162,8 @@ func ConnectBehaviourTreeNodeAPI(context *gin.Context) {
 		return
 	}
 
-	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *ConnectBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo) {
-		return content_modifier.BehaviourTreeConnectNode(req.ParentNodeId, req.ChildNodeId, btDoc)
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *ConnectBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithNodeDiffInfos(content_modifier.BehaviourTreeConnectNode(req.ParentNodeId, req.ChildNodeId, btDoc))
 	})
 
 	context.JSON(http.StatusOK, gin.H{
@@ -164,8 +184,8 @@ func DisconnectBehaviourTreeNodeAPI(context *gin.Context) {
 		return
 	}
 
-	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *DisconnectBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo) {
-		return content_modifier.BehaviourTreeDisconnectNode(req.ChildNodeIds, btDoc)
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *DisconnectBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithNodeDiffInfos(content_modifier.BehaviourTreeDisconnectNode(req.ChildNodeIds, btDoc))
 	})
 
 	context.JSON(http.StatusOK, gin.H{
@@ -197,11 +217,32 @@ func GetDetailInfoAboutBehaviourTreeNodeAPI(context *gin.Context) {
 
 func doGetBehaviourTreeNode(assetId string, nodeId string) (common.ErrorCode, string, *content_modifier.LogicBtNode) {
 
-	var assetDetail common.AssetDetailInfo
-	err := db.GormDatabase.First(&assetDetail, "id = ?", assetId).Error
+	errCode, errMsg, btDoc := loadBehaviourTreeDocument(assetId)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	for Idx := range btDoc.Nodes {
+		if btDoc.Nodes[Idx].NodeId == nodeId {
+			// Get It
+			gotNode := btDoc.Nodes[Idx]
+			return common.Success, "", &gotNode
+		}
+	}
+	return common.BtGetNodeInvalidNodeId, common.BtGetNodeInvalidNodeId.GetMsgFormat(nodeId), nil
+}
+
+func loadBehaviourTreeDocument(assetId string) (common.ErrorCode, string, *content_modifier.BehaviourTreeDocumentation) {
+	assetDetail, err := db.Storage.GetAsset(assetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return common.InvalidAsset, common.InvalidAsset.GetMsgFormat(assetId), nil
+	}
 	if err != nil {
 		return common.DataBaseError, err.Error(), nil
 	}
+	if assetDetail.AssetType != "BehaviourTree" {
+		return common.MismatchedAssetType, common.MismatchedAssetType.GetMsgFormat(assetDetail.AssetId, assetDetail.AssetType, "BehaviourTree"), nil
+	}
 
 	//Deserialization
 	var btDoc content_modifier.BehaviourTreeDocumentation
@@ -209,15 +250,52 @@ func doGetBehaviourTreeNode(assetId string, nodeId string) (common.ErrorCode, st
 	if err != nil {
 		return common.DeserializationError, common.DeserializationError.GetMsg(), nil
 	}
+	return common.Success, "", &btDoc
+}
 
-	for Idx := range btDoc.Nodes {
-		if btDoc.Nodes[Idx].NodeId == nodeId {
-			// Get It
-			gotNode := btDoc.Nodes[Idx]
-			return common.Success, "", &gotNode
-		}
+func ValidateBehaviourTreeAPI(context *gin.Context) {
+	var req ValidateBehaviourTreeReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
 	}
-	return common.BtGetNodeInvalidNodeId, common.BtGetNodeInvalidNodeId.GetMsgFormat(nodeId), nil
+
+	errCode, errMsg, btDoc := loadBehaviourTreeDocument(req.AssetId)
+	if errCode != common.Success {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    errCode,
+			"errMessage": errMsg,
+		})
+		return
+	}
+
+	issues := content_modifier.BehaviourTreeValidate(btDoc)
+	subtreeIssues, err := checkBehaviourTreeSubtreeReferences(db.Storage, req.AssetId, btDoc)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+	blackBoardIssues, err := checkBehaviourTreeBlackBoardReferences(db.Storage, req.AssetId, btDoc)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          common.Success,
+		"errMessage":       "",
+		"validationIssues": append(append(issues, subtreeIssues...), blackBoardIssues...),
+	})
 }
 
 func UpdateBehaviourTreeNodeSettingsAPI(context *gin.Context) {
@@ -231,8 +309,8 @@ func UpdateBehaviourTreeNodeSettingsAPI(context *gin.Context) {
 		return
 	}
 
-	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *UpdateBehaviourTreeNodeSettingsReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo) {
-		return content_modifier.BehaviourTreeUpdateNodeSettings(req.NodeId, req.NodeSettings, btDoc)
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *UpdateBehaviourTreeNodeSettingsReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithNodeDiffInfos(content_modifier.BehaviourTreeUpdateNodeSettings(req.NodeId, req.NodeSettings, btDoc))
 	})
 
 	context.JSON(http.StatusOK, gin.H{
@@ -253,7 +331,7 @@ func RemoveBehaviourTreeNodeAPI(context *gin.Context) {
 		return
 	}
 
-	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *RemoveBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo) {
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *RemoveBehaviourTreeNodeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
 		return content_modifier.BehaviourTreeRemoveNode(req.NodeIds, btDoc)
 	})
 
@@ -264,16 +342,25 @@ func RemoveBehaviourTreeNodeAPI(context *gin.Context) {
 	})
 }
 
-func passBehaviourTreeDocumentModification[T AssetModifier](req T, behaviourTreeModify func(req T, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []content_modifier.BehaviourTreeNodeDiffInfo)) (common.ErrorCode, string, *BehaviourTreeNodeModification) {
+func passBehaviourTreeDocumentModification[T AssetModifier](req T, behaviourTreeModify func(req T, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos)) (common.ErrorCode, string, *BehaviourTreeNodeModification) {
+	return passBehaviourTreeDocumentModificationInTx(req, func(repo db.Repository, req T, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return behaviourTreeModify(req, btDoc)
+	}, recordBehaviourTreeModificationHistory)
+}
+
+// passBehaviourTreeDocumentModificationInTx is the same as passBehaviourTreeDocumentModification,
+// but the modification is able to query in the transaction, and the way to record the history is customizable (for undo and redo)
+func passBehaviourTreeDocumentModificationInTx[T AssetModifier](req T, behaviourTreeModify func(repo db.Repository, req T, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos), recordHistory behaviourTreeHistoryRecorder) (common.ErrorCode, string, *BehaviourTreeNodeModification) {
 	var errCode = common.Success
 	var errMsg = ""
 	var modificationInfo *BehaviourTreeNodeModification = nil
 
-	err := db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var assetDetail common.AssetDetailInfo
+	err := db.Storage.Transaction(func(repo db.Repository) error {
+		var assetDetail *common.AssetDetailInfo
 		//Querying Pass
 		{
-			err := db.GormDatabase.First(&assetDetail, "id = ?", req.GetAssetID()).Error
+			var err error
+			assetDetail, err = repo.GetAssetForUpdate(req.GetAssetID())
 			if err != nil {
 				errCode = common.DataBaseError
 				errMsg = err.Error()
@@ -282,11 +369,23 @@ func passBehaviourTreeDocumentModification[T AssetModifier](req T, behaviourTree
 		}
 
 		//Version Checking Pass
+		//The Stale Modification Is Rebased Onto The Latest Version When The Recent History Is Able To Tell What Is Touched Since Then
+		baseVersion := assetDetail.AssetVersion
+		rebasing := false
+		var touchedIdsSinceRequestVersion content_modifier.BehaviourTreeTouchedIds
 		{
 			if assetDetail.AssetVersion != req.GetCurrentVersion() {
-				eMsg := common.InvalidAssetVersion.GetMsgFormat(assetDetail.AssetVersion, req.GetCurrentVersion())
-				errCode, errMsg = common.InvalidAssetVersion, eMsg
-				return errors.New(eMsg)
+				var err error
+				rebasing, touchedIdsSinceRequestVersion, err = loadBehaviourTreeRebaseChain(repo, req.GetAssetID(), req.GetCurrentVersion(), assetDetail.AssetVersion)
+				if err != nil {
+					errCode, errMsg = common.DataBaseError, err.Error()
+					return err
+				}
+				if !rebasing {
+					eMsg := common.InvalidAssetVersion.GetMsgFormat(assetDetail.AssetVersion, req.GetCurrentVersion())
+					errCode, errMsg = common.InvalidAssetVersion, eMsg
+					return errors.New(eMsg)
+				}
 			}
 		}
 
@@ -302,17 +401,78 @@ func passBehaviourTreeDocumentModification[T AssetModifier](req T, behaviourTree
 		}
 
 		//Real Modified Logic Pass
-		var diffInfos []content_modifier.BehaviourTreeNodeDiffInfo
+		var diffInfos content_modifier.BehaviourTreeDiffInfos
+		prevIssues, err := checkBehaviourTreeDocument(repo, req.GetAssetID(), &btDoc)
+		if err != nil {
+			errCode, errMsg = common.DataBaseError, err.Error()
+			return err
+		}
 		{
-			errCode, errMsg, diffInfos = behaviourTreeModify(req, &btDoc)
+			errCode, errMsg, diffInfos = behaviourTreeModify(repo, req, &btDoc)
 			if errCode != common.Success {
 				return errors.New(errMsg)
 			}
 		}
 
+		//Validation Pass, Only The Issues Introduced By This Modification Are Rejected
+		{
+			postIssues, err := checkBehaviourTreeDocument(repo, req.GetAssetID(), &btDoc)
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+			newIssues := content_modifier.BehaviourTreeFilterNewIssues(prevIssues, postIssues)
+			if len(newIssues) > 0 {
+				errCode = common.BtValidationFailed
+				errMsg = errCode.GetMsgFormat(len(newIssues), newIssues[0].ErrMessage)
+				modificationInfo = &BehaviourTreeNodeModification{
+					PrevVersion:      baseVersion,
+					NewVersion:       baseVersion,
+					ValidationIssues: newIssues,
+				}
+				return errors.New(errMsg)
+			}
+		}
+
+		//Settings Schema Pass, The Created Or Updated Settings Must Follow The Schemas Registered In The Solution
+		{
+			schemaIssues, err := checkBehaviourTreeSettingsSchemas(repo, req.GetAssetID(), diffInfos)
+			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
+				return err
+			}
+			if len(schemaIssues) > 0 {
+				errCode, errMsg = common.BtSettingsSchemaViolation, schemaIssues[0].ErrMessage
+				modificationInfo = &BehaviourTreeNodeModification{
+					PrevVersion:      baseVersion,
+					NewVersion:       baseVersion,
+					ValidationIssues: schemaIssues,
+				}
+				return errors.New(errMsg)
+			}
+		}
+
+		//Conflict Checking Pass, The Rebased Modification Must Not Touch Anything Touched Since The Request Version
+		if rebasing {
+			var touchedIds content_modifier.BehaviourTreeTouchedIds
+			touchedIds.Add(diffInfos)
+			contestedIds := touchedIds.Intersect(touchedIdsSinceRequestVersion)
+			if !contestedIds.IsEmpty() {
+				errCode = common.ConflictedAssetModification
+				errMsg = errCode.GetMsgFormat(req.GetCurrentVersion(), baseVersion)
+				modificationInfo = &BehaviourTreeNodeModification{
+					PrevVersion:        baseVersion,
+					NewVersion:         baseVersion,
+					RebasedFromVersion: req.GetCurrentVersion(),
+					ConflictReport:     &contestedIds,
+				}
+				return errors.New(errMsg)
+			}
+		}
+
 		//Write Modification
-		newVersion := req.GetCurrentVersion()
-		if len(diffInfos) > 0 { // just need real write data when there are some diffInfos
+		newVersion := baseVersion
+		if !diffInfos.IsEmpty() { // just need real write data when there are some diffInfos
 			//Serialization
 			modifiedContent, err := json.Marshal(btDoc)
 			if err != nil {
@@ -327,28 +487,56 @@ func passBehaviourTreeDocumentModification[T AssetModifier](req T, behaviourTree
 			assetDetail.AssetVersion = newVersion
 			assetDetail.AssetContent = string(modifiedContent)
 
-			err = tx.Save(assetDetail).Error
+			err = repo.SaveAsset(assetDetail)
 			if err != nil {
 				errCode = common.DataBaseError
 				eMsg := errCode.GetMsg()
 				errMsg = eMsg
 				return errors.New(eMsg)
 			}
+
+			//History Recording
+			err = recordHistory(repo, req.GetAssetID(), baseVersion, newVersion, diffInfos)
+			if err != nil {
+				errCode = common.DataBaseError
+				errMsg = err.Error()
+				return err
+			}
+
+			//Version Recording
+			err = RecordAssetVersion(repo, assetDetail, baseVersion, "", req.GetAuthor())
+			if err != nil {
+				errCode = common.DataBaseError
+				errMsg = err.Error()
+				return err
+			}
 		}
 
 		//All Pass
 		//Calculate Modification Info
 		modificationInfo = &BehaviourTreeNodeModification{
-			diffInfos,
-			req.GetCurrentVersion(),
-			newVersion}
+			DiffNodesInfos:       diffInfos.NodeDiffInfos,
+			DiffDescriptorsInfos: diffInfos.DescriptorDiffInfos,
+			DiffServicesInfos:    diffInfos.ServiceDiffInfos,
+			DiffBlackBoardInfo:   diffInfos.BlackBoardDiffInfo,
+			PrevVersion:          baseVersion,
+			NewVersion:           newVersion,
+		}
+		if rebasing {
+			modificationInfo.RebasedFromVersion = req.GetCurrentVersion()
+		}
 
 		return nil
 	})
 
 	if err != nil {
 		zap.S().Error(err)
-		return errCode, errMsg, nil
+		return errCode, errMsg, modificationInfo // Only Carries The Validation Issues Or The Conflict Report When Failed
+	}
+
+	//Notify The Other Editors After The Modification Is Committed, Nothing Is Notified When No New Version Is Written
+	if modificationInfo.NewVersion != modificationInfo.PrevVersion {
+		modificationBroker.Publish(req.GetAssetID(), modificationInfo)
 	}
 	return common.Success, "", modificationInfo
 }
@@ -370,42 +558,99 @@ func GetArchivedBehaviourTreeAsset(assetDetail *common.AssetDetailInfo) (common.
 	archivedDoc.AssetName = assetDetail.AssetName
 	archivedDoc.AssetId = assetDetail.AssetId
 	archivedDoc.AssetVersion = assetDetail.AssetVersion
+	archivedDoc.BlackBoardAssetId = btDoc.BlackBoardAssetId
+	archivedDoc.SubtreeAssetIds = make([]string, 0)
+	for _, reference := range content_modifier.BehaviourTreeCollectSubtreeReferences(&btDoc) {
+		if !slices.Contains(archivedDoc.SubtreeAssetIds, reference.SubtreeAssetId) {
+			archivedDoc.SubtreeAssetIds = append(archivedDoc.SubtreeAssetIds, reference.SubtreeAssetId)
+		}
+	}
 
 	archivedNodes := make([]map[string]interface{}, 0, len(btDoc.Nodes))
 	for _, node := range btDoc.Nodes {
 		archivedNode := make(map[string]interface{})
-		//fmt.Printf("%v %d \n", node.Settings, len(node.Settings))
+		if len(node.Settings) > 0 && string(node.Settings) != "null" {
+			err := json.Unmarshal(node.Settings, &archivedNode)
+			if err != nil {
+				return common.DeserializationError, err.Error(), nil
+			}
+		}
+		// The Reserved Keys Are Written After The Settings, So The Settings Never Overwrite Them
 		archivedNode["id"] = node.NodeId
 		archivedNode["type"] = node.NodeType
 		archivedNode["order"] = node.Order
 		archivedNode["parentId"] = node.ParentId
-		//fmt.Printf("before desirialize %v \n", archivedNode)
-		if string(node.Settings) != "null" {
-			fmt.Printf("umarshal ... %s \n", string(node.Settings))
-			err := json.Unmarshal(node.Settings, &archivedNode)
+		if node.NodeType == content_modifier.Node_SimpleParallel {
+			archivedNode[content_modifier.SimpleParallelFinishModeKey] = content_modifier.GetSimpleParallelFinishMode(node.Settings)
+		}
+		if node.NodeType == content_modifier.Node_RunSubtree {
+			archivedNode[content_modifier.RunSubtreeAssetIdKey] = content_modifier.GetRunSubtreeAssetId(node.Settings)
+		}
+
+		archivedNodes = append(archivedNodes, archivedNode)
+	}
+
+	{
+		serializationNodes, err := json.Marshal(&archivedNodes)
+		if err != nil {
+			return common.SerializationError, err.Error(), nil
+		}
+
+		archivedDoc.BehaviourTreeNodes = string(serializationNodes)
+	}
+
+	archivedDescriptors := make([]map[string]interface{}, 0, len(btDoc.Descriptors))
+	for _, descriptor := range btDoc.Descriptors {
+		archivedDescriptor := make(map[string]interface{})
+		if len(descriptor.Settings) > 0 && string(descriptor.Settings) != "null" {
+			err := json.Unmarshal(descriptor.Settings, &archivedDescriptor)
 			if err != nil {
 				return common.DeserializationError, err.Error(), nil
 			}
 		}
-		//fmt.Printf("after desirialize %v \n", archivedNode)
+		archivedDescriptor["id"] = descriptor.DescriptorId
+		archivedDescriptor["type"] = descriptor.DescriptorType
+		archivedDescriptor["order"] = descriptor.Order
+		archivedDescriptor["attachTo"] = descriptor.AttachTo
+		archivedDescriptors = append(archivedDescriptors, archivedDescriptor)
+	}
 
-		////
-		//fmt.Printf("%v \n", archivedNode)
+	{
+		serializationDescriptors, err := json.Marshal(&archivedDescriptors)
+		if err != nil {
+			return common.SerializationError, err.Error(), nil
+		}
 
-		archivedNodes = append(archivedNodes, archivedNode)
+		archivedDoc.BehaviourTreeDescriptors = string(serializationDescriptors)
+	}
+
+	archivedServices := make([]map[string]interface{}, 0, len(btDoc.Services))
+	for _, service := range btDoc.Services {
+		archivedService := make(map[string]interface{})
+		if len(service.Settings) > 0 && string(service.Settings) != "null" {
+			err := json.Unmarshal(service.Settings, &archivedService)
+			if err != nil {
+				return common.DeserializationError, err.Error(), nil
+			}
+		}
+		archivedService["id"] = service.ServiceId
+		archivedService["type"] = service.ServiceType
+		archivedService["order"] = service.Order
+		archivedService["attachTo"] = service.AttachTo
+		archivedService["tickInterval"] = service.TickInterval
+		archivedService["randomDeviation"] = service.RandomDeviation
+		archivedServices = append(archivedServices, archivedService)
 	}
 
 	{
-		serializationNodes, err := json.Marshal(&archivedNodes)
+		serializationServices, err := json.Marshal(&archivedServices)
 		if err != nil {
 			return common.SerializationError, err.Error(), nil
 		}
 
-		archivedDoc.BehaviourTreeNodes = string(serializationNodes)
+		archivedDoc.BehaviourTreeServices = string(serializationServices)
 	}
 
-	archivedDoc.BehaviourTreeDescriptors = "[]"
-	archivedDoc.BehaviourTreeServices = "[]"
 	return common.Success, "", &archivedDoc
 
 }
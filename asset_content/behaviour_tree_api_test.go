@@ -0,0 +1,567 @@
+package asset_content
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type modificationResponse struct {
+	ErrCode          common.ErrorCode               `json:"errCode"`
+	ErrMessage       string                         `json:"errMessage"`
+	ModificationInfo *BehaviourTreeNodeModification `json:"modificationInfo"`
+}
+
+type restorationResponse struct {
+	ErrCode     common.ErrorCode         `json:"errCode"`
+	ErrMessage  string                   `json:"errMessage"`
+	Restoration *AssetVersionRestoration `json:"restoration"`
+}
+
+// newTestRouter serves the handlers with an in-memory repository holding an empty asset set, its id is returned
+func newTestRouter(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db.Storage = db.NewMemoryRepository()
+	router := gin.New()
+	InitializeAssetManagement(router.Group("API/AssetContentModifier"))
+
+	solution := common.SolutionDetailInfo{SolutionId: uuid.New().String(), SolutionName: "Solution", SolutionMeta: json.RawMessage("{}"), SolutionVersion: uuid.New().String()}
+	assetSet := common.AssetSetInfoItem{AssetSetId: uuid.New().String(), SolutionId: solution.SolutionId, AssetSetName: "Set"}
+	if err := db.Storage.CreateSolution(&solution); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Storage.CreateAssetSet(&assetSet); err != nil {
+		t.Fatal(err)
+	}
+	return router, assetSet.AssetSetId
+}
+
+// createTestAsset creates the asset with the content like CreateAssetAPI, the id and the version are returned
+func createTestAsset(t *testing.T, assetSetId string, assetType string, assetName string, content string) (string, string) {
+	t.Helper()
+	asset := common.AssetDetailInfo{AssetId: uuid.New().String(), AssetSetId: assetSetId, AssetType: assetType, AssetName: assetName, AssetVersion: uuid.New().String(), AssetContent: content}
+	if err := db.Storage.CreateAsset(&asset); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordAssetVersion(db.Storage, &asset, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	return asset.AssetId, asset.AssetVersion
+}
+
+// createTestBehaviourTree creates the empty behaviour tree like CreateAssetAPI, the id and the version are returned
+func createTestBehaviourTree(t *testing.T, assetSetId string, assetName string) (string, string) {
+	t.Helper()
+	errCode, errMsg, content := content_modifier.BehaviourTreeCreateEmptyContent()
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	return createTestAsset(t, assetSetId, "BehaviourTree", assetName, content)
+}
+
+// assetVersionOf returns the current version of the asset
+func assetVersionOf(t *testing.T, assetId string) string {
+	t.Helper()
+	assetDetail, err := db.Storage.GetAsset(assetId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return assetDetail.AssetVersion
+}
+
+// callAPI posts the request as JSON and decodes the response into resp
+func callAPI(t *testing.T, router *gin.Engine, name string, req interface{}, resp interface{}) {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/API/AssetContentModifier/"+name, bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("%s responds the status %d", name, recorder.Code)
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), resp)
+	if err != nil {
+		t.Fatalf("%s responds the invalid JSON %s: %v", name, recorder.Body.String(), err)
+	}
+}
+
+func modifyBehaviourTree(t *testing.T, router *gin.Engine, name string, req gin.H, errCode common.ErrorCode) *BehaviourTreeNodeModification {
+	t.Helper()
+	var resp modificationResponse
+	callAPI(t, router, name, req, &resp)
+	if resp.ErrCode != errCode {
+		t.Fatalf("%s responds the errCode %d (%s), expected %d", name, resp.ErrCode, resp.ErrMessage, errCode)
+	}
+	return resp.ModificationInfo
+}
+
+func readBehaviourTree(t *testing.T, assetId string) (string, content_modifier.BehaviourTreeDocumentation) {
+	t.Helper()
+	assetDetail, err := db.Storage.GetAsset(assetId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var btDoc content_modifier.BehaviourTreeDocumentation
+	if err = json.Unmarshal([]byte(assetDetail.AssetContent), &btDoc); err != nil {
+		t.Fatal(err)
+	}
+	return assetDetail.AssetVersion, btDoc
+}
+
+func createNodeReq(assetId string, currentVersion string, nodeType string, initialSettings interface{}) gin.H {
+	return gin.H{"assetId": assetId, "currentVersion": currentVersion, "nodeType": nodeType, "position": gin.H{"x": 1, "y": 1}, "initialSettings": initialSettings}
+}
+
+func TestBehaviourTreeModificationVersionChecking(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+
+	modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, "unknown", content_modifier.Node_Sequence, nil), common.InvalidAssetVersion)
+
+	modificationInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success)
+	latestVersion, btDoc := readBehaviourTree(t, assetId)
+	if modificationInfo.PrevVersion != version || modificationInfo.NewVersion != latestVersion || len(btDoc.Nodes) != 2 {
+		t.Fatalf("the node isn't created as a new version: %+v", modificationInfo)
+	}
+}
+
+func TestBehaviourTreeModificationRebasing(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	nodeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	baseVersion, _ := readBehaviourTree(t, assetId)
+
+	moveReq := func(x float64) gin.H {
+		return gin.H{"assetId": assetId, "currentVersion": baseVersion, "movements": []gin.H{{"nodeId": nodeId, "toPosition": gin.H{"x": x, "y": 0}}}}
+	}
+	modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(10), common.Success)
+	movedVersion, _ := readBehaviourTree(t, assetId)
+
+	//The Stale Modification Touching The Same Node Is Conflicted
+	conflictInfo := modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(20), common.ConflictedAssetModification)
+	if conflictInfo.ConflictReport == nil || len(conflictInfo.ConflictReport.NodeIds) != 1 || conflictInfo.ConflictReport.NodeIds[0] != nodeId {
+		t.Fatalf("the contested node isn't reported: %+v", conflictInfo)
+	}
+	if latestVersion, _ := readBehaviourTree(t, assetId); latestVersion != movedVersion {
+		t.Fatal("the conflicted modification is written")
+	}
+
+	//The Stale Modification Touching Something Else Is Rebased
+	rebasedInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, baseVersion, content_modifier.Node_Sequence, nil), common.Success)
+	latestVersion, btDoc := readBehaviourTree(t, assetId)
+	if rebasedInfo.RebasedFromVersion != baseVersion || rebasedInfo.PrevVersion != movedVersion || rebasedInfo.NewVersion != latestVersion || len(btDoc.Nodes) != 3 {
+		t.Fatalf("the modification isn't rebased onto the latest version: %+v", rebasedInfo)
+	}
+}
+
+func TestBehaviourTreeModificationRebasingConflictReport(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	nodeIds := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		latestVersion, _ := readBehaviourTree(t, assetId)
+		nodeIds = append(nodeIds, modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, latestVersion, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId)
+	}
+	baseVersion, _ := readBehaviourTree(t, assetId)
+	if baseVersion == version {
+		t.Fatal("the nodes aren't created")
+	}
+
+	moveReq := func(currentVersion string, x float64, movedNodeIds ...string) gin.H {
+		movements := make([]gin.H, 0, len(movedNodeIds))
+		for _, nodeId := range movedNodeIds {
+			movements = append(movements, gin.H{"nodeId": nodeId, "toPosition": gin.H{"x": x, "y": 0}})
+		}
+		return gin.H{"assetId": assetId, "currentVersion": currentVersion, "movements": movements}
+	}
+	modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(baseVersion, 10, nodeIds[0], nodeIds[1]), common.Success)
+
+	//Only The Node Touched By Both Modifications Is Contested
+	conflictInfo := modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(baseVersion, 20, nodeIds[1], nodeIds[2]), common.ConflictedAssetModification)
+	if conflictInfo.ConflictReport == nil || len(conflictInfo.ConflictReport.NodeIds) != 1 || conflictInfo.ConflictReport.NodeIds[0] != nodeIds[1] {
+		t.Fatalf("the contested node isn't reported: %+v", conflictInfo.ConflictReport)
+	}
+	if conflictInfo.RebasedFromVersion != baseVersion {
+		t.Fatalf("the request version isn't reported: %+v", conflictInfo)
+	}
+
+	conflictInfo = modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(baseVersion, 20, nodeIds[0], nodeIds[1]), common.ConflictedAssetModification)
+	contestedIds := map[string]bool{}
+	for _, nodeId := range conflictInfo.ConflictReport.NodeIds {
+		contestedIds[nodeId] = true
+	}
+	if len(contestedIds) != 2 || !contestedIds[nodeIds[0]] || !contestedIds[nodeIds[1]] {
+		t.Fatalf("the contested nodes aren't reported: %+v", conflictInfo.ConflictReport)
+	}
+}
+
+func TestBehaviourTreeModificationRebasingDepth(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	nodeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	baseVersion, _ := readBehaviourTree(t, assetId)
+
+	for i := 0; i < MaxRebaseHistoryDepth; i++ {
+		latestVersion, _ := readBehaviourTree(t, assetId)
+		modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", gin.H{"assetId": assetId, "currentVersion": latestVersion, "movements": []gin.H{{"nodeId": nodeId, "toPosition": gin.H{"x": i + 10, "y": 0}}}}, common.Success)
+	}
+
+	//The Modification Just As Stale As The Depth Is Rebased, Which Makes The Base Version One Step Deeper
+	rebasedInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, baseVersion, content_modifier.Node_Sequence, nil), common.Success)
+	if rebasedInfo.RebasedFromVersion != baseVersion {
+		t.Fatalf("the modification isn't rebased over %d versions: %+v", MaxRebaseHistoryDepth, rebasedInfo)
+	}
+	latestVersion, _ := readBehaviourTree(t, assetId)
+	modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, baseVersion, content_modifier.Node_Sequence, nil), common.InvalidAssetVersion)
+	if writtenVersion, _ := readBehaviourTree(t, assetId); writtenVersion != latestVersion {
+		t.Fatal("the modification staler than the depth is written")
+	}
+}
+
+func TestBehaviourTreeModificationRebasingOverUndo(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	nodeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	baseVersion, _ := readBehaviourTree(t, assetId)
+
+	moveReq := func(currentVersion string, x float64) gin.H {
+		return gin.H{"assetId": assetId, "currentVersion": currentVersion, "movements": []gin.H{{"nodeId": nodeId, "toPosition": gin.H{"x": x, "y": 0}}}}
+	}
+	modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(baseVersion, 10), common.Success)
+	movedVersion, _ := readBehaviourTree(t, assetId)
+	modifyBehaviourTree(t, router, "UndoBehaviourTreeModification", gin.H{"assetId": assetId, "currentVersion": movedVersion}, common.Success)
+	undoneVersion, _ := readBehaviourTree(t, assetId)
+
+	//The Node Moved Back By The Undo Is Still Touched Since The Base Version
+	conflictInfo := modifyBehaviourTree(t, router, "MoveBehaviourTreeNode", moveReq(baseVersion, 20), common.ConflictedAssetModification)
+	if conflictInfo.ConflictReport == nil || len(conflictInfo.ConflictReport.NodeIds) != 1 || conflictInfo.ConflictReport.NodeIds[0] != nodeId {
+		t.Fatalf("the node touched by the undo isn't reported: %+v", conflictInfo.ConflictReport)
+	}
+
+	rebasedInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, baseVersion, content_modifier.Node_Sequence, nil), common.Success)
+	latestVersion, btDoc := readBehaviourTree(t, assetId)
+	if rebasedInfo.RebasedFromVersion != baseVersion || rebasedInfo.PrevVersion != undoneVersion || rebasedInfo.NewVersion != latestVersion || len(btDoc.Nodes) != 3 {
+		t.Fatalf("the modification isn't rebased over the undo: %+v", rebasedInfo)
+	}
+}
+
+func TestBehaviourTreeModificationValidation(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+
+	settings := gin.H{content_modifier.RunSubtreeAssetIdKey: uuid.New().String()}
+	rejectedInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_RunSubtree, settings), common.BtValidationFailed)
+	if len(rejectedInfo.ValidationIssues) != 1 || rejectedInfo.ValidationIssues[0].ErrCode != common.BtValidateInvalidSubtreeAsset {
+		t.Fatalf("the new issue isn't reported: %+v", rejectedInfo.ValidationIssues)
+	}
+	if latestVersion, btDoc := readBehaviourTree(t, assetId); latestVersion != version || len(btDoc.Nodes) != 1 {
+		t.Fatal("the rejected modification is written")
+	}
+}
+
+func TestBehaviourTreeUndoRedo(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	historyReq := func() gin.H {
+		latestVersion, _ := readBehaviourTree(t, assetId)
+		return gin.H{"assetId": assetId, "currentVersion": latestVersion}
+	}
+
+	modifyBehaviourTree(t, router, "UndoBehaviourTreeModification", historyReq(), common.BtHistoryNothingToUndo)
+	modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success)
+
+	modifyBehaviourTree(t, router, "UndoBehaviourTreeModification", historyReq(), common.Success)
+	if _, btDoc := readBehaviourTree(t, assetId); len(btDoc.Nodes) != 1 {
+		t.Fatalf("the created node isn't removed by undo: %+v", btDoc.Nodes)
+	}
+	modifyBehaviourTree(t, router, "UndoBehaviourTreeModification", historyReq(), common.BtHistoryNothingToUndo)
+
+	modifyBehaviourTree(t, router, "RedoBehaviourTreeModification", historyReq(), common.Success)
+	if _, btDoc := readBehaviourTree(t, assetId); len(btDoc.Nodes) != 2 {
+		t.Fatalf("the removed node isn't created again by redo: %+v", btDoc.Nodes)
+	}
+	modifyBehaviourTree(t, router, "RedoBehaviourTreeModification", historyReq(), common.BtHistoryNothingToRedo)
+}
+
+func TestRestoringBehaviourTreeIsValidated(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	treeA, versionA := createTestBehaviourTree(t, assetSetId, "A")
+	treeB, versionB := createTestBehaviourTree(t, assetSetId, "B")
+
+	//A Refers B, Then The Reference Is Removed And B Refers A
+	referenceNodeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode",
+		createNodeReq(treeA, versionA, content_modifier.Node_RunSubtree, gin.H{content_modifier.RunSubtreeAssetIdKey: treeB}), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	referringVersion, _ := readBehaviourTree(t, treeA)
+	modifyBehaviourTree(t, router, "RemoveBehaviourTreeNode", gin.H{"assetId": treeA, "currentVersion": referringVersion, "nodeIds": []string{referenceNodeId}}, common.Success)
+	modifyBehaviourTree(t, router, "CreateBehaviourTreeNode",
+		createNodeReq(treeB, versionB, content_modifier.Node_RunSubtree, gin.H{content_modifier.RunSubtreeAssetIdKey: treeA}), common.Success)
+
+	//Restoring The Reference Of A Makes A Recursion
+	latestVersion, _ := readBehaviourTree(t, treeA)
+	var resp restorationResponse
+	callAPI(t, router, "RestoreAssetVersion", gin.H{"assetId": treeA, "currentVersion": latestVersion, "restoringVersion": referringVersion}, &resp)
+	if resp.ErrCode != common.BtValidationFailed || resp.Restoration == nil || len(resp.Restoration.ValidationIssues) != 1 ||
+		resp.Restoration.ValidationIssues[0].ErrCode != common.BtValidateSubtreeRecursion {
+		t.Fatalf("the recursion isn't rejected: %d (%s) %+v", resp.ErrCode, resp.ErrMessage, resp.Restoration)
+	}
+	if restoredVersion, _ := readBehaviourTree(t, treeA); restoredVersion != latestVersion {
+		t.Fatal("the rejected restoring is written")
+	}
+
+	//Restoring The First Version Is Valid, Nothing Is Written Since Its Content Is The Same
+	resp = restorationResponse{}
+	callAPI(t, router, "RestoreAssetVersion", gin.H{"assetId": treeA, "currentVersion": latestVersion, "restoringVersion": versionA}, &resp)
+	if resp.ErrCode != common.Success || resp.Restoration.NewVersion != latestVersion {
+		t.Fatalf("the first version isn't restored: %d (%s) %+v", resp.ErrCode, resp.ErrMessage, resp.Restoration)
+	}
+}
+
+func TestDuplicatingBehaviourTreeSubtreeChecksSourceAsset(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	duplicateReq := func(sourceAssetId string) gin.H {
+		return gin.H{"assetId": assetId, "currentVersion": version, "sourceAssetId": sourceAssetId, "rootNodeIds": []string{"any"}, "offset": gin.H{"x": 0, "y": 0}}
+	}
+
+	modifyBehaviourTree(t, router, "DuplicateBehaviourTreeSubtree", duplicateReq(uuid.New().String()), common.InvalidAsset)
+
+	//The Source Asset Of Another Solution Is Rejected
+	otherSolution := common.SolutionDetailInfo{SolutionId: uuid.New().String(), SolutionName: "Other", SolutionMeta: json.RawMessage("{}"), SolutionVersion: uuid.New().String()}
+	otherAssetSet := common.AssetSetInfoItem{AssetSetId: uuid.New().String(), SolutionId: otherSolution.SolutionId, AssetSetName: "Set"}
+	if err := db.Storage.CreateSolution(&otherSolution); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Storage.CreateAssetSet(&otherAssetSet); err != nil {
+		t.Fatal(err)
+	}
+	otherAssetId, _ := createTestBehaviourTree(t, otherAssetSet.AssetSetId, "Tree")
+	modifyBehaviourTree(t, router, "DuplicateBehaviourTreeSubtree", duplicateReq(otherAssetId), common.BtDuplicateSourceAssetOutOfSolution)
+
+	if latestVersion, _ := readBehaviourTree(t, assetId); latestVersion != version {
+		t.Fatal("the rejected duplication is written")
+	}
+}
+
+func TestSubscribingUnknownBehaviourTreeIsRejected(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/API/AssetContentModifier/SubscribeBehaviourTreeModifications?assetId="+uuid.New().String(), nil))
+	var resp modificationResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("the subscription of the unknown asset is streamed: %s", recorder.Body.String())
+	}
+	if resp.ErrCode != common.InvalidAsset {
+		t.Fatalf("the subscription of the unknown asset responds the errCode %d (%s)", resp.ErrCode, resp.ErrMessage)
+	}
+}
+
+func TestBehaviourTreeDescriptors(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	nodeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	modificationReq := func(fields gin.H) gin.H {
+		fields["assetId"], fields["currentVersion"] = assetId, assetVersionOf(t, assetId)
+		return fields
+	}
+
+	descriptorIds := make([]string, 0, 2)
+	for _, descriptorType := range []string{"Cooldown", "Loop"} {
+		modificationInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeDescriptor", modificationReq(gin.H{"attachTo": nodeId, "descriptorType": descriptorType}), common.Success)
+		descriptorIds = append(descriptorIds, modificationInfo.DiffDescriptorsInfos[0].ModifiedDescriptorId)
+	}
+	modifyBehaviourTree(t, router, "CreateBehaviourTreeDescriptor", modificationReq(gin.H{"attachTo": uuid.New().String(), "descriptorType": "Loop"}), common.BtDescriptorInvalidAttachTarget)
+
+	modifyBehaviourTree(t, router, "ReorderBehaviourTreeDescriptors", modificationReq(gin.H{"nodeId": nodeId, "orderedDescriptorIds": []string{descriptorIds[0]}}), common.BtReorderDescriptorsMismatch)
+	modifyBehaviourTree(t, router, "ReorderBehaviourTreeDescriptors", modificationReq(gin.H{"nodeId": nodeId, "orderedDescriptorIds": []string{descriptorIds[1], descriptorIds[0]}}), common.Success)
+	modifyBehaviourTree(t, router, "UpdateBehaviourTreeDescriptorSettings", modificationReq(gin.H{"descriptorId": descriptorIds[0], "settings": gin.H{"duration": 3}}), common.Success)
+	_, btDoc := readBehaviourTree(t, assetId)
+	for _, descriptor := range btDoc.Descriptors {
+		if descriptor.DescriptorId == descriptorIds[0] && (descriptor.Order != 1 || string(descriptor.Settings) != `{"duration":3}`) {
+			t.Fatalf("the descriptor isn't reordered and updated: %+v", descriptor)
+		}
+	}
+
+	modifyBehaviourTree(t, router, "RemoveBehaviourTreeDescriptor", modificationReq(gin.H{"descriptorIds": descriptorIds}), common.Success)
+	if _, btDoc = readBehaviourTree(t, assetId); len(btDoc.Descriptors) != 0 {
+		t.Fatalf("the descriptors aren't removed: %+v", btDoc.Descriptors)
+	}
+}
+
+func TestBehaviourTreeServices(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	compositeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	taskId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, assetVersionOf(t, assetId), content_modifier.Node_Task, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	modificationReq := func(fields gin.H) gin.H {
+		fields["assetId"], fields["currentVersion"] = assetId, assetVersionOf(t, assetId)
+		return fields
+	}
+
+	modifyBehaviourTree(t, router, "CreateBehaviourTreeService", modificationReq(gin.H{"attachTo": taskId, "serviceType": "Scan"}), common.BtServiceIllegalAttachTarget)
+	serviceIds := make([]string, 0, 2)
+	for _, serviceType := range []string{"Scan", "Aim"} {
+		modificationInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeService", modificationReq(gin.H{"attachTo": compositeId, "serviceType": serviceType}), common.Success)
+		serviceIds = append(serviceIds, modificationInfo.DiffServicesInfos[0].ModifiedServiceId)
+	}
+
+	modifyBehaviourTree(t, router, "ReorderBehaviourTreeServices", modificationReq(gin.H{"nodeId": compositeId, "orderedServiceIds": []string{serviceIds[1], serviceIds[0]}}), common.Success)
+	modifyBehaviourTree(t, router, "UpdateBehaviourTreeServiceSettings", modificationReq(gin.H{"serviceId": serviceIds[0], "serviceType": "Scan", "tickInterval": -1}), common.BtServiceInvalidTickInterval)
+	modifyBehaviourTree(t, router, "UpdateBehaviourTreeServiceSettings", modificationReq(gin.H{"serviceId": serviceIds[0], "serviceType": "Scan", "tickInterval": 2}), common.Success)
+	_, btDoc := readBehaviourTree(t, assetId)
+	for _, service := range btDoc.Services {
+		if service.ServiceId == serviceIds[0] && (service.Order != 1 || service.TickInterval != 2) {
+			t.Fatalf("the service isn't reordered and updated: %+v", service)
+		}
+	}
+
+	//Removing The Composite Removes Its Services
+	modifyBehaviourTree(t, router, "RemoveBehaviourTreeNode", modificationReq(gin.H{"nodeIds": []string{compositeId}}), common.Success)
+	if _, btDoc = readBehaviourTree(t, assetId); len(btDoc.Services) != 0 {
+		t.Fatalf("the services of the removed node aren't removed: %+v", btDoc.Services)
+	}
+}
+
+func TestBehaviourTreeBatchOperations(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	_, btDoc := readBehaviourTree(t, assetId)
+	rootId := btDoc.Nodes[0].NodeId
+	batchReq := func(operations ...gin.H) gin.H {
+		return gin.H{"assetId": assetId, "currentVersion": assetVersionOf(t, assetId), "operations": operations}
+	}
+
+	//Nothing Is Written When Any Operation Fails
+	modifyBehaviourTree(t, router, "ApplyBehaviourTreeOperations", batchReq(
+		gin.H{"operation": content_modifier.Operation_CreateNode, "ref": "sequence", "nodeType": content_modifier.Node_Sequence},
+		gin.H{"operation": content_modifier.Operation_ConnectNode, "parentNodeId": rootId, "childNodeId": "$unknown"},
+	), common.BtBatchUnknownReference)
+	if latestVersion, _ := readBehaviourTree(t, assetId); latestVersion != version {
+		t.Fatal("the failed batch is written")
+	}
+
+	var resp struct {
+		modificationResponse
+		CreatedNodeIds map[string]string `json:"createdNodeIds"`
+	}
+	callAPI(t, router, "ApplyBehaviourTreeOperations", batchReq(
+		gin.H{"operation": content_modifier.Operation_CreateNode, "ref": "sequence", "nodeType": content_modifier.Node_Sequence},
+		gin.H{"operation": content_modifier.Operation_CreateNode, "ref": "task", "nodeType": content_modifier.Node_Task, "position": gin.H{"x": 0, "y": 100}},
+		gin.H{"operation": content_modifier.Operation_ConnectNode, "parentNodeId": rootId, "childNodeId": "$sequence"},
+		gin.H{"operation": content_modifier.Operation_ConnectNode, "parentNodeId": "$sequence", "childNodeId": "$task"},
+	), &resp)
+	if resp.ErrCode != common.Success {
+		t.Fatalf("ApplyBehaviourTreeOperations responds the errCode %d (%s)", resp.ErrCode, resp.ErrMessage)
+	}
+	latestVersion, btDoc := readBehaviourTree(t, assetId)
+	if resp.ModificationInfo.PrevVersion != version || resp.ModificationInfo.NewVersion != latestVersion || len(btDoc.Nodes) != 3 || len(resp.CreatedNodeIds) != 2 {
+		t.Fatalf("the batch isn't written as one version: %+v", resp.ModificationInfo)
+	}
+	for _, node := range btDoc.Nodes {
+		if node.NodeId == resp.CreatedNodeIds["task"] && node.ParentId != resp.CreatedNodeIds["sequence"] {
+			t.Fatalf("the references aren't resolved: %+v", node)
+		}
+	}
+}
+
+func TestDuplicatingBehaviourTreeSubtreeAcrossAssets(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	sourceId, sourceVersion := createTestBehaviourTree(t, assetSetId, "Source")
+	targetId, targetVersion := createTestBehaviourTree(t, assetSetId, "Target")
+	sequenceId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(sourceId, sourceVersion, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	taskId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(sourceId, assetVersionOf(t, sourceId), content_modifier.Node_Task, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	modifyBehaviourTree(t, router, "ConnectBehaviourTreeNode", gin.H{"assetId": sourceId, "currentVersion": assetVersionOf(t, sourceId), "parentNodeId": sequenceId, "childNodeId": taskId}, common.Success)
+
+	var resp struct {
+		modificationResponse
+		DuplicatedNodeIds map[string]string `json:"duplicatedNodeIds"`
+	}
+	callAPI(t, router, "DuplicateBehaviourTreeSubtree", gin.H{"assetId": targetId, "currentVersion": targetVersion, "sourceAssetId": sourceId, "rootNodeIds": []string{sequenceId}, "offset": gin.H{"x": 50, "y": 0}}, &resp)
+	if resp.ErrCode != common.Success {
+		t.Fatalf("DuplicateBehaviourTreeSubtree responds the errCode %d (%s)", resp.ErrCode, resp.ErrMessage)
+	}
+	if len(resp.DuplicatedNodeIds) != 2 || resp.DuplicatedNodeIds[sequenceId] == "" || resp.DuplicatedNodeIds[sequenceId] == sequenceId {
+		t.Fatalf("the ids aren't remapped: %+v", resp.DuplicatedNodeIds)
+	}
+	_, btDoc := readBehaviourTree(t, targetId)
+	for _, node := range btDoc.Nodes {
+		if node.NodeId == resp.DuplicatedNodeIds[taskId] && node.ParentId != resp.DuplicatedNodeIds[sequenceId] {
+			t.Fatalf("the duplicated connection isn't remapped: %+v", node)
+		}
+	}
+	if len(btDoc.Nodes) != 3 {
+		t.Fatalf("%d nodes are in the target, expected 3", len(btDoc.Nodes))
+	}
+}
+
+func TestBehaviourTreeSettingsSchemaViolation(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	assetSet, err := db.Storage.GetAssetSet(assetSetId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaItem := common.SettingsSchemaItem{
+		SchemaId:   uuid.New().String(),
+		SolutionId: assetSet.SolutionId,
+		Category:   content_modifier.SettingsSchemaCategory_Task,
+		TypeName:   "MoveTo",
+		Schema:     json.RawMessage(`{"type":"object","properties":{"speed":{"type":"number","minimum":0}},"required":["speed"]}`),
+	}
+	if err = db.Storage.SaveSettingsSchema(&schemaItem); err != nil {
+		t.Fatal(err)
+	}
+
+	//Creating
+	rejectedInfo := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Task, gin.H{"taskType": "MoveTo", "speed": -1}), common.BtSettingsSchemaViolation)
+	if len(rejectedInfo.ValidationIssues) != 1 || rejectedInfo.ValidationIssues[0].ErrCode != common.BtSettingsSchemaViolation {
+		t.Fatalf("the violation isn't reported: %+v", rejectedInfo.ValidationIssues)
+	}
+	if latestVersion, _ := readBehaviourTree(t, assetId); latestVersion != version {
+		t.Fatal("the node violating the schema is created")
+	}
+	taskId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Task, gin.H{"taskType": "MoveTo", "speed": 2}), common.Success).DiffNodesInfos[0].ModifiedNodeId
+
+	//Updating
+	version = assetVersionOf(t, assetId)
+	updateReq := gin.H{"assetId": assetId, "currentVersion": version, "nodeId": taskId, "settings": gin.H{"taskType": "MoveTo"}}
+	rejectedInfo = modifyBehaviourTree(t, router, "UpdateBehaviourTreeNodeSettings", updateReq, common.BtSettingsSchemaViolation)
+	if len(rejectedInfo.ValidationIssues) != 1 || rejectedInfo.ValidationIssues[0].NodeId != taskId {
+		t.Fatalf("the violation of the task isn't reported: %+v", rejectedInfo.ValidationIssues)
+	}
+	if latestVersion, _ := readBehaviourTree(t, assetId); latestVersion != version {
+		t.Fatal("the settings violating the schema are written")
+	}
+	updateReq["settings"] = gin.H{"taskType": "MoveTo", "speed": 0}
+	modifyBehaviourTree(t, router, "UpdateBehaviourTreeNodeSettings", updateReq, common.Success)
+}
+
+func TestBehaviourTreeLoadingChecksTheAsset(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	errCode, errMsg, content := content_modifier.BlackBoardCreateEmptyContent()
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	blackBoardId, _ := createTestAsset(t, assetSetId, "BlackBoard", "Board", content)
+	assetId, _ := createTestBehaviourTree(t, assetSetId, "Tree")
+
+	for _, name := range []string{"ValidateBehaviourTree", "SimulateBehaviourTree"} {
+		for requestedId, errCode := range map[string]common.ErrorCode{uuid.New().String(): common.InvalidAsset, blackBoardId: common.MismatchedAssetType, assetId: common.Success} {
+			var resp modificationResponse
+			callAPI(t, router, name, gin.H{"assetId": requestedId}, &resp)
+			if resp.ErrCode != errCode {
+				t.Fatalf("%s responds the errCode %d (%s), expected %d", name, resp.ErrCode, resp.ErrMessage, errCode)
+			}
+		}
+	}
+}
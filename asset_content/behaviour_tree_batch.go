@@ -0,0 +1,46 @@
+package asset_content
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"net/http"
+)
+
+type ApplyBehaviourTreeOperationsReq struct {
+	BaseBehaviourTreeModificationReq
+	Operations []content_modifier.BehaviourTreeOperation `json:"operations" binding:"required,dive"`
+}
+
+// ApplyBehaviourTreeOperationsAPI runs all operations as a single modification, which produces only one new version,
+// nothing is written when any of them is failed
+func ApplyBehaviourTreeOperationsAPI(context *gin.Context) {
+	var req ApplyBehaviourTreeOperationsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	var createdNodeIds map[string]string
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *ApplyBehaviourTreeOperationsReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		var errCode common.ErrorCode
+		var errMsg string
+		var diffInfos content_modifier.BehaviourTreeDiffInfos
+		errCode, errMsg, diffInfos, createdNodeIds = content_modifier.BehaviourTreeApplyOperations(req.Operations, btDoc)
+		return errCode, errMsg, diffInfos
+	})
+	if errCode != common.Success {
+		createdNodeIds = nil
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+		"createdNodeIds":   createdNodeIds,
+	})
+}
@@ -0,0 +1,152 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"golang.org/x/exp/slices"
+	"net/http"
+)
+
+type BindBehaviourTreeBlackBoardReq struct {
+	BaseBehaviourTreeModificationReq
+	BlackBoardAssetId string `json:"blackBoardAssetId" binding:"omitempty"` // empty for unbinding
+}
+
+// BlackBoardDanglingReference is a reference of a bound behaviour tree which is broken by the modification of the black board
+type BlackBoardDanglingReference struct {
+	AssetId   string `json:"assetId" binding:"required"`
+	AssetName string `json:"assetName" binding:"required"`
+	content_modifier.BehaviourTreeBlackBoardReference
+	ErrCode    common.ErrorCode `json:"errCode" binding:"required"`
+	ErrMessage string           `json:"errMessage" binding:"required"`
+}
+
+// BindBehaviourTreeBlackBoardAPI binds the behaviour tree to a black board, the binding is rejected when
+// the black board is invalid or some keys referred by the settings are missing in it
+func BindBehaviourTreeBlackBoardAPI(context *gin.Context) {
+	var req BindBehaviourTreeBlackBoardReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *BindBehaviourTreeBlackBoardReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.BehaviourTreeBindBlackBoard(req.BlackBoardAssetId, btDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+// loadBoundBlackBoard returns nil when the bound asset is not a black board in the same solution of the behaviour tree
+func loadBoundBlackBoard(repo db.Repository, assetId string, blackBoardAssetId string) (*content_modifier.BlackBoardDocumentation, error) {
+	blackBoardDetail, err := repo.GetAsset(blackBoardAssetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if blackBoardDetail.AssetType != "BlackBoard" {
+		return nil, nil
+	}
+
+	solutionId, err := querySolutionIdOfAsset(repo, assetId)
+	if err != nil {
+		return nil, err
+	}
+	blackBoardSolutionId, err := querySolutionIdOfAsset(repo, blackBoardAssetId)
+	if err != nil {
+		return nil, err
+	}
+	if solutionId != blackBoardSolutionId {
+		return nil, nil
+	}
+
+	var bbDoc content_modifier.BlackBoardDocumentation
+	err = content_modifier.BlackBoardDeserialize(blackBoardDetail.AssetContent, &bbDoc)
+	if err != nil {
+		return nil, err
+	}
+	return &bbDoc, nil
+}
+
+// checkBehaviourTreeBlackBoardReferences reports the invalid binding and the referred keys which are missing or incompatible,
+// the references are not checked when the behaviour tree is not bound to any black board
+func checkBehaviourTreeBlackBoardReferences(repo db.Repository, assetId string, btDoc *content_modifier.BehaviourTreeDocumentation) ([]content_modifier.BehaviourTreeValidationIssue, error) {
+	issues := make([]content_modifier.BehaviourTreeValidationIssue, 0)
+	if btDoc.BlackBoardAssetId == "" {
+		return issues, nil
+	}
+
+	bbDoc, err := loadBoundBlackBoard(repo, assetId, btDoc.BlackBoardAssetId)
+	if err != nil {
+		return nil, err
+	}
+	if bbDoc == nil {
+		issues = append(issues, content_modifier.BehaviourTreeValidationIssue{
+			ErrCode:    common.BtValidateInvalidBlackBoardAsset,
+			ErrMessage: common.BtValidateInvalidBlackBoardAsset.GetMsgFormat(btDoc.BlackBoardAssetId),
+		})
+		return issues, nil
+	}
+	references := content_modifier.BehaviourTreeCollectBlackBoardReferences(btDoc)
+	return append(issues, content_modifier.BehaviourTreeCheckBlackBoardReferences(references, bbDoc)...), nil
+}
+
+// collectBlackBoardDanglingReferences finds the references of the behaviour trees bound to the black board
+// which are broken by the keys renamed, removed or updated in diffInfos
+func collectBlackBoardDanglingReferences(repo db.Repository, blackBoardAssetId string, bbDoc *content_modifier.BlackBoardDocumentation, diffInfos []content_modifier.BlackBoardKeyDiffInfo) ([]BlackBoardDanglingReference, error) {
+	danglingReferences := make([]BlackBoardDanglingReference, 0)
+	affectedKeyNames := make([]string, 0, len(diffInfos))
+	for _, info := range diffInfos {
+		if info.PreModifiedKey != nil {
+			affectedKeyNames = append(affectedKeyNames, info.PreModifiedKey.KeyName)
+		}
+	}
+	if len(affectedKeyNames) == 0 {
+		return danglingReferences, nil
+	}
+
+	//Querying The Behaviour Trees In The Same Solution
+	solutionId, err := querySolutionIdOfAsset(repo, blackBoardAssetId)
+	if err != nil {
+		return nil, err
+	}
+	btAssets, err := repo.ListSolutionAssetDetails(solutionId, "BehaviourTree")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, btAsset := range btAssets {
+		var btDoc content_modifier.BehaviourTreeDocumentation
+		err = json.Unmarshal([]byte(btAsset.AssetContent), &btDoc)
+		if err != nil {
+			return nil, err
+		}
+		if btDoc.BlackBoardAssetId != blackBoardAssetId {
+			continue
+		}
+		for _, reference := range content_modifier.BehaviourTreeCollectBlackBoardReferences(&btDoc) {
+			if !slices.Contains(affectedKeyNames, reference.KeyName) {
+				continue
+			}
+			issue := content_modifier.BehaviourTreeCheckBlackBoardReference(&reference, bbDoc)
+			if issue.ErrCode != common.Success {
+				danglingReferences = append(danglingReferences, BlackBoardDanglingReference{btAsset.AssetId, btAsset.AssetName, reference, issue.ErrCode, issue.ErrMessage})
+			}
+		}
+	}
+	return danglingReferences, nil
+}
@@ -0,0 +1,150 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"net/http"
+)
+
+type CreateBehaviourTreeDescriptorReq struct {
+	BaseBehaviourTreeModificationReq
+	AttachTo        string          `json:"attachTo" binding:"required"`
+	DescriptorType  string          `json:"descriptorType" binding:"required"`
+	InitialSettings json.RawMessage `json:"initialSettings" binding:"omitempty"`
+}
+
+type RemoveBehaviourTreeDescriptorReq struct {
+	BaseBehaviourTreeModificationReq
+	DescriptorIds []string `json:"descriptorIds" binding:"required"`
+}
+
+type ReorderBehaviourTreeDescriptorsReq struct {
+	BaseBehaviourTreeModificationReq
+	NodeId               string   `json:"nodeId" binding:"required"`
+	OrderedDescriptorIds []string `json:"orderedDescriptorIds" binding:"required"`
+}
+
+type UpdateBehaviourTreeDescriptorSettingsReq struct {
+	BaseBehaviourTreeModificationReq
+	DescriptorId       string          `json:"descriptorId" binding:"required"`
+	DescriptorSettings json.RawMessage `json:"settings" binding:"required"`
+}
+
+type MoveBehaviourTreeDescriptorReq struct {
+	BaseBehaviourTreeModificationReq
+	DescriptorId string `json:"descriptorId" binding:"required"`
+	ToNodeId     string `json:"toNodeId" binding:"required"`
+	ToOrder      int    `json:"toOrder"` // append to the tail when it's out of range
+}
+
+func CreateBehaviourTreeDescriptorAPI(context *gin.Context) {
+	var req CreateBehaviourTreeDescriptorReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *CreateBehaviourTreeDescriptorReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithDescriptorDiffInfos(content_modifier.BehaviourTreeCreateDescriptor(req.AttachTo, req.DescriptorType, req.InitialSettings, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RemoveBehaviourTreeDescriptorAPI(context *gin.Context) {
+	var req RemoveBehaviourTreeDescriptorReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *RemoveBehaviourTreeDescriptorReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithDescriptorDiffInfos(content_modifier.BehaviourTreeRemoveDescriptor(req.DescriptorIds, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func ReorderBehaviourTreeDescriptorsAPI(context *gin.Context) {
+	var req ReorderBehaviourTreeDescriptorsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *ReorderBehaviourTreeDescriptorsReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithDescriptorDiffInfos(content_modifier.BehaviourTreeReorderDescriptors(req.NodeId, req.OrderedDescriptorIds, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func UpdateBehaviourTreeDescriptorSettingsAPI(context *gin.Context) {
+	var req UpdateBehaviourTreeDescriptorSettingsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *UpdateBehaviourTreeDescriptorSettingsReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithDescriptorDiffInfos(content_modifier.BehaviourTreeUpdateDescriptorSettings(req.DescriptorId, req.DescriptorSettings, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func MoveBehaviourTreeDescriptorAPI(context *gin.Context) {
+	var req MoveBehaviourTreeDescriptorReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *MoveBehaviourTreeDescriptorReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithDescriptorDiffInfos(content_modifier.BehaviourTreeMoveDescriptor(req.DescriptorId, req.ToNodeId, req.ToOrder, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
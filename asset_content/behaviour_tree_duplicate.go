@@ -0,0 +1,83 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"net/http"
+)
+
+// DuplicateBehaviourTreeSubtreeReq duplicates the subtrees of the source asset into the asset of the request,
+// the offset is not validated by binding because zero is a valid offset
+type DuplicateBehaviourTreeSubtreeReq struct {
+	BaseBehaviourTreeModificationReq
+	SourceAssetId string                      `json:"sourceAssetId" binding:"required"`
+	RootNodeIds   []string                    `json:"rootNodeIds" binding:"required"`
+	Offset        content_modifier.XYPosition `json:"offset" binding:"-"`
+}
+
+func DuplicateBehaviourTreeSubtreeAPI(context *gin.Context) {
+	var req DuplicateBehaviourTreeSubtreeReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	var duplicatedNodeIds map[string]string
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModificationInTx(&req, func(repo db.Repository, req *DuplicateBehaviourTreeSubtreeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		//Querying Source Pass
+		sourceDoc := btDoc
+		if req.SourceAssetId != req.GetAssetID() {
+			sourceAssetDetail, err := repo.GetAsset(req.SourceAssetId)
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return common.InvalidAsset, common.InvalidAsset.GetMsgFormat(req.SourceAssetId), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			if err != nil {
+				return common.DataBaseError, err.Error(), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			if sourceAssetDetail.AssetType != "BehaviourTree" {
+				return common.BtDuplicateInvalidSourceAsset, common.BtDuplicateInvalidSourceAsset.GetMsgFormat(req.SourceAssetId, sourceAssetDetail.AssetType), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			//The Settings And The Subtree References Of The Source Nodes Only Make Sense In The Same Solution
+			solutionId, err := querySolutionIdOfAsset(repo, req.GetAssetID())
+			if err != nil {
+				return common.DataBaseError, err.Error(), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			sourceSolutionId, err := querySolutionIdOfAsset(repo, req.SourceAssetId)
+			if err != nil {
+				return common.DataBaseError, err.Error(), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			if sourceSolutionId != solutionId {
+				return common.BtDuplicateSourceAssetOutOfSolution, common.BtDuplicateSourceAssetOutOfSolution.GetMsgFormat(req.SourceAssetId, solutionId), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			sourceDoc = &content_modifier.BehaviourTreeDocumentation{}
+			err = json.Unmarshal([]byte(sourceAssetDetail.AssetContent), sourceDoc)
+			if err != nil {
+				return common.DeserializationError, common.DeserializationError.GetMsg(), content_modifier.BehaviourTreeDiffInfos{}
+			}
+		}
+
+		var errCode common.ErrorCode
+		var errMsg string
+		var diffInfos content_modifier.BehaviourTreeDiffInfos
+		errCode, errMsg, diffInfos, duplicatedNodeIds = content_modifier.BehaviourTreeDuplicateSubtree(req.RootNodeIds, req.Offset, sourceDoc, btDoc)
+		return errCode, errMsg, diffInfos
+	}, recordBehaviourTreeModificationHistory)
+	if errCode != common.Success {
+		duplicatedNodeIds = nil
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":           errCode,
+		"errMessage":        errMsg,
+		"modificationInfo":  modificationInfo,
+		"duplicatedNodeIds": duplicatedNodeIds,
+	})
+}
@@ -0,0 +1,211 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"net/http"
+	"time"
+)
+
+type UndoRedoBehaviourTreeModificationReq struct {
+	BaseBehaviourTreeModificationReq
+}
+
+type GetBehaviourTreeUndoRedoStateReq struct {
+	AssetId string `json:"assetId" binding:"required"`
+}
+
+// behaviourTreeHistoryRecorder is called in the transaction after the modified document is written
+type behaviourTreeHistoryRecorder func(repo db.Repository, assetId string, prevVersion string, newVersion string, diffInfos content_modifier.BehaviourTreeDiffInfos) error
+
+func createBehaviourTreeHistoryItem(repo db.Repository, assetId string, prevVersion string, newVersion string, operation string, undoState string, targetHistoryId uint, diffInfos content_modifier.BehaviourTreeDiffInfos) error {
+	serializedDiffInfos, err := json.Marshal(diffInfos)
+	if err != nil {
+		return err
+	}
+
+	historyItem := common.AssetModificationHistoryItem{
+		AssetId:         assetId,
+		PrevVersion:     prevVersion,
+		NewVersion:      newVersion,
+		Operation:       operation,
+		UndoState:       undoState,
+		TargetHistoryId: targetHistoryId,
+		DiffInfos:       string(serializedDiffInfos),
+		CreatedAt:       time.Now().UnixMilli(),
+	}
+	return repo.CreateModificationHistory(&historyItem)
+}
+
+// recordBehaviourTreeModificationHistory records a normal modification, all undone records are discarded like a common undo stack
+func recordBehaviourTreeModificationHistory(repo db.Repository, assetId string, prevVersion string, newVersion string, diffInfos content_modifier.BehaviourTreeDiffInfos) error {
+	err := repo.UpdateModificationHistoriesUndoState(assetId, common.HistoryOperation_Modify, common.HistoryUndoState_Undone, common.HistoryUndoState_Discarded)
+	if err != nil {
+		return err
+	}
+	return createBehaviourTreeHistoryItem(repo, assetId, prevVersion, newVersion, common.HistoryOperation_Modify, common.HistoryUndoState_Applied, 0, diffInfos)
+}
+
+func UndoBehaviourTreeModificationAPI(context *gin.Context) {
+	var req UndoRedoBehaviourTreeModificationReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeHistoryReplay(&req, common.HistoryOperation_Undo)
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RedoBehaviourTreeModificationAPI(context *gin.Context) {
+	var req UndoRedoBehaviourTreeModificationReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeHistoryReplay(&req, common.HistoryOperation_Redo)
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+// passBehaviourTreeHistoryReplay applies the inverted diff infos of the latest applied record for undo,
+// or the diff infos of the earliest undone record for redo, as a new version of the document
+func passBehaviourTreeHistoryReplay(req *UndoRedoBehaviourTreeModificationReq, operation string) (common.ErrorCode, string, *BehaviourTreeNodeModification) {
+	var targetItem common.AssetModificationHistoryItem
+
+	return passBehaviourTreeDocumentModificationInTx(req, func(repo db.Repository, req *UndoRedoBehaviourTreeModificationReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		//Querying Target Pass
+		{
+			var foundItem *common.AssetModificationHistoryItem
+			var err error
+			if operation == common.HistoryOperation_Undo {
+				foundItem, err = repo.FindModificationHistory(req.GetAssetID(), common.HistoryOperation_Modify, common.HistoryUndoState_Applied, true)
+			} else {
+				foundItem, err = repo.FindModificationHistory(req.GetAssetID(), common.HistoryOperation_Modify, common.HistoryUndoState_Undone, false)
+			}
+			if errors.Is(err, db.ErrRecordNotFound) {
+				if operation == common.HistoryOperation_Undo {
+					return common.BtHistoryNothingToUndo, common.BtHistoryNothingToUndo.GetMsgFormat(req.GetAssetID()), content_modifier.BehaviourTreeDiffInfos{}
+				}
+				return common.BtHistoryNothingToRedo, common.BtHistoryNothingToRedo.GetMsgFormat(req.GetAssetID()), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			if err != nil {
+				return common.DataBaseError, err.Error(), content_modifier.BehaviourTreeDiffInfos{}
+			}
+			targetItem = *foundItem
+		}
+
+		//Replay Pass
+		var diffInfos content_modifier.BehaviourTreeDiffInfos
+		err := json.Unmarshal([]byte(targetItem.DiffInfos), &diffInfos)
+		if err != nil {
+			return common.DeserializationError, common.DeserializationError.GetMsg(), content_modifier.BehaviourTreeDiffInfos{}
+		}
+		if operation == common.HistoryOperation_Undo {
+			diffInfos = diffInfos.Invert()
+		}
+		return content_modifier.BehaviourTreeApplyDiffInfos(diffInfos, btDoc)
+	}, func(repo db.Repository, assetId string, prevVersion string, newVersion string, diffInfos content_modifier.BehaviourTreeDiffInfos) error {
+		targetUndoState := common.HistoryUndoState_Undone
+		if operation == common.HistoryOperation_Redo {
+			targetUndoState = common.HistoryUndoState_Applied
+		}
+		updated, err := repo.UpdateModificationHistoryUndoState(targetItem.HistoryId, targetItem.UndoState, targetUndoState)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return errors.New(common.BtHistoryConflict.GetMsgFormat("History", assetId))
+		}
+		return createBehaviourTreeHistoryItem(repo, assetId, prevVersion, newVersion, operation, "", targetItem.HistoryId, diffInfos)
+	})
+}
+
+func GetBehaviourTreeUndoRedoStateAPI(context *gin.Context) {
+	var req GetBehaviourTreeUndoRedoStateReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	var undoableCount, redoableCount int64
+	undoableCount, err = db.Storage.CountModificationHistories(req.AssetId, common.HistoryOperation_Modify, common.HistoryUndoState_Applied)
+	if err == nil {
+		redoableCount, err = db.Storage.CountModificationHistories(req.AssetId, common.HistoryOperation_Modify, common.HistoryUndoState_Undone)
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":       common.Success,
+		"errMessage":    "",
+		"undoableCount": undoableCount,
+		"redoableCount": redoableCount,
+	})
+}
+
+// MaxRebaseHistoryDepth limits how many recent versions a stale modification is able to be rebased over
+const MaxRebaseHistoryDepth = 64
+
+// loadBehaviourTreeRebaseChain collects the ids touched by the modifications from baseVersion to latestVersion,
+// false is returned when the chain between them is not completely recorded in the recent history
+func loadBehaviourTreeRebaseChain(repo db.Repository, assetId string, baseVersion string, latestVersion string) (bool, content_modifier.BehaviourTreeTouchedIds, error) {
+	var touchedIds content_modifier.BehaviourTreeTouchedIds
+
+	recentItems, err := repo.ListRecentModificationHistories(assetId, MaxRebaseHistoryDepth)
+	if err != nil {
+		return false, touchedIds, err
+	}
+
+	// Walk Back From The Latest Version Until The Base Version
+	expectedVersion := latestVersion
+	for _, item := range recentItems {
+		if item.NewVersion != expectedVersion {
+			return false, touchedIds, nil
+		}
+
+		var diffInfos content_modifier.BehaviourTreeDiffInfos
+		err = json.Unmarshal([]byte(item.DiffInfos), &diffInfos)
+		if err != nil {
+			return false, touchedIds, err
+		}
+		touchedIds.Add(diffInfos)
+
+		if item.PrevVersion == baseVersion {
+			return true, touchedIds, nil
+		}
+		expectedVersion = item.PrevVersion
+	}
+	return false, touchedIds, nil
+}
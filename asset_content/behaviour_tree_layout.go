@@ -0,0 +1,52 @@
+package asset_content
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"net/http"
+)
+
+type AutoLayoutBehaviourTreeReq struct {
+	BaseBehaviourTreeModificationReq
+	Orientation    string   `json:"orientation" binding:"omitempty"`    // use LayoutOrientation_TopDown when it's absent
+	SiblingSpacing *float32 `json:"siblingSpacing" binding:"omitempty"` // use DefaultLayoutSiblingSpacing when it's absent
+	LevelSpacing   *float32 `json:"levelSpacing" binding:"omitempty"`   // use DefaultLayoutLevelSpacing when it's absent
+}
+
+func AutoLayoutBehaviourTreeAPI(context *gin.Context) {
+	var req AutoLayoutBehaviourTreeReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	options := content_modifier.BehaviourTreeLayoutOptions{
+		Orientation:    content_modifier.LayoutOrientation_TopDown,
+		SiblingSpacing: content_modifier.DefaultLayoutSiblingSpacing,
+		LevelSpacing:   content_modifier.DefaultLayoutLevelSpacing,
+	}
+	if req.Orientation != "" {
+		options.Orientation = req.Orientation
+	}
+	if req.SiblingSpacing != nil {
+		options.SiblingSpacing = *req.SiblingSpacing
+	}
+	if req.LevelSpacing != nil {
+		options.LevelSpacing = *req.LevelSpacing
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *AutoLayoutBehaviourTreeReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithNodeDiffInfos(content_modifier.BehaviourTreeAutoLayout(options, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
@@ -0,0 +1,145 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"golang.org/x/exp/slices"
+	"math"
+	"strings"
+)
+
+// checkBehaviourTreeSubtreeReferences reports the run subtree nodes of the document which refer to an invalid asset
+// or make a recursion through the assets (A runs B runs A). the document is the in-memory one of assetId,
+// the other assets are read in the transaction
+func checkBehaviourTreeSubtreeReferences(repo db.Repository, assetId string, btDoc *content_modifier.BehaviourTreeDocumentation) ([]content_modifier.BehaviourTreeValidationIssue, error) {
+	issues := make([]content_modifier.BehaviourTreeValidationIssue, 0)
+	references := content_modifier.BehaviourTreeCollectSubtreeReferences(btDoc)
+	if len(references) == 0 {
+		return issues, nil
+	}
+
+	//Querying The Behaviour Trees In The Same Solution
+	solutionId, err := querySolutionIdOfAsset(repo, assetId)
+	if err != nil {
+		return nil, err
+	}
+	solutionAssets, err := repo.ListSolutionAssetDetails(solutionId, "BehaviourTree")
+	if err != nil {
+		return nil, err
+	}
+	solutionAssetIds := make([]string, 0, len(solutionAssets))
+	for _, solutionAsset := range solutionAssets {
+		solutionAssetIds = append(solutionAssetIds, solutionAsset.AssetId)
+	}
+
+	//The Referred Asset Ids Of Each Asset, The Documents Of Other Assets Are Loaded Lazily
+	referredAssetIdsByAssetId := map[string][]string{}
+	loadReferredAssetIds := func(referringAssetId string) ([]string, error) {
+		if referredAssetIds, exist := referredAssetIdsByAssetId[referringAssetId]; exist {
+			return referredAssetIds, nil
+		}
+		doc := btDoc
+		if referringAssetId != assetId {
+			assetDetail, err := repo.GetAsset(referringAssetId)
+			if err != nil {
+				return nil, err
+			}
+			doc = &content_modifier.BehaviourTreeDocumentation{}
+			err = json.Unmarshal([]byte(assetDetail.AssetContent), doc)
+			if err != nil {
+				return nil, err
+			}
+		}
+		referredAssetIds := make([]string, 0)
+		for _, reference := range content_modifier.BehaviourTreeCollectSubtreeReferences(doc) {
+			if !slices.Contains(referredAssetIds, reference.SubtreeAssetId) && slices.Contains(solutionAssetIds, reference.SubtreeAssetId) {
+				referredAssetIds = append(referredAssetIds, reference.SubtreeAssetId)
+			}
+		}
+		referredAssetIdsByAssetId[referringAssetId] = referredAssetIds
+		return referredAssetIds, nil
+	}
+
+	// findPathBackToAsset returns the asset path from fromAssetId back to assetId, nil is returned when there is no such path.
+	// the assets being searched are skipped to break other cycles, so a "no path" result is only memorized when it didn't
+	// skip any asset searched before fromAssetId, otherwise the skipped asset may still lead back through another way.
+	// the lowest depth of the skipped assets is returned for the callers to tell that
+	pathBackByAssetId := map[string][]string{}
+	searchingDepthByAssetId := map[string]int{}
+	var findPathBackToAsset func(fromAssetId string) ([]string, int, error)
+	findPathBackToAsset = func(fromAssetId string) ([]string, int, error) {
+		if fromAssetId == assetId {
+			return []string{fromAssetId}, math.MaxInt, nil
+		}
+		if path, searched := pathBackByAssetId[fromAssetId]; searched {
+			return path, math.MaxInt, nil
+		}
+		if depth, searching := searchingDepthByAssetId[fromAssetId]; searching {
+			return nil, depth, nil
+		}
+		depth := len(searchingDepthByAssetId)
+		searchingDepthByAssetId[fromAssetId] = depth
+		defer delete(searchingDepthByAssetId, fromAssetId)
+
+		referredAssetIds, err := loadReferredAssetIds(fromAssetId)
+		if err != nil {
+			return nil, 0, err
+		}
+		skippedDepth := math.MaxInt
+		for _, referredAssetId := range referredAssetIds {
+			path, referredSkippedDepth, err := findPathBackToAsset(referredAssetId)
+			if err != nil {
+				return nil, 0, err
+			}
+			if path != nil {
+				path = append([]string{fromAssetId}, path...)
+				pathBackByAssetId[fromAssetId] = path
+				return path, math.MaxInt, nil
+			}
+			skippedDepth = min(skippedDepth, referredSkippedDepth)
+		}
+		if skippedDepth >= depth {
+			pathBackByAssetId[fromAssetId] = nil
+		}
+		return nil, skippedDepth, nil
+	}
+
+	for _, reference := range references {
+		if !slices.Contains(solutionAssetIds, reference.SubtreeAssetId) {
+			issues = append(issues, content_modifier.BehaviourTreeValidationIssue{
+				NodeId:     reference.NodeId,
+				ErrCode:    common.BtValidateInvalidSubtreeAsset,
+				ErrMessage: common.BtValidateInvalidSubtreeAsset.GetMsgFormat(reference.NodeId, reference.SubtreeAssetId),
+			})
+			continue
+		}
+		path, _, err := findPathBackToAsset(reference.SubtreeAssetId)
+		if err != nil {
+			return nil, err
+		}
+		if path != nil {
+			issues = append(issues, content_modifier.BehaviourTreeValidationIssue{
+				NodeId:     reference.NodeId,
+				ErrCode:    common.BtValidateSubtreeRecursion,
+				ErrMessage: common.BtValidateSubtreeRecursion.GetMsgFormat(reference.NodeId, strings.Join(append([]string{assetId}, path...), " -> ")),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// checkBehaviourTreeDocument reports the hard issues of the document, including the ones about the referred assets
+func checkBehaviourTreeDocument(repo db.Repository, assetId string, btDoc *content_modifier.BehaviourTreeDocumentation) ([]content_modifier.BehaviourTreeValidationIssue, error) {
+	issues := content_modifier.BehaviourTreeCheckStructure(btDoc)
+	subtreeIssues, err := checkBehaviourTreeSubtreeReferences(repo, assetId, btDoc)
+	if err != nil {
+		return nil, err
+	}
+	blackBoardIssues, err := checkBehaviourTreeBlackBoardReferences(repo, assetId, btDoc)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(issues, subtreeIssues...), blackBoardIssues...), nil
+}
@@ -0,0 +1,135 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"net/http"
+)
+
+type CreateBehaviourTreeServiceReq struct {
+	BaseBehaviourTreeModificationReq
+	AttachTo        string          `json:"attachTo" binding:"required"`
+	ServiceType     string          `json:"serviceType" binding:"required"`
+	TickInterval    *float32        `json:"tickInterval" binding:"omitempty"`    // use DefaultServiceTickInterval when it's absent
+	RandomDeviation *float32        `json:"randomDeviation" binding:"omitempty"` // use DefaultServiceRandomDeviation when it's absent
+	InitialSettings json.RawMessage `json:"initialSettings" binding:"omitempty"`
+}
+
+type RemoveBehaviourTreeServiceReq struct {
+	BaseBehaviourTreeModificationReq
+	ServiceIds []string `json:"serviceIds" binding:"required"`
+}
+
+type ReorderBehaviourTreeServicesReq struct {
+	BaseBehaviourTreeModificationReq
+	NodeId            string   `json:"nodeId" binding:"required"`
+	OrderedServiceIds []string `json:"orderedServiceIds" binding:"required"`
+}
+
+type UpdateBehaviourTreeServiceSettingsReq struct {
+	BaseBehaviourTreeModificationReq
+	ServiceId       string          `json:"serviceId" binding:"required"`
+	ServiceType     string          `json:"serviceType" binding:"required"`
+	TickInterval    float32         `json:"tickInterval" binding:"required"`
+	RandomDeviation float32         `json:"randomDeviation"`
+	ServiceSettings json.RawMessage `json:"settings" binding:"omitempty"`
+}
+
+func CreateBehaviourTreeServiceAPI(context *gin.Context) {
+	var req CreateBehaviourTreeServiceReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	tickInterval := content_modifier.DefaultServiceTickInterval
+	if req.TickInterval != nil {
+		tickInterval = *req.TickInterval
+	}
+	randomDeviation := content_modifier.DefaultServiceRandomDeviation
+	if req.RandomDeviation != nil {
+		randomDeviation = *req.RandomDeviation
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *CreateBehaviourTreeServiceReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithServiceDiffInfos(content_modifier.BehaviourTreeCreateService(req.AttachTo, req.ServiceType, tickInterval, randomDeviation, req.InitialSettings, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RemoveBehaviourTreeServiceAPI(context *gin.Context) {
+	var req RemoveBehaviourTreeServiceReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *RemoveBehaviourTreeServiceReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithServiceDiffInfos(content_modifier.BehaviourTreeRemoveService(req.ServiceIds, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func ReorderBehaviourTreeServicesAPI(context *gin.Context) {
+	var req ReorderBehaviourTreeServicesReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *ReorderBehaviourTreeServicesReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithServiceDiffInfos(content_modifier.BehaviourTreeReorderServices(req.NodeId, req.OrderedServiceIds, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func UpdateBehaviourTreeServiceSettingsAPI(context *gin.Context) {
+	var req UpdateBehaviourTreeServiceSettingsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBehaviourTreeDocumentModification(&req, func(req *UpdateBehaviourTreeServiceSettingsReq, btDoc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, content_modifier.BehaviourTreeDiffInfos) {
+		return content_modifier.WithServiceDiffInfos(content_modifier.BehaviourTreeUpdateServiceSettings(req.ServiceId, req.ServiceType, req.TickInterval, req.RandomDeviation, req.ServiceSettings, btDoc))
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
@@ -0,0 +1,80 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/simulator"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"net/http"
+)
+
+type SimulateBehaviourTreeReq struct {
+	AssetId string           `json:"assetId" binding:"required"`
+	Script  simulator.Script `json:"script" binding:"omitempty"`
+}
+
+// SimulateBehaviourTreeAPI ticks the current version of the asset headlessly, nothing is modified
+func SimulateBehaviourTreeAPI(context *gin.Context) {
+	var req SimulateBehaviourTreeReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, btDoc := loadBehaviourTreeDocument(req.AssetId)
+	if errCode != common.Success {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    errCode,
+			"errMessage": errMsg,
+		})
+		return
+	}
+
+	// The Broken Structure Is Not Able To Be Ticked
+	issues := content_modifier.BehaviourTreeCheckStructure(btDoc)
+	if len(issues) > 0 {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":          common.BtValidationFailed,
+			"errMessage":       common.BtValidationFailed.GetMsgFormat(len(issues), issues[0].ErrMessage),
+			"validationIssues": issues,
+		})
+		return
+	}
+
+	// The Default Values Of The Bound Black Board Are Used For The Keys Absent In The Script
+	if btDoc.BlackBoardAssetId != "" {
+		bbDoc, err := loadBoundBlackBoard(db.Storage, req.AssetId, btDoc.BlackBoardAssetId)
+		if err != nil {
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.DataBaseError,
+				"errMessage": err.Error(),
+			})
+			return
+		}
+		if bbDoc != nil {
+			if req.Script.InitialBlackboard == nil {
+				req.Script.InitialBlackboard = make(map[string]interface{}, len(bbDoc.Keys))
+			}
+			for _, key := range bbDoc.Keys {
+				var defaultValue interface{}
+				if _, exist := req.Script.InitialBlackboard[key.KeyName]; exist || json.Unmarshal(key.DefaultValue, &defaultValue) != nil || defaultValue == nil {
+					continue
+				}
+				req.Script.InitialBlackboard[key.KeyName] = defaultValue
+			}
+		}
+	}
+
+	errCode, errMsg, traces := simulator.Simulate(req.Script, btDoc)
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":    errCode,
+		"errMessage": errMsg,
+		"traces":     traces,
+	})
+}
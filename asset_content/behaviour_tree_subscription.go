@@ -0,0 +1,165 @@
+package asset_content
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// SubscriptionBufferSize is how many modifications are able to be queued for a slow subscriber,
+	// the subscriber is dropped when it's full and the editor should reload the asset after reconnecting
+	SubscriptionBufferSize = 64
+	// SubscriptionHeartbeatInterval keeps the idle stream alive through the proxies
+	SubscriptionHeartbeatInterval = 30 * time.Second
+
+	SubscriptionEvent_Modification = "modification"
+	SubscriptionEvent_Heartbeat    = "heartbeat"
+)
+
+type SubscribeBehaviourTreeModificationsReq struct {
+	AssetId string `form:"assetId" binding:"required"`
+}
+
+// behaviourTreeModificationBroker fans out the committed modifications to the editors viewing the same asset
+type behaviourTreeModificationBroker struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan *BehaviourTreeNodeModification]struct{}
+}
+
+var modificationBroker = &behaviourTreeModificationBroker{
+	subscribers: make(map[string]map[chan *BehaviourTreeNodeModification]struct{}),
+}
+
+func (broker *behaviourTreeModificationBroker) Subscribe(assetId string) chan *BehaviourTreeNodeModification {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	subscriber := make(chan *BehaviourTreeNodeModification, SubscriptionBufferSize)
+	if broker.subscribers[assetId] == nil {
+		broker.subscribers[assetId] = make(map[chan *BehaviourTreeNodeModification]struct{})
+	}
+	broker.subscribers[assetId][subscriber] = struct{}{}
+	return subscriber
+}
+
+// Unsubscribe removes and closes the subscriber, it's fine to call it on the dropped subscriber
+func (broker *behaviourTreeModificationBroker) Unsubscribe(assetId string, subscriber chan *BehaviourTreeNodeModification) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	broker.removeSubscriber(assetId, subscriber)
+}
+
+func (broker *behaviourTreeModificationBroker) removeSubscriber(assetId string, subscriber chan *BehaviourTreeNodeModification) {
+	assetSubscribers, exist := broker.subscribers[assetId]
+	if !exist {
+		return
+	}
+	if _, exist = assetSubscribers[subscriber]; !exist {
+		return
+	}
+	delete(assetSubscribers, subscriber)
+	close(subscriber)
+	if len(assetSubscribers) == 0 {
+		delete(broker.subscribers, assetId)
+	}
+}
+
+// Drop removes all the subscribers of the asset, it's used when the asset is changed without the diff infos,
+// the editors should reload the asset after reconnecting
+func (broker *behaviourTreeModificationBroker) Drop(assetId string) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	for subscriber := range broker.subscribers[assetId] {
+		broker.removeSubscriber(assetId, subscriber)
+	}
+}
+
+// DropBehaviourTreeSubscriptions closes the streams of the removed behaviour tree, it's called after the removal is committed
+func DropBehaviourTreeSubscriptions(assetId string) {
+	modificationBroker.Drop(assetId)
+}
+
+// Publish never blocks the modification, the subscriber which can't keep up is dropped
+func (broker *behaviourTreeModificationBroker) Publish(assetId string, modificationInfo *BehaviourTreeNodeModification) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	for subscriber := range broker.subscribers[assetId] {
+		select {
+		case subscriber <- modificationInfo:
+		default:
+			broker.removeSubscriber(assetId, subscriber)
+		}
+	}
+}
+
+// SubscribeBehaviourTreeModificationsAPI streams every committed modification of the asset as server-sent events,
+// the clients are able to find the missed modifications by comparing prevVersion with the version they hold.
+// the modifications are published after their transactions are committed, so two concurrent ones may arrive in the
+// reverse order of committing. the clients must chain them by prevVersion and newVersion, and hold the one whose
+// prevVersion is not reached yet until its predecessor arrives
+func SubscribeBehaviourTreeModificationsAPI(context *gin.Context) {
+	var req SubscribeBehaviourTreeModificationsReq
+	err := context.ShouldBindQuery(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	assetDetail, err := db.Storage.GetAsset(req.AssetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAsset,
+			"errMessage": common.InvalidAsset.GetMsgFormat(req.AssetId),
+		})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+	if assetDetail.AssetType != "BehaviourTree" {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAssetType,
+			"errMessage": common.InvalidAssetType.GetMsgFormat(assetDetail.AssetType),
+		})
+		return
+	}
+
+	subscriber := modificationBroker.Subscribe(req.AssetId)
+	defer modificationBroker.Unsubscribe(req.AssetId, subscriber)
+
+	heartbeat := time.NewTicker(SubscriptionHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	context.Header("Cache-Control", "no-cache")
+	context.Header("X-Accel-Buffering", "no")
+	context.Stream(func(w io.Writer) bool {
+		select {
+		case modificationInfo, ok := <-subscriber:
+			if !ok {
+				return false // Dropped For Being Too Slow Or The Asset Is Restored Or Removed
+			}
+			context.SSEvent(SubscriptionEvent_Modification, modificationInfo)
+			return true
+		case <-heartbeat.C:
+			context.SSEvent(SubscriptionEvent_Heartbeat, time.Now().UnixMilli())
+			return true
+		case <-context.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,299 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type BaseBlackBoardModificationReq struct {
+	AssetId        string `json:"assetId" binding:"required"`
+	CurrentVersion string `json:"currentVersion" binding:"required"`
+	Author         string `json:"author" binding:"max=64"` // optional, it's kept in the version of the asset
+}
+
+func (req *BaseBlackBoardModificationReq) GetAssetID() string {
+	return req.AssetId
+}
+
+func (req *BaseBlackBoardModificationReq) GetCurrentVersion() string {
+	return req.CurrentVersion
+}
+
+func (req *BaseBlackBoardModificationReq) GetAuthor() string {
+	return req.Author
+}
+
+type CreateBlackBoardKeyReq struct {
+	BaseBlackBoardModificationReq
+	Key content_modifier.BlackBoardKey `json:"key" binding:"required"`
+}
+
+type RenameBlackBoardKeyReq struct {
+	BaseBlackBoardModificationReq
+	KeyName    string `json:"keyName" binding:"required"`
+	NewKeyName string `json:"newKeyName" binding:"required"`
+}
+
+type RemoveBlackBoardKeyReq struct {
+	BaseBlackBoardModificationReq
+	KeyNames []string `json:"keyNames" binding:"required"`
+}
+
+type UpdateBlackBoardKeyReq struct {
+	BaseBlackBoardModificationReq
+	Key content_modifier.BlackBoardKey `json:"key" binding:"required"`
+}
+
+type BlackBoardModification struct {
+	DiffKeysInfos []content_modifier.BlackBoardKeyDiffInfo `json:"diffKeysInfos" binding:"required"`
+	PrevVersion   string                                   `json:"prevVersion" binding:"required"`
+	NewVersion    string                                   `json:"newVersion" binding:"required"`
+
+	DanglingReferences []BlackBoardDanglingReference `json:"danglingReferences,omitempty"` // the references of the bound behaviour trees broken by this modification
+}
+
+type ArchivedBlackBoard struct {
+	AssetName    string `json:"assetName" binding:"required"`
+	AssetId      string `json:"assetId" binding:"required"`
+	AssetVersion string `json:"assetVersion" binding:"required"`
+
+	BlackBoardKeys string `json:"blackBoardKeys" binding:"required"`
+}
+
+func CreateBlackBoardKeyAPI(context *gin.Context) {
+	var req CreateBlackBoardKeyReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBlackBoardDocumentModification(&req, func(req *CreateBlackBoardKeyReq, bbDoc *content_modifier.BlackBoardDocumentation) (common.ErrorCode, string, []content_modifier.BlackBoardKeyDiffInfo) {
+		return content_modifier.BlackBoardCreateKey(req.Key, bbDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RenameBlackBoardKeyAPI(context *gin.Context) {
+	var req RenameBlackBoardKeyReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBlackBoardDocumentModification(&req, func(req *RenameBlackBoardKeyReq, bbDoc *content_modifier.BlackBoardDocumentation) (common.ErrorCode, string, []content_modifier.BlackBoardKeyDiffInfo) {
+		return content_modifier.BlackBoardRenameKey(req.KeyName, req.NewKeyName, bbDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RemoveBlackBoardKeyAPI(context *gin.Context) {
+	var req RemoveBlackBoardKeyReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBlackBoardDocumentModification(&req, func(req *RemoveBlackBoardKeyReq, bbDoc *content_modifier.BlackBoardDocumentation) (common.ErrorCode, string, []content_modifier.BlackBoardKeyDiffInfo) {
+		return content_modifier.BlackBoardRemoveKey(req.KeyNames, bbDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func UpdateBlackBoardKeyAPI(context *gin.Context) {
+	var req UpdateBlackBoardKeyReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passBlackBoardDocumentModification(&req, func(req *UpdateBlackBoardKeyReq, bbDoc *content_modifier.BlackBoardDocumentation) (common.ErrorCode, string, []content_modifier.BlackBoardKeyDiffInfo) {
+		return content_modifier.BlackBoardUpdateKey(req.Key, bbDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+// passBlackBoardDocumentModification is the optimistic versioning like the behaviour tree, the stale request is always rejected
+func passBlackBoardDocumentModification[T AssetModifier](req T, blackBoardModify func(req T, bbDoc *content_modifier.BlackBoardDocumentation) (common.ErrorCode, string, []content_modifier.BlackBoardKeyDiffInfo)) (common.ErrorCode, string, *BlackBoardModification) {
+	var errCode = common.Success
+	var errMsg = ""
+	var modificationInfo *BlackBoardModification = nil
+
+	err := db.Storage.Transaction(func(repo db.Repository) error {
+		var assetDetail *common.AssetDetailInfo
+		//Querying Pass
+		{
+			var err error
+			assetDetail, err = repo.GetAssetForUpdate(req.GetAssetID())
+			if err != nil {
+				errCode = common.DataBaseError
+				errMsg = err.Error()
+				return err
+			}
+			if assetDetail.AssetType != "BlackBoard" {
+				eMsg := common.MismatchedAssetType.GetMsgFormat(assetDetail.AssetId, assetDetail.AssetType, "BlackBoard")
+				errCode, errMsg = common.MismatchedAssetType, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Version Checking Pass
+		{
+			if assetDetail.AssetVersion != req.GetCurrentVersion() {
+				eMsg := common.InvalidAssetVersion.GetMsgFormat(assetDetail.AssetVersion, req.GetCurrentVersion())
+				errCode, errMsg = common.InvalidAssetVersion, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Deserialization Pass
+		var bbDoc content_modifier.BlackBoardDocumentation
+		{
+			err := content_modifier.BlackBoardDeserialize(assetDetail.AssetContent, &bbDoc)
+			if err != nil {
+				eMsg := common.DeserializationError.GetMsg()
+				errCode, errMsg = common.DeserializationError, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Real Modified Logic Pass
+		var diffInfos []content_modifier.BlackBoardKeyDiffInfo
+		{
+			errCode, errMsg, diffInfos = blackBoardModify(req, &bbDoc)
+			if errCode != common.Success {
+				return errors.New(errMsg)
+			}
+		}
+
+		//Write Modification
+		newVersion := assetDetail.AssetVersion
+		if len(diffInfos) > 0 { // just need real write data when there are some diffInfos
+			//Serialization
+			modifiedContent, err := json.Marshal(bbDoc)
+			if err != nil {
+				errCode = common.SerializationError
+				eMsg := errCode.GetMsg()
+				errMsg = eMsg
+				return errors.New(eMsg)
+			}
+
+			//DB Update
+			newVersion = uuid.New().String()
+			assetDetail.AssetVersion = newVersion
+			assetDetail.AssetContent = string(modifiedContent)
+
+			err = repo.SaveAsset(assetDetail)
+			if err != nil {
+				errCode = common.DataBaseError
+				eMsg := errCode.GetMsg()
+				errMsg = eMsg
+				return errors.New(eMsg)
+			}
+
+			//Version Recording
+			err = RecordAssetVersion(repo, assetDetail, req.GetCurrentVersion(), "", req.GetAuthor())
+			if err != nil {
+				errCode = common.DataBaseError
+				errMsg = err.Error()
+				return err
+			}
+		}
+
+		//Dangling Reference Pass, The Broken References Are Reported But Not Rejected
+		danglingReferences, err := collectBlackBoardDanglingReferences(repo, req.GetAssetID(), &bbDoc, diffInfos)
+		if err != nil {
+			errCode, errMsg = common.DataBaseError, err.Error()
+			return err
+		}
+
+		//All Pass
+		//Calculate Modification Info
+		modificationInfo = &BlackBoardModification{
+			DiffKeysInfos:      diffInfos,
+			PrevVersion:        req.GetCurrentVersion(),
+			NewVersion:         newVersion,
+			DanglingReferences: danglingReferences,
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+		return errCode, errMsg, nil
+	}
+	return common.Success, "", modificationInfo
+}
+
+func GetArchivedBlackBoardAsset(assetDetail *common.AssetDetailInfo) (common.ErrorCode, string, *ArchivedBlackBoard) {
+
+	if assetDetail.AssetType != "BlackBoard" {
+		return common.ArchiveAssetsUnexpectAssetType, common.ArchiveAssetsUnexpectAssetType.GetMsgFormat(assetDetail.AssetType, "BlackBoard"), nil
+	}
+
+	var bbDoc content_modifier.BlackBoardDocumentation
+	//Deserialize
+	err := content_modifier.BlackBoardDeserialize(assetDetail.AssetContent, &bbDoc)
+	if err != nil {
+		return common.DeserializationError, err.Error(), nil
+	}
+
+	var archivedDoc ArchivedBlackBoard
+	archivedDoc.AssetName = assetDetail.AssetName
+	archivedDoc.AssetId = assetDetail.AssetId
+	archivedDoc.AssetVersion = assetDetail.AssetVersion
+
+	{
+		serializationKeys, err := json.Marshal(&bbDoc.Keys)
+		if err != nil {
+			return common.SerializationError, err.Error(), nil
+		}
+
+		archivedDoc.BlackBoardKeys = string(serializationKeys)
+	}
+
+	return common.Success, "", &archivedDoc
+}
@@ -0,0 +1,138 @@
+package asset_content
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"testing"
+)
+
+type blackBoardModificationResponse struct {
+	ErrCode          common.ErrorCode        `json:"errCode"`
+	ErrMessage       string                  `json:"errMessage"`
+	ModificationInfo *BlackBoardModification `json:"modificationInfo"`
+}
+
+func modifyBlackBoard(t *testing.T, router *gin.Engine, name string, req gin.H, errCode common.ErrorCode) *BlackBoardModification {
+	t.Helper()
+	var resp blackBoardModificationResponse
+	callAPI(t, router, name, req, &resp)
+	if resp.ErrCode != errCode {
+		t.Fatalf("%s responds the errCode %d (%s), expected %d", name, resp.ErrCode, resp.ErrMessage, errCode)
+	}
+	return resp.ModificationInfo
+}
+
+func readBlackBoard(t *testing.T, assetId string) content_modifier.BlackBoardDocumentation {
+	t.Helper()
+	assetDetail, err := db.Storage.GetAsset(assetId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bbDoc content_modifier.BlackBoardDocumentation
+	if err = content_modifier.BlackBoardDeserialize(assetDetail.AssetContent, &bbDoc); err != nil {
+		t.Fatal(err)
+	}
+	return bbDoc
+}
+
+func TestBlackBoardKeys(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	errCode, errMsg, content := content_modifier.BlackBoardCreateEmptyContent()
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	assetId, version := createTestAsset(t, assetSetId, "BlackBoard", "Board", content)
+	modificationReq := func(fields gin.H) gin.H {
+		fields["assetId"], fields["currentVersion"] = assetId, assetVersionOf(t, assetId)
+		return fields
+	}
+
+	modificationInfo := modifyBlackBoard(t, router, "CreateBlackBoardKey", modificationReq(gin.H{"key": gin.H{"name": "hp", "type": content_modifier.BlackBoardKey_Int}}), common.Success)
+	if modificationInfo.PrevVersion != version || len(modificationInfo.DiffKeysInfos) != 1 {
+		t.Fatalf("the key isn't created as a new version: %+v", modificationInfo)
+	}
+	modifyBlackBoard(t, router, "CreateBlackBoardKey", gin.H{"assetId": assetId, "currentVersion": version, "key": gin.H{"name": "mp", "type": content_modifier.BlackBoardKey_Int}}, common.InvalidAssetVersion)
+	modifyBlackBoard(t, router, "CreateBlackBoardKey", modificationReq(gin.H{"key": gin.H{"name": "hp", "type": content_modifier.BlackBoardKey_Float}}), common.BbDuplicatedKeyName)
+	modifyBlackBoard(t, router, "CreateBlackBoardKey", modificationReq(gin.H{"key": gin.H{"name": "mp", "type": "matrix"}}), common.BbInvalidKeyType)
+
+	modifyBlackBoard(t, router, "RenameBlackBoardKey", modificationReq(gin.H{"keyName": "hp", "newKeyName": "health"}), common.Success)
+	modifyBlackBoard(t, router, "UpdateBlackBoardKey", modificationReq(gin.H{"key": gin.H{"name": "health", "type": content_modifier.BlackBoardKey_Int, "defaultValue": 100}}), common.Success)
+	bbDoc := readBlackBoard(t, assetId)
+	if len(bbDoc.Keys) != 1 || bbDoc.Keys[0].KeyName != "health" || string(bbDoc.Keys[0].DefaultValue) != "100" {
+		t.Fatalf("the key isn't renamed and updated: %+v", bbDoc.Keys)
+	}
+
+	modifyBlackBoard(t, router, "RemoveBlackBoardKey", modificationReq(gin.H{"keyNames": []string{"hp"}}), common.BbInvalidKey)
+	modifyBlackBoard(t, router, "RemoveBlackBoardKey", modificationReq(gin.H{"keyNames": []string{"health"}}), common.Success)
+	if bbDoc = readBlackBoard(t, assetId); len(bbDoc.Keys) != 0 {
+		t.Fatalf("the key isn't removed: %+v", bbDoc.Keys)
+	}
+}
+
+func TestBehaviourTreeBlackBoardBinding(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	errCode, errMsg, content := content_modifier.BlackBoardCreateEmptyContent()
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	blackBoardId, _ := createTestAsset(t, assetSetId, "BlackBoard", "Board", content)
+	keyModificationReq := func(fields gin.H) gin.H {
+		fields["assetId"], fields["currentVersion"] = blackBoardId, assetVersionOf(t, blackBoardId)
+		return fields
+	}
+	modifyBlackBoard(t, router, "CreateBlackBoardKey", keyModificationReq(gin.H{"key": gin.H{"name": "hp", "type": content_modifier.BlackBoardKey_Int}}), common.Success)
+	modifyBlackBoard(t, router, "CreateBlackBoardKey", keyModificationReq(gin.H{"key": gin.H{"name": "alive", "type": content_modifier.BlackBoardKey_Bool}}), common.Success)
+
+	assetId, version := createTestBehaviourTree(t, assetSetId, "Tree")
+	modificationReq := func(fields gin.H) gin.H {
+		fields["assetId"], fields["currentVersion"] = assetId, assetVersionOf(t, assetId)
+		return fields
+	}
+	nodeId := modifyBehaviourTree(t, router, "CreateBehaviourTreeNode", createNodeReq(assetId, version, content_modifier.Node_Sequence, nil), common.Success).DiffNodesInfos[0].ModifiedNodeId
+	descriptorId := modifyBehaviourTree(t, router, "CreateBehaviourTreeDescriptor", modificationReq(gin.H{"attachTo": nodeId, "descriptorType": content_modifier.Descriptor_Blackboard}), common.Success).DiffDescriptorsInfos[0].ModifiedDescriptorId
+
+	//The Conditions Aren't Checked Before Binding, But The Binding Introducing The Issues Is Rejected
+	modifyBehaviourTree(t, router, "BindBehaviourTreeBlackBoard", modificationReq(gin.H{"blackBoardAssetId": assetId}), common.BtValidationFailed)
+	testCases := []struct {
+		condition gin.H
+		errCode   common.ErrorCode
+	}{
+		{gin.H{"key": "mana", "operator": content_modifier.BlackboardOperator_IsSet}, common.BtValidateUnknownBlackBoardKey},
+		{gin.H{"key": "alive", "operator": content_modifier.BlackboardOperator_Greater, "value": 1}, common.BtValidateIncompatibleBlackBoardKey},
+		{gin.H{"key": "hp", "operator": content_modifier.BlackboardOperator_Equal, "value": "high"}, common.BtValidateIncompatibleBlackBoardValue},
+	}
+	for _, testCase := range testCases {
+		modifyBehaviourTree(t, router, "UpdateBehaviourTreeDescriptorSettings", modificationReq(gin.H{"descriptorId": descriptorId, "settings": testCase.condition}), common.Success)
+		rejectedInfo := modifyBehaviourTree(t, router, "BindBehaviourTreeBlackBoard", modificationReq(gin.H{"blackBoardAssetId": blackBoardId}), common.BtValidationFailed)
+		if len(rejectedInfo.ValidationIssues) != 1 || rejectedInfo.ValidationIssues[0].ErrCode != testCase.errCode || rejectedInfo.ValidationIssues[0].AttachmentId != descriptorId {
+			t.Fatalf("binding with the condition %v reports %+v, expected the errCode %d", testCase.condition, rejectedInfo.ValidationIssues, testCase.errCode)
+		}
+	}
+
+	condition := gin.H{"key": "hp", "operator": content_modifier.BlackboardOperator_Greater, "value": 10}
+	modifyBehaviourTree(t, router, "UpdateBehaviourTreeDescriptorSettings", modificationReq(gin.H{"descriptorId": descriptorId, "settings": condition}), common.Success)
+	modifyBehaviourTree(t, router, "BindBehaviourTreeBlackBoard", modificationReq(gin.H{"blackBoardAssetId": blackBoardId}), common.Success)
+	if _, btDoc := readBehaviourTree(t, assetId); btDoc.BlackBoardAssetId != blackBoardId {
+		t.Fatalf("the behaviour tree is bound to %s", btDoc.BlackBoardAssetId)
+	}
+
+	//The Modifications Of The Black Board Report The References They Break
+	expectDanglingReference := func(modificationInfo *BlackBoardModification, errCode common.ErrorCode) {
+		t.Helper()
+		references := modificationInfo.DanglingReferences
+		if len(references) != 1 || references[0].AssetId != assetId || references[0].AttachmentId != descriptorId || references[0].KeyName != "hp" || references[0].ErrCode != errCode {
+			t.Fatalf("the dangling references are %+v, expected the errCode %d", references, errCode)
+		}
+	}
+	expectDanglingReference(modifyBlackBoard(t, router, "RenameBlackBoardKey", keyModificationReq(gin.H{"keyName": "hp", "newKeyName": "health"}), common.Success), common.BtValidateUnknownBlackBoardKey)
+	if references := modifyBlackBoard(t, router, "RenameBlackBoardKey", keyModificationReq(gin.H{"keyName": "health", "newKeyName": "hp"}), common.Success).DanglingReferences; len(references) != 0 {
+		t.Fatalf("renaming the key back reports %+v", references)
+	}
+	expectDanglingReference(modifyBlackBoard(t, router, "UpdateBlackBoardKey", keyModificationReq(gin.H{"key": gin.H{"name": "hp", "type": content_modifier.BlackBoardKey_Bool}}), common.Success), common.BtValidateIncompatibleBlackBoardKey)
+	expectDanglingReference(modifyBlackBoard(t, router, "RemoveBlackBoardKey", keyModificationReq(gin.H{"keyNames": []string{"hp"}}), common.Success), common.BtValidateUnknownBlackBoardKey)
+	if references := modifyBlackBoard(t, router, "RemoveBlackBoardKey", keyModificationReq(gin.H{"keyNames": []string{"alive"}}), common.Success).DanglingReferences; len(references) != 0 {
+		t.Fatalf("removing the key which isn't referred reports %+v", references)
+	}
+}
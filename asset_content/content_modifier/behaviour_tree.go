@@ -11,15 +11,26 @@ import (
 // "bt_root" : BTRootNode, // Not Supported Now
 // "bt_selector" : BTSelectorNode,
 // "bt_sequence" : BTSequenceNode,
-// "bt_simpleParallel" : BTSimpleParallelNode, Not Supported Now
+// "bt_simpleParallel" : BTSimpleParallelNode,
 // "bt_task" : BTTaskNode
+// "bt_runSubtree" : BTRunSubtreeNode
 const (
-	Node_Root     = "bt_root"
-	Node_Selector = "bt_selector"
-	Node_Sequence = "bt_sequence"
-	Node_Task     = "bt_task"
+	Node_Root           = "bt_root"
+	Node_Selector       = "bt_selector"
+	Node_Sequence       = "bt_sequence"
+	Node_SimpleParallel = "bt_simpleParallel"
+	Node_Task           = "bt_task"
+	Node_RunSubtree     = "bt_runSubtree"
 )
 
+func isCompositeNodeType(nodeType string) bool {
+	return nodeType == Node_Selector || nodeType == Node_Sequence || nodeType == Node_SimpleParallel
+}
+
+func isLeafNodeType(nodeType string) bool {
+	return nodeType == Node_Task || nodeType == Node_RunSubtree
+}
+
 type XYPosition struct {
 	X float32 `json:"x" binding:"required"`
 	Y float32 `json:"y" binding:"required"`
@@ -35,15 +46,21 @@ type LogicBtNode struct {
 }
 
 type LogicBtDescriptor struct {
-	DescriptorId string `json:"id" binding:"required"`
-	AttachTo     string `json:"attachTo" binding:"required"`
-	Order        int    `json:"order" binding:"required"`
+	DescriptorId   string          `json:"id" binding:"required"`
+	AttachTo       string          `json:"attachTo" binding:"required"`
+	Order          int             `json:"order" binding:"required"`
+	DescriptorType string          `json:"type" binding:"required"`
+	Settings       json.RawMessage `json:"settings" binding:"omitempty"`
 }
 
 type LogicBtService struct {
-	ServiceId string `json:"id" binding:"required"`
-	AttachTo  string `json:"attachTo" binding:"required"`
-	Order     int    `json:"order" binding:"required"`
+	ServiceId       string          `json:"id" binding:"required"`
+	AttachTo        string          `json:"attachTo" binding:"required"`
+	Order           int             `json:"order" binding:"required"`
+	ServiceType     string          `json:"type" binding:"required"`
+	TickInterval    float32         `json:"tickInterval" binding:"required"`
+	RandomDeviation float32         `json:"randomDeviation" binding:"required"`
+	Settings        json.RawMessage `json:"settings" binding:"omitempty"`
 }
 
 type BehaviourTreeDocumentation struct {
@@ -51,6 +68,8 @@ type BehaviourTreeDocumentation struct {
 	Nodes           []LogicBtNode
 	Descriptors     []LogicBtDescriptor
 	Services        []LogicBtService
+
+	BlackBoardAssetId string // the bound black board, the keys referred by the settings are validated against it
 }
 
 type BehaviourTreeNodeMovementItem struct {
@@ -64,6 +83,69 @@ type BehaviourTreeNodeDiffInfo struct {
 	PostModifiedNode *LogicBtNode `json:"postModifiedNode" binding:"required"`
 }
 
+type BehaviourTreeDescriptorDiffInfo struct {
+	ModifiedDescriptorId   string             `json:"modifiedDescriptorId" binding:"required"`
+	PreModifiedDescriptor  *LogicBtDescriptor `json:"preModifiedDescriptor" binding:"required"`
+	PostModifiedDescriptor *LogicBtDescriptor `json:"postModifiedDescriptor" binding:"required"`
+}
+
+type BehaviourTreeServiceDiffInfo struct {
+	ModifiedServiceId   string          `json:"modifiedServiceId" binding:"required"`
+	PreModifiedService  *LogicBtService `json:"preModifiedService" binding:"required"`
+	PostModifiedService *LogicBtService `json:"postModifiedService" binding:"required"`
+}
+
+// BehaviourTreeDiffInfos collects all kinds of diff infos produced by one modification of the document
+type BehaviourTreeDiffInfos struct {
+	NodeDiffInfos       []BehaviourTreeNodeDiffInfo       `json:"diffNodesInfos"`
+	DescriptorDiffInfos []BehaviourTreeDescriptorDiffInfo `json:"diffDescriptorsInfos"`
+	ServiceDiffInfos    []BehaviourTreeServiceDiffInfo    `json:"diffServicesInfos"`
+
+	BlackBoardDiffInfo *BehaviourTreeBlackBoardDiffInfo `json:"diffBlackBoardInfo,omitempty"`
+}
+
+func (diffInfos *BehaviourTreeDiffInfos) IsEmpty() bool {
+	return len(diffInfos.NodeDiffInfos) == 0 && len(diffInfos.DescriptorDiffInfos) == 0 && len(diffInfos.ServiceDiffInfos) == 0 && diffInfos.BlackBoardDiffInfo == nil
+}
+
+// Merge merges the diff infos of a later modification, the elements which are created and removed in the merged modifications are dropped
+func (diffInfos *BehaviourTreeDiffInfos) Merge(newDiffInfos BehaviourTreeDiffInfos) {
+	diffInfos.NodeDiffInfos = slices.DeleteFunc(mergeOrAppendNodeDiffInfo(diffInfos.NodeDiffInfos, newDiffInfos.NodeDiffInfos...), func(info BehaviourTreeNodeDiffInfo) bool {
+		return info.PreModifiedNode == nil && info.PostModifiedNode == nil
+	})
+	diffInfos.DescriptorDiffInfos = slices.DeleteFunc(mergeOrAppendDescriptorDiffInfo(diffInfos.DescriptorDiffInfos, newDiffInfos.DescriptorDiffInfos...), func(info BehaviourTreeDescriptorDiffInfo) bool {
+		return info.PreModifiedDescriptor == nil && info.PostModifiedDescriptor == nil
+	})
+	diffInfos.ServiceDiffInfos = slices.DeleteFunc(mergeOrAppendServiceDiffInfo(diffInfos.ServiceDiffInfos, newDiffInfos.ServiceDiffInfos...), func(info BehaviourTreeServiceDiffInfo) bool {
+		return info.PreModifiedService == nil && info.PostModifiedService == nil
+	})
+	if newDiffInfos.BlackBoardDiffInfo != nil {
+		mergedDiffInfo := *newDiffInfos.BlackBoardDiffInfo
+		if diffInfos.BlackBoardDiffInfo != nil {
+			mergedDiffInfo.PreBlackBoardAssetId = diffInfos.BlackBoardDiffInfo.PreBlackBoardAssetId
+		}
+		diffInfos.BlackBoardDiffInfo = &mergedDiffInfo
+		if mergedDiffInfo.PreBlackBoardAssetId == mergedDiffInfo.PostBlackBoardAssetId {
+			diffInfos.BlackBoardDiffInfo = nil
+		}
+	}
+}
+
+// WithNodeDiffInfos wraps the result of the modification which just modifies nodes
+func WithNodeDiffInfos(errCode common.ErrorCode, errMsg string, nodeDiffInfos []BehaviourTreeNodeDiffInfo) (common.ErrorCode, string, BehaviourTreeDiffInfos) {
+	return errCode, errMsg, BehaviourTreeDiffInfos{NodeDiffInfos: nodeDiffInfos}
+}
+
+// WithDescriptorDiffInfos wraps the result of the modification which just modifies descriptors
+func WithDescriptorDiffInfos(errCode common.ErrorCode, errMsg string, descriptorDiffInfos []BehaviourTreeDescriptorDiffInfo) (common.ErrorCode, string, BehaviourTreeDiffInfos) {
+	return errCode, errMsg, BehaviourTreeDiffInfos{DescriptorDiffInfos: descriptorDiffInfos}
+}
+
+// WithServiceDiffInfos wraps the result of the modification which just modifies services
+func WithServiceDiffInfos(errCode common.ErrorCode, errMsg string, serviceDiffInfos []BehaviourTreeServiceDiffInfo) (common.ErrorCode, string, BehaviourTreeDiffInfos) {
+	return errCode, errMsg, BehaviourTreeDiffInfos{ServiceDiffInfos: serviceDiffInfos}
+}
+
 // in this function, we just use the postModifiedNode of new diff info to replace the same info in exist array
 // we are not care the diff info is valid or not (for instance the post modified node is totally same as the previous one)
 func mergeOrAppendNodeDiffInfo(nodeDiffInfos []BehaviourTreeNodeDiffInfo, newDiffInfos ...BehaviourTreeNodeDiffInfo) []BehaviourTreeNodeDiffInfo {
@@ -116,15 +198,24 @@ func BehaviourTreeMoveNode(movementInfos []BehaviourTreeNodeMovementItem, doc *B
 				}
 
 				postModifiedNode := *modifyingNode
-				diffInfos = append(diffInfos, BehaviourTreeNodeDiffInfo{PreModifiedNode: &preModifiedNode, PostModifiedNode: &postModifiedNode})
+				diffInfos = append(diffInfos, BehaviourTreeNodeDiffInfo{ModifiedNodeId: preModifiedNode.NodeId, PreModifiedNode: &preModifiedNode, PostModifiedNode: &postModifiedNode})
 			}
 		}
 	}
 
 	//Reorder
 	for _, parentId := range parentIdsForMovedNode {
+		prevMainChildId := simpleParallelMainChildId(parentId, doc)
 		//Reorder
 		diffInfos = mergeOrAppendNodeDiffInfo(diffInfos, reorderBehaviourTreeNodesByParentId(doc, parentId)...)
+
+		// The Child Moved To The Main Place Of Simple Parallel Must Be A Task, The Modified Document Is Dropped When Failed
+		if parentIdx := slices.IndexFunc(doc.Nodes, func(n LogicBtNode) bool { return n.NodeId == parentId }); parentIdx >= 0 && doc.Nodes[parentIdx].NodeType == Node_SimpleParallel {
+			errCode, errMsg := checkSimpleParallelMainTask(parentId, prevMainChildId, doc)
+			if errCode != common.Success {
+				return errCode, errMsg, nil
+			}
+		}
 	}
 
 	return common.Success, "", diffInfos
@@ -134,10 +225,15 @@ func BehaviourTreeCreateNode(nodeType string, toPosition XYPosition, initialSett
 	//"bt_root" : BTRootNode, // Not Supported Now
 	//"bt_selector" : BTSelectorNode,
 	//"bt_sequence" : BTSequenceNode,
-	//"bt_simpleParallel" : BTSimpleParallelNode, Not Supported Now
+	//"bt_simpleParallel" : BTSimpleParallelNode,
 	//"bt_task" : BTTaskNode
+	//"bt_runSubtree" : BTRunSubtreeNode
 	diffInfos := make([]BehaviourTreeNodeDiffInfo, 0, 1)
-	if nodeType == Node_Selector || nodeType == Node_Sequence || nodeType == Node_Task {
+	errCode, errMsg, initialSettings := normalizeNodeSettings(nodeType, initialSettings)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	if isCompositeNodeType(nodeType) || isLeafNodeType(nodeType) {
 		newNode := LogicBtNode{uuid.New().String(), "", toPosition, nodeType, -1, initialSettings}
 		doc.Nodes = append(doc.Nodes, newNode)
 		diffInfos = []BehaviourTreeNodeDiffInfo{{newNode.NodeId, nil, &newNode}}
@@ -146,7 +242,7 @@ func BehaviourTreeCreateNode(nodeType string, toPosition XYPosition, initialSett
 	return common.BtInvalidNodeType, common.BtInvalidNodeType.GetMsgFormat(nodeType), nil
 }
 
-func BehaviourTreeRemoveNode(nodeIds []string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeNodeDiffInfo) {
+func BehaviourTreeRemoveNode(nodeIds []string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, BehaviourTreeDiffInfos) {
 
 	disconnectedParentIds := make([]string, 0, len(nodeIds)) //For Reorder
 
@@ -156,7 +252,7 @@ func BehaviourTreeRemoveNode(nodeIds []string, doc *BehaviourTreeDocumentation)
 	for _, existNode := range doc.Nodes {
 		if slices.Contains(nodeIds, existNode.NodeId) { // Need To Removed
 			if existNode.NodeType == Node_Root {
-				return common.BtIllegalRemoveRoot, common.BtIllegalRemoveRoot.GetMsg(), nil
+				return common.BtIllegalRemoveRoot, common.BtIllegalRemoveRoot.GetMsg(), BehaviourTreeDiffInfos{}
 			}
 
 			// For Reorder
@@ -183,12 +279,16 @@ func BehaviourTreeRemoveNode(nodeIds []string, doc *BehaviourTreeDocumentation)
 	for _, removedNodeId := range removedNodeIds {
 		errCode, errMsg, diffInfosForDisconnect := BehaviourTreeDisconnectNodeByParentId(removedNodeId, doc)
 		if errCode != common.Success {
-			return errCode, errMsg, nil
+			return errCode, errMsg, BehaviourTreeDiffInfos{}
 		}
 		diffInfos = mergeOrAppendNodeDiffInfo(diffInfos, diffInfosForDisconnect...)
 	}
 
-	return common.Success, "", diffInfos
+	//Remove The Attachments Of Removed Nodes
+	descriptorDiffInfos := behaviourTreeRemoveDescriptorsByAttachTo(removedNodeIds, doc)
+	serviceDiffInfos := behaviourTreeRemoveServicesByAttachTo(removedNodeIds, doc)
+
+	return common.Success, "", BehaviourTreeDiffInfos{NodeDiffInfos: diffInfos, DescriptorDiffInfos: descriptorDiffInfos, ServiceDiffInfos: serviceDiffInfos}
 }
 
 func BehaviourTreeConnectNode(parentId string, childId string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeNodeDiffInfo) {
@@ -217,9 +317,28 @@ func BehaviourTreeConnectNode(parentId string, childId string, doc *BehaviourTre
 		if doc.Nodes[pIdx].NodeType == Node_Task {
 			return common.BtConnectInvalidTaskForParent, common.BtConnectInvalidTaskForParent.GetMsgFormat(parentId), nil
 		}
+		// Check Run Subtree Always Not Parent
+		if doc.Nodes[pIdx].NodeType == Node_RunSubtree {
+			return common.BtConnectInvalidRunSubtreeForParent, common.BtConnectInvalidRunSubtreeForParent.GetMsgFormat(parentId), nil
+		}
 
-		//TODO Front End Has Cycle Check, Consider Do Check In BackEnd
+		// Check The Child Is Not The Parent Itself Or One Of Its Ancestors
+		// The Steps Are Limited By The Count Of Nodes In Case Of The Document Already Has A Cycle Above The Parent
+		ancestorId := parentId
+		for step := 0; ancestorId != "" && step <= len(doc.Nodes); step++ {
+			if ancestorId == childId {
+				return common.BtConnectCycleDetected, common.BtConnectCycleDetected.GetMsgFormat(parentId, childId), nil
+			}
+			ancestorIdx := slices.IndexFunc(doc.Nodes, func(n LogicBtNode) bool {
+				return n.NodeId == ancestorId
+			})
+			if ancestorIdx < 0 {
+				break
+			}
+			ancestorId = doc.Nodes[ancestorIdx].ParentId
+		}
 
+		prevMainChildId := simpleParallelMainChildId(parentId, doc)
 		if doc.Nodes[cIdx].ParentId != parentId { //If The Client Already Connect To The Request Parent, Skip
 			preModifiedNode := doc.Nodes[cIdx]
 			doc.Nodes[cIdx].ParentId = parentId
@@ -230,6 +349,18 @@ func BehaviourTreeConnectNode(parentId string, childId string, doc *BehaviourTre
 		//Reorder
 		diffInfos = mergeOrAppendNodeDiffInfo(diffInfos, reorderBehaviourTreeNodesByParentId(doc, parentId)...)
 
+		// Check The Children Rules Of Simple Parallel After Reorder, The Modified Document Is Dropped When Failed
+		if doc.Nodes[pIdx].NodeType == Node_SimpleParallel {
+			errCode, errMsg := checkSimpleParallelChildren(parentId, doc)
+			if errCode != common.Success {
+				return errCode, errMsg, nil
+			}
+			errCode, errMsg = checkSimpleParallelMainTask(parentId, prevMainChildId, doc)
+			if errCode != common.Success {
+				return errCode, errMsg, nil
+			}
+		}
+
 		return common.Success, "", diffInfos
 	} else if pIdx < 0 {
 		return common.BtConnectInvalidParent, common.BtConnectInvalidParent.GetMsgFormat(parentId), nil
@@ -304,6 +435,11 @@ func BehaviourTreeUpdateNodeSettings(nodeId string, settings json.RawMessage, do
 		if doc.Nodes[Idx].NodeId == nodeId {
 			// Get It
 			modifyingNode := &doc.Nodes[Idx]
+			errCode, errMsg, normalizedSettings := normalizeNodeSettings(modifyingNode.NodeType, settings)
+			if errCode != common.Success {
+				return errCode, errMsg, nil
+			}
+			settings = normalizedSettings
 			preModifiedNode := *modifyingNode
 			modifyingNode.Settings = settings
 			postModifiedNode := *modifyingNode
@@ -313,6 +449,31 @@ func BehaviourTreeUpdateNodeSettings(nodeId string, settings json.RawMessage, do
 	return common.BtUpdateSettingsInvalidNodeId, common.BtUpdateSettingsInvalidNodeId.GetMsgFormat(nodeId), nil
 }
 
+// normalizeNodeSettings checks the settings is an object without reserved keys, then applies the settings rules of the node types which have any
+func normalizeNodeSettings(nodeType string, settings json.RawMessage) (common.ErrorCode, string, json.RawMessage) {
+	errCode, errMsg := checkSettingsObject(settings, ReservedNodeSettingsKeys)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	switch nodeType {
+	case Node_SimpleParallel:
+		return normalizeSimpleParallelSettings(settings)
+	case Node_RunSubtree:
+		return normalizeRunSubtreeSettings(settings)
+	}
+	return common.Success, "", settings
+}
+
+func cmpFloat32(a, b float32) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
 func reorderBehaviourTreeNodesByParentId(doc *BehaviourTreeDocumentation, parentId string) []BehaviourTreeNodeDiffInfo {
 	reorderingNodes := make([]*LogicBtNode, 0, 8)
 	for i, _ := range doc.Nodes {
@@ -324,8 +485,15 @@ func reorderBehaviourTreeNodesByParentId(doc *BehaviourTreeDocumentation, parent
 
 	diffInfos := make([]BehaviourTreeNodeDiffInfo, 0, len(reorderingNodes)/2)
 	if len(reorderingNodes) > 0 {
-		slices.SortFunc(reorderingNodes, func(a, b *LogicBtNode) int {
-			return (int)(a.Position.X - b.Position.X)
+		// Sort By X, Then By Y For The Siblings In The Same Column (Left-Right Layout), Then Keep The Current Order
+		slices.SortStableFunc(reorderingNodes, func(a, b *LogicBtNode) int {
+			if c := cmpFloat32(a.Position.X, b.Position.X); c != 0 {
+				return c
+			}
+			if c := cmpFloat32(a.Position.Y, b.Position.Y); c != 0 {
+				return c
+			}
+			return a.Order - b.Order
 		})
 
 		for j, _ := range reorderingNodes {
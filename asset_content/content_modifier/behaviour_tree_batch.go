@@ -0,0 +1,126 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"strings"
+)
+
+const (
+	Operation_CreateNode         = "createNode"
+	Operation_MoveNode           = "moveNode"
+	Operation_ConnectNode        = "connectNode"
+	Operation_DisconnectNode     = "disconnectNode"
+	Operation_RemoveNode         = "removeNode"
+	Operation_UpdateNodeSettings = "updateNodeSettings"
+
+	// the node id which starts with NodeReferencePrefix refers to the node created by the previous operation in the same batch
+	NodeReferencePrefix = "$"
+)
+
+// BehaviourTreeOperation is one of the heterogeneous operations in a batch, only the fields for its operation type are used,
+// the positions are not validated by binding because zero is a valid coordinate here
+type BehaviourTreeOperation struct {
+	Operation string `json:"operation" binding:"required"`
+
+	// createNode
+	Ref             string          `json:"ref,omitempty"` // the later operations are able to use "$" + Ref as the id of the created node
+	NodeType        string          `json:"nodeType,omitempty"`
+	Position        XYPosition      `json:"position,omitempty" binding:"-"`
+	InitialSettings json.RawMessage `json:"initialSettings,omitempty"`
+	// moveNode
+	MovementItems []BehaviourTreeNodeMovementItem `json:"movements,omitempty" binding:"-"`
+	// connectNode
+	ParentNodeId string `json:"parentNodeId,omitempty"`
+	ChildNodeId  string `json:"childNodeId,omitempty"`
+	// disconnectNode
+	ChildNodeIds []string `json:"childNodeIds,omitempty"`
+	// removeNode
+	NodeIds []string `json:"nodeIds,omitempty"`
+	// updateNodeSettings
+	NodeId   string          `json:"nodeId,omitempty"`
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// BehaviourTreeApplyOperations runs the operations in order against the same document, the diff infos are merged,
+// the created node ids are returned by their refs. the caller must drop the document when it's failed
+func BehaviourTreeApplyOperations(operations []BehaviourTreeOperation, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, BehaviourTreeDiffInfos, map[string]string) {
+	createdNodeIds := make(map[string]string)
+	var mergedDiffInfos BehaviourTreeDiffInfos
+
+	for opIdx, op := range operations {
+		//Reference Resolving
+		var unknownRef string
+		resolve := func(nodeId string) string {
+			if !strings.HasPrefix(nodeId, NodeReferencePrefix) {
+				return nodeId
+			}
+			createdNodeId, exist := createdNodeIds[strings.TrimPrefix(nodeId, NodeReferencePrefix)]
+			if !exist && unknownRef == "" {
+				unknownRef = nodeId
+			}
+			return createdNodeId
+		}
+		resolveAll := func(nodeIds []string) []string {
+			resolvedIds := make([]string, 0, len(nodeIds))
+			for _, nodeId := range nodeIds {
+				resolvedIds = append(resolvedIds, resolve(nodeId))
+			}
+			return resolvedIds
+		}
+
+		var errCode common.ErrorCode
+		var errMsg string
+		var diffInfos BehaviourTreeDiffInfos
+		switch op.Operation {
+		case Operation_CreateNode:
+			if _, exist := createdNodeIds[op.Ref]; exist && op.Ref != "" {
+				return common.BtBatchDuplicatedReference, common.BtBatchDuplicatedReference.GetMsgFormat(op.Ref, opIdx), BehaviourTreeDiffInfos{}, nil
+			}
+			errCode, errMsg, diffInfos = WithNodeDiffInfos(BehaviourTreeCreateNode(op.NodeType, op.Position, op.InitialSettings, doc))
+			if errCode == common.Success && op.Ref != "" {
+				createdNodeIds[op.Ref] = diffInfos.NodeDiffInfos[0].ModifiedNodeId
+			}
+		case Operation_MoveNode:
+			movementItems := make([]BehaviourTreeNodeMovementItem, 0, len(op.MovementItems))
+			for _, item := range op.MovementItems {
+				movementItems = append(movementItems, BehaviourTreeNodeMovementItem{resolve(item.NodeId), item.ToPosition})
+			}
+			if unknownRef == "" {
+				errCode, errMsg, diffInfos = WithNodeDiffInfos(BehaviourTreeMoveNode(movementItems, doc))
+			}
+		case Operation_ConnectNode:
+			parentId, childId := resolve(op.ParentNodeId), resolve(op.ChildNodeId)
+			if unknownRef == "" {
+				errCode, errMsg, diffInfos = WithNodeDiffInfos(BehaviourTreeConnectNode(parentId, childId, doc))
+			}
+		case Operation_DisconnectNode:
+			childIds := resolveAll(op.ChildNodeIds)
+			if unknownRef == "" {
+				errCode, errMsg, diffInfos = WithNodeDiffInfos(BehaviourTreeDisconnectNode(childIds, doc))
+			}
+		case Operation_RemoveNode:
+			nodeIds := resolveAll(op.NodeIds)
+			if unknownRef == "" {
+				errCode, errMsg, diffInfos = BehaviourTreeRemoveNode(nodeIds, doc)
+			}
+		case Operation_UpdateNodeSettings:
+			nodeId := resolve(op.NodeId)
+			if unknownRef == "" {
+				errCode, errMsg, diffInfos = WithNodeDiffInfos(BehaviourTreeUpdateNodeSettings(nodeId, op.Settings, doc))
+			}
+		default:
+			return common.BtBatchInvalidOperation, common.BtBatchInvalidOperation.GetMsgFormat(op.Operation, opIdx), BehaviourTreeDiffInfos{}, nil
+		}
+
+		if unknownRef != "" {
+			return common.BtBatchUnknownReference, common.BtBatchUnknownReference.GetMsgFormat(unknownRef, opIdx), BehaviourTreeDiffInfos{}, nil
+		}
+		if errCode != common.Success {
+			return errCode, common.BtBatchOperationFailed.GetMsgFormat(opIdx, op.Operation, errMsg), BehaviourTreeDiffInfos{}, nil
+		}
+		mergedDiffInfos.Merge(diffInfos)
+	}
+
+	return common.Success, "", mergedDiffInfos, createdNodeIds
+}
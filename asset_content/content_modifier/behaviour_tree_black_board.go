@@ -0,0 +1,185 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+	"sort"
+	"strconv"
+)
+
+// Descriptor_Blackboard is the built-in blackboard condition decorator, its settings are {"key", "operator", "value"}
+const Descriptor_Blackboard = "bt_blackboard"
+
+const (
+	BlackboardOperator_IsSet          = "isSet"
+	BlackboardOperator_IsNotSet       = "isNotSet"
+	BlackboardOperator_Equal          = "equal"
+	BlackboardOperator_NotEqual       = "notEqual"
+	BlackboardOperator_Less           = "less"
+	BlackboardOperator_LessOrEqual    = "lessOrEqual"
+	BlackboardOperator_Greater        = "greater"
+	BlackboardOperator_GreaterOrEqual = "greaterOrEqual"
+)
+
+// Besides the built-in decorator, any JSON object in the settings of nodes, descriptors and services like
+// {"blackBoardKey": "target", "keyTypes": ["vector"]} refers to a key of the bound black board,
+// "keyTypes" is optional and all the types are acceptable without it
+const (
+	BlackBoardKeyReferenceKey      = "blackBoardKey"
+	BlackBoardKeyReferenceTypesKey = "keyTypes"
+)
+
+// BehaviourTreeBlackBoardDiffInfo is the change of the bound black board asset, the empty id means unbound
+type BehaviourTreeBlackBoardDiffInfo struct {
+	PreBlackBoardAssetId  string `json:"preBlackBoardAssetId"`
+	PostBlackBoardAssetId string `json:"postBlackBoardAssetId"`
+}
+
+// BehaviourTreeBlackBoardReference is a key of the black board referred by the settings of a node or an attachment
+type BehaviourTreeBlackBoardReference struct {
+	NodeId       string   `json:"nodeId" binding:"required"`
+	AttachmentId string   `json:"attachmentId,omitempty"`
+	Field        string   `json:"field" binding:"required"` // the path of the reference in the settings, like "targets.0"
+	KeyName      string   `json:"keyName" binding:"required"`
+	KeyTypes     []string `json:"keyTypes,omitempty"` // the acceptable key types, empty means any
+
+	comparedValue json.RawMessage // the value the key is compared with by the blackboard condition
+}
+
+func BehaviourTreeBindBlackBoard(blackBoardAssetId string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, BehaviourTreeDiffInfos) {
+	if doc.BlackBoardAssetId == blackBoardAssetId {
+		return common.Success, "", BehaviourTreeDiffInfos{}
+	}
+	diffInfo := BehaviourTreeBlackBoardDiffInfo{doc.BlackBoardAssetId, blackBoardAssetId}
+	doc.BlackBoardAssetId = blackBoardAssetId
+	return common.Success, "", BehaviourTreeDiffInfos{BlackBoardDiffInfo: &diffInfo}
+}
+
+// collectSettingsBlackBoardReferences walks the settings and finds the generic references
+func collectSettingsBlackBoardReferences(nodeId string, attachmentId string, settings json.RawMessage) []BehaviourTreeBlackBoardReference {
+	references := make([]BehaviourTreeBlackBoardReference, 0)
+	if len(settings) == 0 {
+		return references
+	}
+	var settingsValue interface{}
+	if json.Unmarshal(settings, &settingsValue) != nil {
+		return references
+	}
+
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		join := func(field string) string {
+			if path == "" {
+				return field
+			}
+			return path + "." + field
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if keyName, isString := v[BlackBoardKeyReferenceKey].(string); isString {
+				reference := BehaviourTreeBlackBoardReference{NodeId: nodeId, AttachmentId: attachmentId, Field: path, KeyName: keyName}
+				if keyTypes, isArray := v[BlackBoardKeyReferenceTypesKey].([]interface{}); isArray {
+					for _, keyType := range keyTypes {
+						if keyTypeString, isString := keyType.(string); isString {
+							reference.KeyTypes = append(reference.KeyTypes, keyTypeString)
+						}
+					}
+				}
+				references = append(references, reference)
+				return
+			}
+			fields := make([]string, 0, len(v))
+			for field := range v {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields) // keep the order stable for the reports
+			for _, field := range fields {
+				walk(join(field), v[field])
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(join(strconv.Itoa(i)), item)
+			}
+		}
+	}
+	walk("", settingsValue)
+	return references
+}
+
+// blackboardConditionReference returns the reference of the built-in decorator, the key types depend on the operator
+func blackboardConditionReference(descriptor *LogicBtDescriptor) (BehaviourTreeBlackBoardReference, bool) {
+	var condition struct {
+		Key      string          `json:"key"`
+		Operator string          `json:"operator"`
+		Value    json.RawMessage `json:"value"`
+	}
+	if len(descriptor.Settings) == 0 || json.Unmarshal(descriptor.Settings, &condition) != nil || condition.Key == "" {
+		return BehaviourTreeBlackBoardReference{}, false
+	}
+
+	reference := BehaviourTreeBlackBoardReference{NodeId: descriptor.AttachTo, AttachmentId: descriptor.DescriptorId, Field: "key", KeyName: condition.Key}
+	switch condition.Operator {
+	case BlackboardOperator_Equal, BlackboardOperator_NotEqual:
+		reference.comparedValue = condition.Value
+	case BlackboardOperator_Less, BlackboardOperator_LessOrEqual, BlackboardOperator_Greater, BlackboardOperator_GreaterOrEqual:
+		reference.KeyTypes = []string{BlackBoardKey_Int, BlackBoardKey_Float}
+	}
+	return reference, true
+}
+
+// BehaviourTreeCollectBlackBoardReferences returns all the black board keys referred by the document
+func BehaviourTreeCollectBlackBoardReferences(doc *BehaviourTreeDocumentation) []BehaviourTreeBlackBoardReference {
+	references := make([]BehaviourTreeBlackBoardReference, 0)
+	for _, node := range doc.Nodes {
+		references = append(references, collectSettingsBlackBoardReferences(node.NodeId, "", node.Settings)...)
+	}
+	for i := range doc.Descriptors {
+		descriptor := &doc.Descriptors[i]
+		if descriptor.DescriptorType == Descriptor_Blackboard {
+			if reference, exist := blackboardConditionReference(descriptor); exist {
+				references = append(references, reference)
+			}
+			continue
+		}
+		references = append(references, collectSettingsBlackBoardReferences(descriptor.AttachTo, descriptor.DescriptorId, descriptor.Settings)...)
+	}
+	for _, service := range doc.Services {
+		references = append(references, collectSettingsBlackBoardReferences(service.AttachTo, service.ServiceId, service.Settings)...)
+	}
+	return references
+}
+
+// BehaviourTreeCheckBlackBoardReference tells whether the key referred exists in the black board with a compatible type
+func BehaviourTreeCheckBlackBoardReference(reference *BehaviourTreeBlackBoardReference, bbDoc *BlackBoardDocumentation) BehaviourTreeValidationIssue {
+	issue := BehaviourTreeValidationIssue{NodeId: reference.NodeId, AttachmentId: reference.AttachmentId, ErrCode: common.Success}
+	kIdx := blackBoardKeyIndex(reference.KeyName, bbDoc)
+	if kIdx < 0 {
+		issue.ErrCode = common.BtValidateUnknownBlackBoardKey
+		issue.ErrMessage = issue.ErrCode.GetMsgFormat(reference.Field, reference.KeyName)
+		return issue
+	}
+	key := &bbDoc.Keys[kIdx]
+	if len(reference.KeyTypes) > 0 && !slices.Contains(reference.KeyTypes, key.KeyType) {
+		issue.ErrCode = common.BtValidateIncompatibleBlackBoardKey
+		issue.ErrMessage = issue.ErrCode.GetMsgFormat(reference.Field, reference.KeyName, key.KeyType, reference.KeyTypes)
+		return issue
+	}
+	if len(reference.comparedValue) > 0 && string(reference.comparedValue) != "null" && !CheckBlackBoardValue(key, reference.comparedValue) {
+		issue.ErrCode = common.BtValidateIncompatibleBlackBoardValue
+		issue.ErrMessage = issue.ErrCode.GetMsgFormat(string(reference.comparedValue), reference.KeyName, key.KeyType)
+		return issue
+	}
+	return issue
+}
+
+// BehaviourTreeCheckBlackBoardReferences reports the references which are dangling or incompatible in the black board
+func BehaviourTreeCheckBlackBoardReferences(references []BehaviourTreeBlackBoardReference, bbDoc *BlackBoardDocumentation) []BehaviourTreeValidationIssue {
+	issues := make([]BehaviourTreeValidationIssue, 0)
+	for i := range references {
+		if issue := BehaviourTreeCheckBlackBoardReference(&references[i], bbDoc); issue.ErrCode != common.Success {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,233 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+// in this function, we just use the postModifiedDescriptor of new diff info to replace the same info in exist array
+// it's the same as mergeOrAppendNodeDiffInfo
+func mergeOrAppendDescriptorDiffInfo(descriptorDiffInfos []BehaviourTreeDescriptorDiffInfo, newDiffInfos ...BehaviourTreeDescriptorDiffInfo) []BehaviourTreeDescriptorDiffInfo {
+	for _, newDiffInfo := range newDiffInfos {
+		existDescriptorIdx := slices.IndexFunc(descriptorDiffInfos, func(info BehaviourTreeDescriptorDiffInfo) bool {
+			return info.ModifiedDescriptorId == newDiffInfo.ModifiedDescriptorId
+		})
+		if existDescriptorIdx > -1 {
+			descriptorDiffInfos[existDescriptorIdx].PostModifiedDescriptor = newDiffInfo.PostModifiedDescriptor
+		} else {
+			descriptorDiffInfos = append(descriptorDiffInfos, newDiffInfo)
+		}
+	}
+	return descriptorDiffInfos
+}
+
+func checkDescriptorAttachTarget(nodeId string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string) {
+	nIdx := slices.IndexFunc(doc.Nodes, func(n LogicBtNode) bool {
+		return n.NodeId == nodeId
+	})
+	if nIdx < 0 {
+		return common.BtDescriptorInvalidAttachTarget, common.BtDescriptorInvalidAttachTarget.GetMsgFormat(nodeId)
+	}
+	if doc.Nodes[nIdx].NodeType == Node_Root {
+		return common.BtDescriptorIllegalAttachToRoot, common.BtDescriptorIllegalAttachToRoot.GetMsg()
+	}
+	return common.Success, ""
+}
+
+func BehaviourTreeCreateDescriptor(attachTo string, descriptorType string, initialSettings json.RawMessage, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeDescriptorDiffInfo) {
+	errCode, errMsg := checkDescriptorAttachTarget(attachTo, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	errCode, errMsg = checkSettingsObject(initialSettings, ReservedDescriptorSettingsKeys)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	// The New Descriptor Is Always Appended To The Tail
+	order := 0
+	for _, descriptor := range doc.Descriptors {
+		if descriptor.AttachTo == attachTo {
+			order++
+		}
+	}
+
+	newDescriptor := LogicBtDescriptor{uuid.New().String(), attachTo, order, descriptorType, initialSettings}
+	doc.Descriptors = append(doc.Descriptors, newDescriptor)
+	return common.Success, "", []BehaviourTreeDescriptorDiffInfo{{newDescriptor.DescriptorId, nil, &newDescriptor}}
+}
+
+func BehaviourTreeRemoveDescriptor(descriptorIds []string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeDescriptorDiffInfo) {
+	for _, descriptorId := range descriptorIds {
+		if !slices.ContainsFunc(doc.Descriptors, func(d LogicBtDescriptor) bool { return d.DescriptorId == descriptorId }) {
+			return common.BtDescriptorInvalidDescriptorId, common.BtDescriptorInvalidDescriptorId.GetMsgFormat(descriptorId), nil
+		}
+	}
+
+	diffInfos := make([]BehaviourTreeDescriptorDiffInfo, 0, len(descriptorIds))
+	detachedNodeIds := make([]string, 0, len(descriptorIds)) //For Reorder
+	reserveDescriptors := make([]LogicBtDescriptor, 0, len(doc.Descriptors))
+	for _, existDescriptor := range doc.Descriptors {
+		if slices.Contains(descriptorIds, existDescriptor.DescriptorId) {
+			if slices.Index(detachedNodeIds, existDescriptor.AttachTo) < 0 {
+				detachedNodeIds = append(detachedNodeIds, existDescriptor.AttachTo)
+			}
+			diffInfos = append(diffInfos, BehaviourTreeDescriptorDiffInfo{existDescriptor.DescriptorId, &existDescriptor, nil})
+		} else {
+			reserveDescriptors = append(reserveDescriptors, existDescriptor)
+		}
+	}
+	doc.Descriptors = reserveDescriptors
+
+	//Reorder
+	for _, nodeId := range detachedNodeIds {
+		diffInfos = mergeOrAppendDescriptorDiffInfo(diffInfos, reorderBehaviourTreeDescriptorsByAttachTo(doc, nodeId)...)
+	}
+
+	return common.Success, "", diffInfos
+}
+
+func BehaviourTreeReorderDescriptors(nodeId string, orderedDescriptorIds []string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeDescriptorDiffInfo) {
+	attachedCount := 0
+	for _, descriptor := range doc.Descriptors {
+		if descriptor.AttachTo == nodeId {
+			attachedCount++
+			if !slices.Contains(orderedDescriptorIds, descriptor.DescriptorId) {
+				return common.BtReorderDescriptorsMismatch, common.BtReorderDescriptorsMismatch.GetMsgFormat(nodeId), nil
+			}
+		}
+	}
+	if attachedCount != len(orderedDescriptorIds) {
+		return common.BtReorderDescriptorsMismatch, common.BtReorderDescriptorsMismatch.GetMsgFormat(nodeId), nil
+	}
+
+	diffInfos := make([]BehaviourTreeDescriptorDiffInfo, 0, len(orderedDescriptorIds))
+	for i := range doc.Descriptors {
+		modifyingDescriptor := &doc.Descriptors[i]
+		if modifyingDescriptor.AttachTo != nodeId {
+			continue
+		}
+		order := slices.Index(orderedDescriptorIds, modifyingDescriptor.DescriptorId)
+		if modifyingDescriptor.Order != order {
+			preModifiedDescriptor := *modifyingDescriptor
+			modifyingDescriptor.Order = order
+			postModifiedDescriptor := *modifyingDescriptor
+			diffInfos = append(diffInfos, BehaviourTreeDescriptorDiffInfo{preModifiedDescriptor.DescriptorId, &preModifiedDescriptor, &postModifiedDescriptor})
+		}
+	}
+	return common.Success, "", diffInfos
+}
+
+func BehaviourTreeUpdateDescriptorSettings(descriptorId string, settings json.RawMessage, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeDescriptorDiffInfo) {
+	errCode, errMsg := checkSettingsObject(settings, ReservedDescriptorSettingsKeys)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	for i := range doc.Descriptors {
+		if doc.Descriptors[i].DescriptorId == descriptorId {
+			modifyingDescriptor := &doc.Descriptors[i]
+			preModifiedDescriptor := *modifyingDescriptor
+			modifyingDescriptor.Settings = settings
+			postModifiedDescriptor := *modifyingDescriptor
+			return common.Success, "", []BehaviourTreeDescriptorDiffInfo{{descriptorId, &preModifiedDescriptor, &postModifiedDescriptor}}
+		}
+	}
+	return common.BtDescriptorInvalidDescriptorId, common.BtDescriptorInvalidDescriptorId.GetMsgFormat(descriptorId), nil
+}
+
+// BehaviourTreeMoveDescriptor moves the descriptor to the order of the target node,
+// the descriptor will be appended to the tail when the order is out of range
+func BehaviourTreeMoveDescriptor(descriptorId string, toNodeId string, toOrder int, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeDescriptorDiffInfo) {
+	dIdx := slices.IndexFunc(doc.Descriptors, func(d LogicBtDescriptor) bool {
+		return d.DescriptorId == descriptorId
+	})
+	if dIdx < 0 {
+		return common.BtDescriptorInvalidDescriptorId, common.BtDescriptorInvalidDescriptorId.GetMsgFormat(descriptorId), nil
+	}
+	errCode, errMsg := checkDescriptorAttachTarget(toNodeId, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	fromNodeId := doc.Descriptors[dIdx].AttachTo
+
+	//Collect The Descriptors Of Target Node In Order Without The Moving One
+	orderedDescriptorIds := make([]string, 0, 4)
+	{
+		targetDescriptors := make([]LogicBtDescriptor, 0, 4)
+		for _, descriptor := range doc.Descriptors {
+			if descriptor.AttachTo == toNodeId && descriptor.DescriptorId != descriptorId {
+				targetDescriptors = append(targetDescriptors, descriptor)
+			}
+		}
+		slices.SortStableFunc(targetDescriptors, func(a, b LogicBtDescriptor) int {
+			return a.Order - b.Order
+		})
+		for _, descriptor := range targetDescriptors {
+			orderedDescriptorIds = append(orderedDescriptorIds, descriptor.DescriptorId)
+		}
+	}
+	if toOrder < 0 || toOrder > len(orderedDescriptorIds) {
+		toOrder = len(orderedDescriptorIds)
+	}
+	orderedDescriptorIds = slices.Insert(orderedDescriptorIds, toOrder, descriptorId)
+
+	diffInfos := make([]BehaviourTreeDescriptorDiffInfo, 0, len(orderedDescriptorIds))
+	//Attach
+	if fromNodeId != toNodeId {
+		modifyingDescriptor := &doc.Descriptors[dIdx]
+		preModifiedDescriptor := *modifyingDescriptor
+		modifyingDescriptor.AttachTo = toNodeId
+		postModifiedDescriptor := *modifyingDescriptor
+		diffInfos = append(diffInfos, BehaviourTreeDescriptorDiffInfo{descriptorId, &preModifiedDescriptor, &postModifiedDescriptor})
+
+		diffInfos = mergeOrAppendDescriptorDiffInfo(diffInfos, reorderBehaviourTreeDescriptorsByAttachTo(doc, fromNodeId)...)
+	}
+
+	//Reorder
+	_, _, reorderDiffInfos := BehaviourTreeReorderDescriptors(toNodeId, orderedDescriptorIds, doc)
+	diffInfos = mergeOrAppendDescriptorDiffInfo(diffInfos, reorderDiffInfos...)
+
+	return common.Success, "", diffInfos
+}
+
+func behaviourTreeRemoveDescriptorsByAttachTo(nodeIds []string, doc *BehaviourTreeDocumentation) []BehaviourTreeDescriptorDiffInfo {
+	diffInfos := make([]BehaviourTreeDescriptorDiffInfo, 0)
+	reserveDescriptors := make([]LogicBtDescriptor, 0, len(doc.Descriptors))
+	for _, existDescriptor := range doc.Descriptors {
+		if slices.Contains(nodeIds, existDescriptor.AttachTo) {
+			diffInfos = append(diffInfos, BehaviourTreeDescriptorDiffInfo{existDescriptor.DescriptorId, &existDescriptor, nil})
+		} else {
+			reserveDescriptors = append(reserveDescriptors, existDescriptor)
+		}
+	}
+	doc.Descriptors = reserveDescriptors
+	return diffInfos
+}
+
+// reorderBehaviourTreeDescriptorsByAttachTo keeps the relative order of the descriptors and makes the orders continuous
+func reorderBehaviourTreeDescriptorsByAttachTo(doc *BehaviourTreeDocumentation, nodeId string) []BehaviourTreeDescriptorDiffInfo {
+	reorderingDescriptors := make([]*LogicBtDescriptor, 0, 4)
+	for i := range doc.Descriptors {
+		if doc.Descriptors[i].AttachTo == nodeId {
+			reorderingDescriptors = append(reorderingDescriptors, &doc.Descriptors[i])
+		}
+	}
+
+	diffInfos := make([]BehaviourTreeDescriptorDiffInfo, 0, len(reorderingDescriptors)/2)
+	slices.SortStableFunc(reorderingDescriptors, func(a, b *LogicBtDescriptor) int {
+		return a.Order - b.Order
+	})
+	for j := range reorderingDescriptors {
+		if reorderingDescriptors[j].Order != j {
+			preModifiedDescriptor := *reorderingDescriptors[j]
+			reorderingDescriptors[j].Order = j
+			postModifiedDescriptor := *reorderingDescriptors[j]
+			diffInfos = append(diffInfos, BehaviourTreeDescriptorDiffInfo{preModifiedDescriptor.DescriptorId, &preModifiedDescriptor, &postModifiedDescriptor})
+		}
+	}
+	return diffInfos
+}
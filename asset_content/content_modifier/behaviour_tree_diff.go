@@ -0,0 +1,138 @@
+package content_modifier
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+// Invert swaps the pre-modified and the post-modified parts of all diff infos,
+// applying the inverted diff infos will revert the original modification
+func (diffInfos *BehaviourTreeDiffInfos) Invert() BehaviourTreeDiffInfos {
+	inverted := BehaviourTreeDiffInfos{
+		NodeDiffInfos:       make([]BehaviourTreeNodeDiffInfo, 0, len(diffInfos.NodeDiffInfos)),
+		DescriptorDiffInfos: make([]BehaviourTreeDescriptorDiffInfo, 0, len(diffInfos.DescriptorDiffInfos)),
+		ServiceDiffInfos:    make([]BehaviourTreeServiceDiffInfo, 0, len(diffInfos.ServiceDiffInfos)),
+	}
+	for _, info := range diffInfos.NodeDiffInfos {
+		inverted.NodeDiffInfos = append(inverted.NodeDiffInfos, BehaviourTreeNodeDiffInfo{info.ModifiedNodeId, info.PostModifiedNode, info.PreModifiedNode})
+	}
+	for _, info := range diffInfos.DescriptorDiffInfos {
+		inverted.DescriptorDiffInfos = append(inverted.DescriptorDiffInfos, BehaviourTreeDescriptorDiffInfo{info.ModifiedDescriptorId, info.PostModifiedDescriptor, info.PreModifiedDescriptor})
+	}
+	for _, info := range diffInfos.ServiceDiffInfos {
+		inverted.ServiceDiffInfos = append(inverted.ServiceDiffInfos, BehaviourTreeServiceDiffInfo{info.ModifiedServiceId, info.PostModifiedService, info.PreModifiedService})
+	}
+	if info := diffInfos.BlackBoardDiffInfo; info != nil {
+		inverted.BlackBoardDiffInfo = &BehaviourTreeBlackBoardDiffInfo{info.PostBlackBoardAssetId, info.PreBlackBoardAssetId}
+	}
+	return inverted
+}
+
+// applyDiffInfo makes the element identified by id become the post one.
+// the element must be absent when the pre one is nil, and must be present otherwise, or it's a conflict
+func applyDiffInfo[E any](elements []E, getId func(e *E) string, id string, pre *E, post *E) ([]E, bool) {
+	eIdx := slices.IndexFunc(elements, func(e E) bool {
+		return getId(&e) == id
+	})
+	if (pre == nil) != (eIdx < 0) {
+		return elements, false
+	}
+
+	switch {
+	case pre == nil && post == nil: // created and removed in the same modification
+	case pre == nil:
+		elements = append(elements, *post)
+	case post == nil:
+		elements = slices.Delete(elements, eIdx, eIdx+1)
+	default:
+		elements[eIdx] = *post
+	}
+	return elements, true
+}
+
+// BehaviourTreeApplyDiffInfos replays the diff infos onto the document,
+// it's used to redo the modification or to undo the modification with the inverted diff infos
+func BehaviourTreeApplyDiffInfos(diffInfos BehaviourTreeDiffInfos, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, BehaviourTreeDiffInfos) {
+	var applied bool
+	for _, info := range diffInfos.NodeDiffInfos {
+		doc.Nodes, applied = applyDiffInfo(doc.Nodes, func(n *LogicBtNode) string { return n.NodeId }, info.ModifiedNodeId, info.PreModifiedNode, info.PostModifiedNode)
+		if !applied {
+			return common.BtHistoryConflict, common.BtHistoryConflict.GetMsgFormat("Node", info.ModifiedNodeId), BehaviourTreeDiffInfos{}
+		}
+	}
+	for _, info := range diffInfos.DescriptorDiffInfos {
+		doc.Descriptors, applied = applyDiffInfo(doc.Descriptors, func(d *LogicBtDescriptor) string { return d.DescriptorId }, info.ModifiedDescriptorId, info.PreModifiedDescriptor, info.PostModifiedDescriptor)
+		if !applied {
+			return common.BtHistoryConflict, common.BtHistoryConflict.GetMsgFormat("Descriptor", info.ModifiedDescriptorId), BehaviourTreeDiffInfos{}
+		}
+	}
+	for _, info := range diffInfos.ServiceDiffInfos {
+		doc.Services, applied = applyDiffInfo(doc.Services, func(s *LogicBtService) string { return s.ServiceId }, info.ModifiedServiceId, info.PreModifiedService, info.PostModifiedService)
+		if !applied {
+			return common.BtHistoryConflict, common.BtHistoryConflict.GetMsgFormat("Service", info.ModifiedServiceId), BehaviourTreeDiffInfos{}
+		}
+	}
+	if info := diffInfos.BlackBoardDiffInfo; info != nil {
+		if doc.BlackBoardAssetId != info.PreBlackBoardAssetId {
+			return common.BtHistoryConflict, common.BtHistoryConflict.GetMsgFormat("BlackBoard", info.PreBlackBoardAssetId), BehaviourTreeDiffInfos{}
+		}
+		doc.BlackBoardAssetId = info.PostBlackBoardAssetId
+	}
+	return common.Success, "", diffInfos
+}
+
+// BehaviourTreeTouchedIds is the ids of the elements which are created, modified or removed by some modifications
+type BehaviourTreeTouchedIds struct {
+	NodeIds       []string `json:"nodeIds" binding:"required"`
+	DescriptorIds []string `json:"descriptorIds" binding:"required"`
+	ServiceIds    []string `json:"serviceIds" binding:"required"`
+	BlackBoard    bool     `json:"blackBoard,omitempty"` // the binding of the black board is touched
+}
+
+func (touchedIds *BehaviourTreeTouchedIds) IsEmpty() bool {
+	return len(touchedIds.NodeIds) == 0 && len(touchedIds.DescriptorIds) == 0 && len(touchedIds.ServiceIds) == 0 && !touchedIds.BlackBoard
+}
+
+func appendUniqueIds(ids []string, newIds ...string) []string {
+	for _, newId := range newIds {
+		if !slices.Contains(ids, newId) {
+			ids = append(ids, newId)
+		}
+	}
+	return ids
+}
+
+// Add collects the ids touched by the diff infos
+func (touchedIds *BehaviourTreeTouchedIds) Add(diffInfos BehaviourTreeDiffInfos) {
+	for _, info := range diffInfos.NodeDiffInfos {
+		touchedIds.NodeIds = appendUniqueIds(touchedIds.NodeIds, info.ModifiedNodeId)
+	}
+	for _, info := range diffInfos.DescriptorDiffInfos {
+		touchedIds.DescriptorIds = appendUniqueIds(touchedIds.DescriptorIds, info.ModifiedDescriptorId)
+	}
+	for _, info := range diffInfos.ServiceDiffInfos {
+		touchedIds.ServiceIds = appendUniqueIds(touchedIds.ServiceIds, info.ModifiedServiceId)
+	}
+	if diffInfos.BlackBoardDiffInfo != nil {
+		touchedIds.BlackBoard = true
+	}
+}
+
+// Intersect returns the ids which are touched by both
+func (touchedIds *BehaviourTreeTouchedIds) Intersect(other BehaviourTreeTouchedIds) BehaviourTreeTouchedIds {
+	intersect := func(a []string, b []string) []string {
+		ids := make([]string, 0)
+		for _, id := range a {
+			if slices.Contains(b, id) {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	return BehaviourTreeTouchedIds{
+		NodeIds:       intersect(touchedIds.NodeIds, other.NodeIds),
+		DescriptorIds: intersect(touchedIds.DescriptorIds, other.DescriptorIds),
+		ServiceIds:    intersect(touchedIds.ServiceIds, other.ServiceIds),
+		BlackBoard:    touchedIds.BlackBoard && other.BlackBoard,
+	}
+}
@@ -0,0 +1,98 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+func cloneSettings(settings json.RawMessage) json.RawMessage {
+	if settings == nil {
+		return nil
+	}
+	return slices.Clone(settings)
+}
+
+// BehaviourTreeDuplicateSubtree clones the subtrees under rootNodeIds of sourceDoc into targetDoc with fresh ids,
+// sourceDoc and targetDoc are able to be the same one. the links and orders inside the subtrees are preserved,
+// the cloned roots are left disconnected. the id map from the source ids to the cloned ids is returned as well
+func BehaviourTreeDuplicateSubtree(rootNodeIds []string, offset XYPosition, sourceDoc *BehaviourTreeDocumentation, targetDoc *BehaviourTreeDocumentation) (common.ErrorCode, string, BehaviourTreeDiffInfos, map[string]string) {
+	// Check The Subtree Roots
+	for _, rootNodeId := range rootNodeIds {
+		nIdx := slices.IndexFunc(sourceDoc.Nodes, func(n LogicBtNode) bool {
+			return n.NodeId == rootNodeId
+		})
+		if nIdx < 0 {
+			return common.BtDuplicateInvalidNodeId, common.BtDuplicateInvalidNodeId.GetMsgFormat(rootNodeId), BehaviourTreeDiffInfos{}, nil
+		}
+		if sourceDoc.Nodes[nIdx].NodeType == Node_Root {
+			return common.BtDuplicateIllegalRoot, common.BtDuplicateIllegalRoot.GetMsg(), BehaviourTreeDiffInfos{}, nil
+		}
+	}
+
+	// Collect The Descendants, The Visited Ids Keep It Finite Even If The Source Has A Cycle
+	duplicatedNodeIds := make(map[string]string)
+	pendingNodeIds := slices.Clone(rootNodeIds)
+	for len(pendingNodeIds) > 0 {
+		nodeId := pendingNodeIds[0]
+		pendingNodeIds = pendingNodeIds[1:]
+		if _, visited := duplicatedNodeIds[nodeId]; visited {
+			continue
+		}
+		duplicatedNodeIds[nodeId] = uuid.New().String()
+		for _, node := range sourceDoc.Nodes {
+			if node.ParentId == nodeId {
+				pendingNodeIds = append(pendingNodeIds, node.NodeId)
+			}
+		}
+	}
+
+	// Clone Everything Before Appending Because The Source Is Able To Be The Target
+	clonedNodes := make([]LogicBtNode, 0, len(duplicatedNodeIds))
+	for _, node := range sourceDoc.Nodes {
+		newNodeId, exist := duplicatedNodeIds[node.NodeId]
+		if !exist {
+			continue
+		}
+		clonedNode := LogicBtNode{newNodeId, "", XYPosition{node.Position.X + offset.X, node.Position.Y + offset.Y}, node.NodeType, -1, cloneSettings(node.Settings)}
+		if newParentId, parentExist := duplicatedNodeIds[node.ParentId]; parentExist {
+			clonedNode.ParentId = newParentId
+			clonedNode.Order = node.Order
+		}
+		clonedNodes = append(clonedNodes, clonedNode)
+	}
+	clonedDescriptors := make([]LogicBtDescriptor, 0)
+	for _, descriptor := range sourceDoc.Descriptors {
+		if newAttachTo, exist := duplicatedNodeIds[descriptor.AttachTo]; exist {
+			clonedDescriptors = append(clonedDescriptors, LogicBtDescriptor{uuid.New().String(), newAttachTo, descriptor.Order, descriptor.DescriptorType, cloneSettings(descriptor.Settings)})
+		}
+	}
+	clonedServices := make([]LogicBtService, 0)
+	for _, service := range sourceDoc.Services {
+		if newAttachTo, exist := duplicatedNodeIds[service.AttachTo]; exist {
+			clonedServices = append(clonedServices, LogicBtService{uuid.New().String(), newAttachTo, service.Order, service.ServiceType, service.TickInterval, service.RandomDeviation, cloneSettings(service.Settings)})
+		}
+	}
+
+	// Append To The Target
+	diffInfos := BehaviourTreeDiffInfos{
+		NodeDiffInfos:       make([]BehaviourTreeNodeDiffInfo, 0, len(clonedNodes)),
+		DescriptorDiffInfos: make([]BehaviourTreeDescriptorDiffInfo, 0, len(clonedDescriptors)),
+		ServiceDiffInfos:    make([]BehaviourTreeServiceDiffInfo, 0, len(clonedServices)),
+	}
+	for i := range clonedNodes {
+		targetDoc.Nodes = append(targetDoc.Nodes, clonedNodes[i])
+		diffInfos.NodeDiffInfos = append(diffInfos.NodeDiffInfos, BehaviourTreeNodeDiffInfo{clonedNodes[i].NodeId, nil, &clonedNodes[i]})
+	}
+	for i := range clonedDescriptors {
+		targetDoc.Descriptors = append(targetDoc.Descriptors, clonedDescriptors[i])
+		diffInfos.DescriptorDiffInfos = append(diffInfos.DescriptorDiffInfos, BehaviourTreeDescriptorDiffInfo{clonedDescriptors[i].DescriptorId, nil, &clonedDescriptors[i]})
+	}
+	for i := range clonedServices {
+		targetDoc.Services = append(targetDoc.Services, clonedServices[i])
+		diffInfos.ServiceDiffInfos = append(diffInfos.ServiceDiffInfos, BehaviourTreeServiceDiffInfo{clonedServices[i].ServiceId, nil, &clonedServices[i]})
+	}
+
+	return common.Success, "", diffInfos, duplicatedNodeIds
+}
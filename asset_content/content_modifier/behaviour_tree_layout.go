@@ -0,0 +1,96 @@
+package content_modifier
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	LayoutOrientation_TopDown   = "topDown"   // the children are below the parent and arranged from left to right
+	LayoutOrientation_LeftRight = "leftRight" // the children are on the right of the parent and arranged from top to bottom
+
+	DefaultLayoutSiblingSpacing float32 = 200
+	DefaultLayoutLevelSpacing   float32 = 160
+)
+
+// BehaviourTreeLayoutOptions decides the shape of the auto layout,
+// siblingSpacing is the distance between adjacent leaves and levelSpacing is the distance between a parent and its children
+type BehaviourTreeLayoutOptions struct {
+	Orientation    string
+	SiblingSpacing float32
+	LevelSpacing   float32
+}
+
+// BehaviourTreeAutoLayout places the nodes reachable from the root as a tidy tree, every parent is centered over its children.
+// the root keeps its position, the nodes which are not connected to the root are not touched.
+// the children are placed in their current order, so the sibling order is still the same after reordering by positions
+func BehaviourTreeAutoLayout(options BehaviourTreeLayoutOptions, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeNodeDiffInfo) {
+	if options.Orientation != LayoutOrientation_TopDown && options.Orientation != LayoutOrientation_LeftRight {
+		return common.BtLayoutInvalidOrientation, common.BtLayoutInvalidOrientation.GetMsgFormat(options.Orientation), nil
+	}
+	if options.SiblingSpacing <= 0 || options.LevelSpacing <= 0 {
+		return common.BtLayoutInvalidSpacing, common.BtLayoutInvalidSpacing.GetMsgFormat(options.SiblingSpacing, options.LevelSpacing), nil
+	}
+
+	rootIdx := slices.IndexFunc(doc.Nodes, func(n LogicBtNode) bool {
+		return n.NodeType == Node_Root
+	})
+	if rootIdx < 0 {
+		return common.BtValidateInvalidRootCount, common.BtValidateInvalidRootCount.GetMsgFormat(0), nil
+	}
+
+	childrenByParentId := make(map[string][]*LogicBtNode, len(doc.Nodes))
+	for i := range doc.Nodes {
+		if doc.Nodes[i].ParentId != "" {
+			childrenByParentId[doc.Nodes[i].ParentId] = append(childrenByParentId[doc.Nodes[i].ParentId], &doc.Nodes[i])
+		}
+	}
+	for _, children := range childrenByParentId {
+		slices.SortStableFunc(children, func(a, b *LogicBtNode) int {
+			return a.Order - b.Order
+		})
+	}
+
+	// Breadth Is The Axis Of Siblings And Depth Is The Axis Of Levels, The Visited Ids Keep It Finite Even If There Is A Cycle
+	breadthById := make(map[string]float32, len(doc.Nodes))
+	depthById := make(map[string]float32, len(doc.Nodes))
+	nextLeafBreadth := float32(0)
+	var place func(node *LogicBtNode, depth float32)
+	place = func(node *LogicBtNode, depth float32) {
+		depthById[node.NodeId] = depth
+		breadthById[node.NodeId] = 0 // Mark Visited
+		placedChildren := make([]*LogicBtNode, 0, len(childrenByParentId[node.NodeId]))
+		for _, child := range childrenByParentId[node.NodeId] {
+			if _, visited := depthById[child.NodeId]; !visited {
+				place(child, depth+options.LevelSpacing)
+				placedChildren = append(placedChildren, child)
+			}
+		}
+		if len(placedChildren) == 0 {
+			breadthById[node.NodeId] = nextLeafBreadth
+			nextLeafBreadth += options.SiblingSpacing
+			return
+		}
+		breadthById[node.NodeId] = (breadthById[placedChildren[0].NodeId] + breadthById[placedChildren[len(placedChildren)-1].NodeId]) / 2
+	}
+	root := &doc.Nodes[rootIdx]
+	place(root, 0)
+
+	// Translate The Layout To Keep The Root In Place
+	rootPosition := root.Position
+	rootBreadth := breadthById[root.NodeId]
+	movementItems := make([]BehaviourTreeNodeMovementItem, 0, len(breadthById))
+	for _, node := range doc.Nodes {
+		breadth, placed := breadthById[node.NodeId]
+		if !placed {
+			continue
+		}
+		toPosition := XYPosition{rootPosition.X + breadth - rootBreadth, rootPosition.Y + depthById[node.NodeId]}
+		if options.Orientation == LayoutOrientation_LeftRight {
+			toPosition = XYPosition{rootPosition.X + depthById[node.NodeId], rootPosition.Y + breadth - rootBreadth}
+		}
+		movementItems = append(movementItems, BehaviourTreeNodeMovementItem{node.NodeId, toPosition})
+	}
+
+	return BehaviourTreeMoveNode(movementItems, doc)
+}
@@ -0,0 +1,60 @@
+package content_modifier
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"testing"
+)
+
+// newLayoutTestDocument builds root -> sequence -> [a, b, c] and a -> [a0, a1], the positions are against the sibling order
+func newLayoutTestDocument() *BehaviourTreeDocumentation {
+	node := func(nodeId string, nodeType string, parentId string, order int, x float32, y float32) LogicBtNode {
+		return LogicBtNode{NodeId: nodeId, ParentId: parentId, NodeType: nodeType, Order: order, Position: XYPosition{x, y}}
+	}
+	return &BehaviourTreeDocumentation{Nodes: []LogicBtNode{
+		node("root", Node_Root, "", 0, 0, 0),
+		node("sequence", Node_Sequence, "root", 0, 0, 100),
+		node("c", Node_Task, "sequence", 2, -300, -300),
+		node("b", Node_Task, "sequence", 1, 0, 300),
+		node("a", Node_Selector, "sequence", 0, 300, 200),
+		node("a1", Node_Task, "a", 1, 0, 0),
+		node("a0", Node_Task, "a", 0, 10, 10),
+	}}
+}
+
+func TestBehaviourTreeAutoLayoutKeepsSiblingOrder(t *testing.T) {
+	for _, orientation := range []string{LayoutOrientation_TopDown, LayoutOrientation_LeftRight} {
+		t.Run(orientation, func(t *testing.T) {
+			doc := newLayoutTestDocument()
+			orders := make(map[string]int, len(doc.Nodes))
+			for _, n := range doc.Nodes {
+				orders[n.NodeId] = n.Order
+			}
+
+			options := BehaviourTreeLayoutOptions{Orientation: orientation, SiblingSpacing: DefaultLayoutSiblingSpacing, LevelSpacing: DefaultLayoutLevelSpacing}
+			errCode, errMsg, diffInfos := BehaviourTreeAutoLayout(options, doc)
+			if errCode != common.Success {
+				t.Fatal(errMsg)
+			}
+			if len(diffInfos) == 0 {
+				t.Fatal("nothing is moved by the layout")
+			}
+			for _, n := range doc.Nodes {
+				if n.Order != orders[n.NodeId] {
+					t.Fatalf("the order of %s is changed by the layout to %d", n.NodeId, n.Order)
+				}
+			}
+
+			//Reordering By The Positions After The Layout Changes Nothing
+			for _, parentId := range []string{"sequence", "a"} {
+				if reorderDiffInfos := reorderBehaviourTreeNodesByParentId(doc, parentId); len(reorderDiffInfos) != 0 {
+					t.Fatalf("the children of %s are reordered after the layout: %+v", parentId, reorderDiffInfos)
+				}
+			}
+			for _, n := range doc.Nodes {
+				if n.Order != orders[n.NodeId] {
+					t.Fatalf("the order of %s is changed by reordering to %d", n.NodeId, n.Order)
+				}
+			}
+		})
+	}
+}
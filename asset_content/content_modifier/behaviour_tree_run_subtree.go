@@ -0,0 +1,59 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+)
+
+// The run subtree node is a leaf which runs the root of another BehaviourTree asset in the same solution,
+// the referenced asset id is kept in its settings with the key RunSubtreeAssetIdKey
+const RunSubtreeAssetIdKey = "subtreeAssetId"
+
+// BehaviourTreeSubtreeReference is a run subtree node and the asset it refers to
+type BehaviourTreeSubtreeReference struct {
+	NodeId         string `json:"nodeId" binding:"required"`
+	SubtreeAssetId string `json:"subtreeAssetId" binding:"required"`
+}
+
+// normalizeRunSubtreeSettings rejects the referenced asset id which is not a string, an absent one is acceptable during editing
+func normalizeRunSubtreeSettings(settings json.RawMessage) (common.ErrorCode, string, json.RawMessage) {
+	settingsMap := make(map[string]interface{})
+	if len(settings) > 0 && string(settings) != "null" {
+		err := json.Unmarshal(settings, &settingsMap)
+		if err != nil {
+			return common.DeserializationError, err.Error(), nil
+		}
+	}
+
+	if subtreeAssetId, exist := settingsMap[RunSubtreeAssetIdKey]; exist {
+		if _, isString := subtreeAssetId.(string); !isString {
+			return common.BtRunSubtreeInvalidSettings, common.BtRunSubtreeInvalidSettings.GetMsgFormat(subtreeAssetId), nil
+		}
+	}
+	return common.Success, "", settings
+}
+
+// GetRunSubtreeAssetId returns the referenced asset id in settings, empty string is returned when it's absent
+func GetRunSubtreeAssetId(settings json.RawMessage) string {
+	var runSubtreeSettings struct {
+		SubtreeAssetId string `json:"subtreeAssetId"`
+	}
+	if len(settings) > 0 {
+		_ = json.Unmarshal(settings, &runSubtreeSettings)
+	}
+	return runSubtreeSettings.SubtreeAssetId
+}
+
+// BehaviourTreeCollectSubtreeReferences returns the references of all run subtree nodes which have referred to an asset
+func BehaviourTreeCollectSubtreeReferences(doc *BehaviourTreeDocumentation) []BehaviourTreeSubtreeReference {
+	references := make([]BehaviourTreeSubtreeReference, 0)
+	for _, node := range doc.Nodes {
+		if node.NodeType != Node_RunSubtree {
+			continue
+		}
+		if subtreeAssetId := GetRunSubtreeAssetId(node.Settings); subtreeAssetId != "" {
+			references = append(references, BehaviourTreeSubtreeReference{node.NodeId, subtreeAssetId})
+		}
+	}
+	return references
+}
@@ -0,0 +1,202 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	DefaultServiceTickInterval    float32 = 0.5
+	DefaultServiceRandomDeviation float32 = 0.1
+)
+
+// in this function, we just use the postModifiedService of new diff info to replace the same info in exist array
+// it's the same as mergeOrAppendNodeDiffInfo
+func mergeOrAppendServiceDiffInfo(serviceDiffInfos []BehaviourTreeServiceDiffInfo, newDiffInfos ...BehaviourTreeServiceDiffInfo) []BehaviourTreeServiceDiffInfo {
+	for _, newDiffInfo := range newDiffInfos {
+		existServiceIdx := slices.IndexFunc(serviceDiffInfos, func(info BehaviourTreeServiceDiffInfo) bool {
+			return info.ModifiedServiceId == newDiffInfo.ModifiedServiceId
+		})
+		if existServiceIdx > -1 {
+			serviceDiffInfos[existServiceIdx].PostModifiedService = newDiffInfo.PostModifiedService
+		} else {
+			serviceDiffInfos = append(serviceDiffInfos, newDiffInfo)
+		}
+	}
+	return serviceDiffInfos
+}
+
+// Services are only able to be attached to the composite nodes
+func checkServiceAttachTarget(nodeId string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string) {
+	nIdx := slices.IndexFunc(doc.Nodes, func(n LogicBtNode) bool {
+		return n.NodeId == nodeId
+	})
+	if nIdx < 0 {
+		return common.BtServiceInvalidAttachTarget, common.BtServiceInvalidAttachTarget.GetMsgFormat(nodeId)
+	}
+	nodeType := doc.Nodes[nIdx].NodeType
+	if !isCompositeNodeType(nodeType) {
+		return common.BtServiceIllegalAttachTarget, common.BtServiceIllegalAttachTarget.GetMsgFormat(nodeType)
+	}
+	return common.Success, ""
+}
+
+func checkServiceTickInterval(tickInterval float32, randomDeviation float32) (common.ErrorCode, string) {
+	if tickInterval <= 0 || randomDeviation < 0 || randomDeviation > tickInterval {
+		return common.BtServiceInvalidTickInterval, common.BtServiceInvalidTickInterval.GetMsgFormat(tickInterval, randomDeviation)
+	}
+	return common.Success, ""
+}
+
+func BehaviourTreeCreateService(attachTo string, serviceType string, tickInterval float32, randomDeviation float32, initialSettings json.RawMessage, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeServiceDiffInfo) {
+	errCode, errMsg := checkServiceAttachTarget(attachTo, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	errCode, errMsg = checkServiceTickInterval(tickInterval, randomDeviation)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	errCode, errMsg = checkSettingsObject(initialSettings, ReservedServiceSettingsKeys)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	// The New Service Is Always Appended To The Tail
+	order := 0
+	for _, service := range doc.Services {
+		if service.AttachTo == attachTo {
+			order++
+		}
+	}
+
+	newService := LogicBtService{uuid.New().String(), attachTo, order, serviceType, tickInterval, randomDeviation, initialSettings}
+	doc.Services = append(doc.Services, newService)
+	return common.Success, "", []BehaviourTreeServiceDiffInfo{{newService.ServiceId, nil, &newService}}
+}
+
+func BehaviourTreeRemoveService(serviceIds []string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeServiceDiffInfo) {
+	for _, serviceId := range serviceIds {
+		if !slices.ContainsFunc(doc.Services, func(s LogicBtService) bool { return s.ServiceId == serviceId }) {
+			return common.BtServiceInvalidServiceId, common.BtServiceInvalidServiceId.GetMsgFormat(serviceId), nil
+		}
+	}
+
+	diffInfos := make([]BehaviourTreeServiceDiffInfo, 0, len(serviceIds))
+	detachedNodeIds := make([]string, 0, len(serviceIds)) //For Reorder
+	reserveServices := make([]LogicBtService, 0, len(doc.Services))
+	for _, existService := range doc.Services {
+		if slices.Contains(serviceIds, existService.ServiceId) {
+			if slices.Index(detachedNodeIds, existService.AttachTo) < 0 {
+				detachedNodeIds = append(detachedNodeIds, existService.AttachTo)
+			}
+			diffInfos = append(diffInfos, BehaviourTreeServiceDiffInfo{existService.ServiceId, &existService, nil})
+		} else {
+			reserveServices = append(reserveServices, existService)
+		}
+	}
+	doc.Services = reserveServices
+
+	//Reorder
+	for _, nodeId := range detachedNodeIds {
+		diffInfos = mergeOrAppendServiceDiffInfo(diffInfos, reorderBehaviourTreeServicesByAttachTo(doc, nodeId)...)
+	}
+
+	return common.Success, "", diffInfos
+}
+
+func BehaviourTreeReorderServices(nodeId string, orderedServiceIds []string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeServiceDiffInfo) {
+	attachedCount := 0
+	for _, service := range doc.Services {
+		if service.AttachTo == nodeId {
+			attachedCount++
+			if !slices.Contains(orderedServiceIds, service.ServiceId) {
+				return common.BtReorderServicesMismatch, common.BtReorderServicesMismatch.GetMsgFormat(nodeId), nil
+			}
+		}
+	}
+	if attachedCount != len(orderedServiceIds) {
+		return common.BtReorderServicesMismatch, common.BtReorderServicesMismatch.GetMsgFormat(nodeId), nil
+	}
+
+	diffInfos := make([]BehaviourTreeServiceDiffInfo, 0, len(orderedServiceIds))
+	for i := range doc.Services {
+		modifyingService := &doc.Services[i]
+		if modifyingService.AttachTo != nodeId {
+			continue
+		}
+		order := slices.Index(orderedServiceIds, modifyingService.ServiceId)
+		if modifyingService.Order != order {
+			preModifiedService := *modifyingService
+			modifyingService.Order = order
+			postModifiedService := *modifyingService
+			diffInfos = append(diffInfos, BehaviourTreeServiceDiffInfo{preModifiedService.ServiceId, &preModifiedService, &postModifiedService})
+		}
+	}
+	return common.Success, "", diffInfos
+}
+
+func BehaviourTreeUpdateServiceSettings(serviceId string, serviceType string, tickInterval float32, randomDeviation float32, settings json.RawMessage, doc *BehaviourTreeDocumentation) (common.ErrorCode, string, []BehaviourTreeServiceDiffInfo) {
+	errCode, errMsg := checkServiceTickInterval(tickInterval, randomDeviation)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	errCode, errMsg = checkSettingsObject(settings, ReservedServiceSettingsKeys)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	for i := range doc.Services {
+		if doc.Services[i].ServiceId == serviceId {
+			modifyingService := &doc.Services[i]
+			preModifiedService := *modifyingService
+			modifyingService.ServiceType = serviceType
+			modifyingService.TickInterval = tickInterval
+			modifyingService.RandomDeviation = randomDeviation
+			modifyingService.Settings = settings
+			postModifiedService := *modifyingService
+			return common.Success, "", []BehaviourTreeServiceDiffInfo{{serviceId, &preModifiedService, &postModifiedService}}
+		}
+	}
+	return common.BtServiceInvalidServiceId, common.BtServiceInvalidServiceId.GetMsgFormat(serviceId), nil
+}
+
+func behaviourTreeRemoveServicesByAttachTo(nodeIds []string, doc *BehaviourTreeDocumentation) []BehaviourTreeServiceDiffInfo {
+	diffInfos := make([]BehaviourTreeServiceDiffInfo, 0)
+	reserveServices := make([]LogicBtService, 0, len(doc.Services))
+	for _, existService := range doc.Services {
+		if slices.Contains(nodeIds, existService.AttachTo) {
+			diffInfos = append(diffInfos, BehaviourTreeServiceDiffInfo{existService.ServiceId, &existService, nil})
+		} else {
+			reserveServices = append(reserveServices, existService)
+		}
+	}
+	doc.Services = reserveServices
+	return diffInfos
+}
+
+// reorderBehaviourTreeServicesByAttachTo keeps the relative order of the services and makes the orders continuous
+func reorderBehaviourTreeServicesByAttachTo(doc *BehaviourTreeDocumentation, nodeId string) []BehaviourTreeServiceDiffInfo {
+	reorderingServices := make([]*LogicBtService, 0, 4)
+	for i := range doc.Services {
+		if doc.Services[i].AttachTo == nodeId {
+			reorderingServices = append(reorderingServices, &doc.Services[i])
+		}
+	}
+
+	diffInfos := make([]BehaviourTreeServiceDiffInfo, 0, len(reorderingServices)/2)
+	slices.SortStableFunc(reorderingServices, func(a, b *LogicBtService) int {
+		return a.Order - b.Order
+	})
+	for j := range reorderingServices {
+		if reorderingServices[j].Order != j {
+			preModifiedService := *reorderingServices[j]
+			reorderingServices[j].Order = j
+			postModifiedService := *reorderingServices[j]
+			diffInfos = append(diffInfos, BehaviourTreeServiceDiffInfo{preModifiedService.ServiceId, &preModifiedService, &postModifiedService})
+		}
+	}
+	return diffInfos
+}
@@ -0,0 +1,99 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+)
+
+// The simple parallel node runs its first child as the main task, and its second child as the background subtree
+// while the main task is running. the children are ordered by their X position, so the main task must be placed left of
+// the background child, it's reported by the validation rather than rejected, since the children are connected one by one.
+// finishMode decides what happens to the background subtree when the main task is finished:
+// "immediate" aborts the background subtree, "delayed" waits for the background subtree to be finished
+const (
+	SimpleParallelFinishMode_Immediate = "immediate"
+	SimpleParallelFinishMode_Delayed   = "delayed"
+
+	SimpleParallelFinishModeKey = "finishMode"
+)
+
+// normalizeSimpleParallelSettings fills the default finish mode and rejects the invalid one
+func normalizeSimpleParallelSettings(settings json.RawMessage) (common.ErrorCode, string, json.RawMessage) {
+	settingsMap := make(map[string]interface{})
+	if len(settings) > 0 && string(settings) != "null" {
+		err := json.Unmarshal(settings, &settingsMap)
+		if err != nil {
+			return common.DeserializationError, err.Error(), nil
+		}
+	}
+
+	finishMode, exist := settingsMap[SimpleParallelFinishModeKey]
+	if !exist {
+		settingsMap[SimpleParallelFinishModeKey] = SimpleParallelFinishMode_Immediate
+	} else if finishMode != SimpleParallelFinishMode_Immediate && finishMode != SimpleParallelFinishMode_Delayed {
+		return common.BtSimpleParallelInvalidFinishMode, common.BtSimpleParallelInvalidFinishMode.GetMsgFormat(finishMode), nil
+	}
+
+	normalizedSettings, err := json.Marshal(settingsMap)
+	if err != nil {
+		return common.SerializationError, err.Error(), nil
+	}
+	return common.Success, "", normalizedSettings
+}
+
+// GetSimpleParallelFinishMode returns the finish mode in settings, the default one is returned when it's absent
+func GetSimpleParallelFinishMode(settings json.RawMessage) string {
+	var parallelSettings struct {
+		FinishMode string `json:"finishMode"`
+	}
+	if len(settings) > 0 {
+		_ = json.Unmarshal(settings, &parallelSettings)
+	}
+	if parallelSettings.FinishMode == "" {
+		return SimpleParallelFinishMode_Immediate
+	}
+	return parallelSettings.FinishMode
+}
+
+// checkSimpleParallelChildren checks there are at most two children, the missing children are acceptable during editing
+func checkSimpleParallelChildren(parallelNodeId string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string) {
+	childCount := 0
+	for _, node := range doc.Nodes {
+		if node.ParentId == parallelNodeId {
+			childCount++
+		}
+	}
+	if childCount > 2 {
+		return common.BtSimpleParallelTooManyChildren, common.BtSimpleParallelTooManyChildren.GetMsgFormat(parallelNodeId)
+	}
+	return common.Success, ""
+}
+
+// simpleParallelMainChild returns the child which runs as the main task, it's nil when there are no children
+func simpleParallelMainChild(parallelNodeId string, doc *BehaviourTreeDocumentation) *LogicBtNode {
+	var mainChild *LogicBtNode
+	for i, node := range doc.Nodes {
+		if node.ParentId == parallelNodeId && (mainChild == nil || node.Order < mainChild.Order) {
+			mainChild = &doc.Nodes[i]
+		}
+	}
+	return mainChild
+}
+
+// simpleParallelMainChildId returns the id of the main child, it's empty when there are no children
+func simpleParallelMainChildId(parallelNodeId string, doc *BehaviourTreeDocumentation) string {
+	if mainChild := simpleParallelMainChild(parallelNodeId, doc); mainChild != nil {
+		return mainChild.NodeId
+	}
+	return ""
+}
+
+// checkSimpleParallelMainTask rejects the main child which is not a task when connecting or reordering makes it the main one,
+// the main child which is not changed is left to the validation
+func checkSimpleParallelMainTask(parallelNodeId string, prevMainChildId string, doc *BehaviourTreeDocumentation) (common.ErrorCode, string) {
+	mainChild := simpleParallelMainChild(parallelNodeId, doc)
+	if mainChild == nil || mainChild.NodeId == prevMainChildId || mainChild.NodeType == Node_Task {
+		return common.Success, ""
+	}
+	return common.BtSimpleParallelInvalidMainTask, common.BtSimpleParallelInvalidMainTask.GetMsgFormat(mainChild.NodeId, parallelNodeId)
+}
@@ -0,0 +1,55 @@
+package content_modifier
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"testing"
+)
+
+func newSimpleParallelTestDocument() *BehaviourTreeDocumentation {
+	return &BehaviourTreeDocumentation{Nodes: []LogicBtNode{
+		{NodeId: "parallel", NodeType: Node_SimpleParallel, Order: -1, Position: XYPosition{0, 0}},
+		{NodeId: "task", NodeType: Node_Task, Order: -1, Position: XYPosition{0, 100}},
+		{NodeId: "sequence", NodeType: Node_Sequence, Order: -1, Position: XYPosition{100, 100}},
+	}}
+}
+
+func TestSimpleParallelConnectRejectsNonTaskMainChild(t *testing.T) {
+	doc := newSimpleParallelTestDocument()
+	if errCode, _, _ := BehaviourTreeConnectNode("parallel", "sequence", doc); errCode != common.BtSimpleParallelInvalidMainTask {
+		t.Fatalf("the sequence is connected as the main child: %d", errCode)
+	}
+
+	doc = newSimpleParallelTestDocument()
+	if errCode, errMsg, _ := BehaviourTreeConnectNode("parallel", "task", doc); errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	//The Sequence On The Right Of The Main Task Is The Background Child
+	if errCode, errMsg, _ := BehaviourTreeConnectNode("parallel", "sequence", doc); errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+}
+
+func TestSimpleParallelMoveRejectsNonTaskMainChild(t *testing.T) {
+	doc := newSimpleParallelTestDocument()
+	for _, childId := range []string{"task", "sequence"} {
+		if errCode, errMsg, _ := BehaviourTreeConnectNode("parallel", childId, doc); errCode != common.Success {
+			t.Fatal(errMsg)
+		}
+	}
+
+	//Moving The Background Child Left Of The Main Task Makes It The Main One
+	errCode, _, _ := BehaviourTreeMoveNode([]BehaviourTreeNodeMovementItem{{NodeId: "sequence", ToPosition: XYPosition{-100, 100}}}, doc)
+	if errCode != common.BtSimpleParallelInvalidMainTask {
+		t.Fatalf("the sequence is moved to the main place: %d", errCode)
+	}
+
+	//Moving Without Changing The Main Child Is Fine
+	doc = newSimpleParallelTestDocument()
+	for _, childId := range []string{"task", "sequence"} {
+		BehaviourTreeConnectNode("parallel", childId, doc)
+	}
+	errCode, errMsg, _ := BehaviourTreeMoveNode([]BehaviourTreeNodeMovementItem{{NodeId: "sequence", ToPosition: XYPosition{200, 100}}}, doc)
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+}
@@ -0,0 +1,179 @@
+package content_modifier
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+type BehaviourTreeValidationIssue struct {
+	NodeId       string           `json:"nodeId" binding:"required"` // empty only when the issue is about the whole document, for instance there isn't any root
+	AttachmentId string           `json:"attachmentId,omitempty"`    // the id of descriptor or service when the issue is about an attachment
+	ErrCode      common.ErrorCode `json:"errCode" binding:"required"`
+	ErrMessage   string           `json:"errMessage" binding:"required"`
+
+	FieldErrors []SettingsFieldError `json:"fieldErrors,omitempty"` // the violations of the settings schema
+}
+
+func newBehaviourTreeValidationIssue(nodeId string, errCode common.ErrorCode, params ...any) BehaviourTreeValidationIssue {
+	return BehaviourTreeValidationIssue{NodeId: nodeId, ErrCode: errCode, ErrMessage: errCode.GetMsgFormat(params...)}
+}
+
+// BehaviourTreeCheckStructure reports the issues which make the document structurally broken,
+// these issues are never expected to be produced by any modification
+func BehaviourTreeCheckStructure(doc *BehaviourTreeDocumentation) []BehaviourTreeValidationIssue {
+	issues := make([]BehaviourTreeValidationIssue, 0)
+
+	parentIdByNodeId := make(map[string]string, len(doc.Nodes))
+	childCountByNodeId := make(map[string]int, len(doc.Nodes))
+	rootIds := make([]string, 0, 1)
+
+	//Duplicated Node Id And Root Counting Pass
+	for _, node := range doc.Nodes {
+		if _, exist := parentIdByNodeId[node.NodeId]; exist {
+			issues = append(issues, newBehaviourTreeValidationIssue(node.NodeId, common.BtValidateDuplicatedNodeId, node.NodeId))
+			continue
+		}
+		parentIdByNodeId[node.NodeId] = node.ParentId
+		if node.NodeType == Node_Root {
+			rootIds = append(rootIds, node.NodeId)
+		}
+	}
+	if len(rootIds) == 0 {
+		issues = append(issues, newBehaviourTreeValidationIssue("", common.BtValidateInvalidRootCount, len(rootIds)))
+	}
+	for _, extraRootId := range rootIds[min(len(rootIds), 1):] { // the first root is kept, every extra one is reported
+		issues = append(issues, newBehaviourTreeValidationIssue(extraRootId, common.BtValidateInvalidRootCount, len(rootIds)))
+	}
+
+	//Unknown Parent Pass
+	for _, node := range doc.Nodes {
+		if node.ParentId == "" {
+			continue
+		}
+		if _, exist := parentIdByNodeId[node.ParentId]; !exist {
+			issues = append(issues, newBehaviourTreeValidationIssue(node.NodeId, common.BtValidateUnknownParent, node.NodeId, node.ParentId))
+			continue
+		}
+		childCountByNodeId[node.ParentId]++
+	}
+
+	//Cycle Pass
+	cyclicNodeIds := make([]string, 0)
+	checkedNodeIds := make(map[string]bool, len(doc.Nodes))
+	for _, node := range doc.Nodes {
+		walkingPath := make([]string, 0, 8)
+		for nodeId := node.NodeId; nodeId != "" && !checkedNodeIds[nodeId]; nodeId = parentIdByNodeId[nodeId] {
+			if pathIdx := slices.Index(walkingPath, nodeId); pathIdx > -1 {
+				cyclicNodeIds = append(cyclicNodeIds, walkingPath[pathIdx:]...)
+				break
+			}
+			walkingPath = append(walkingPath, nodeId)
+		}
+		for _, nodeId := range walkingPath {
+			checkedNodeIds[nodeId] = true
+		}
+	}
+	for _, node := range doc.Nodes {
+		if slices.Contains(cyclicNodeIds, node.NodeId) {
+			issues = append(issues, newBehaviourTreeValidationIssue(node.NodeId, common.BtValidateCycleDetected, node.NodeId))
+		}
+	}
+
+	//Root Children Pass
+	for _, rootId := range rootIds {
+		if childCountByNodeId[rootId] > 1 {
+			issues = append(issues, newBehaviourTreeValidationIssue(rootId, common.BtValidateRootWithMultipleChildren, rootId, childCountByNodeId[rootId]))
+		}
+	}
+
+	//Simple Parallel Pass
+	for _, node := range doc.Nodes {
+		if node.NodeType == Node_SimpleParallel {
+			errCode, errMsg := checkSimpleParallelChildren(node.NodeId, doc)
+			if errCode != common.Success {
+				issues = append(issues, BehaviourTreeValidationIssue{NodeId: node.NodeId, ErrCode: errCode, ErrMessage: errMsg})
+			}
+		}
+	}
+
+	//Attachments Pass
+	for _, descriptor := range doc.Descriptors {
+		if _, exist := parentIdByNodeId[descriptor.AttachTo]; !exist {
+			issue := newBehaviourTreeValidationIssue(descriptor.AttachTo, common.BtValidateUnknownAttachTarget, descriptor.DescriptorId, descriptor.AttachTo)
+			issue.AttachmentId = descriptor.DescriptorId
+			issues = append(issues, issue)
+		}
+	}
+	for _, service := range doc.Services {
+		if _, exist := parentIdByNodeId[service.AttachTo]; !exist {
+			issue := newBehaviourTreeValidationIssue(service.AttachTo, common.BtValidateUnknownAttachTarget, service.ServiceId, service.AttachTo)
+			issue.AttachmentId = service.ServiceId
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// BehaviourTreeValidate reports all issues of the document, including the ones which are acceptable during editing
+// (for instance a composite node which is just created and not connected with any child)
+func BehaviourTreeValidate(doc *BehaviourTreeDocumentation) []BehaviourTreeValidationIssue {
+	issues := BehaviourTreeCheckStructure(doc)
+
+	//Composite Without Children Pass
+	for _, node := range doc.Nodes {
+		if node.NodeType != Node_Selector && node.NodeType != Node_Sequence {
+			continue // The Children Of Simple Parallel Are Checked By Its Own Pass
+		}
+		hasChild := slices.ContainsFunc(doc.Nodes, func(n LogicBtNode) bool {
+			return n.ParentId == node.NodeId
+		})
+		if !hasChild {
+			issues = append(issues, newBehaviourTreeValidationIssue(node.NodeId, common.BtValidateCompositeWithoutChildren, node.NodeId))
+		}
+	}
+
+	//Simple Parallel Children Pass
+	for _, node := range doc.Nodes {
+		if node.NodeType != Node_SimpleParallel {
+			continue
+		}
+		childCount := 0
+		for _, n := range doc.Nodes {
+			if n.ParentId == node.NodeId {
+				childCount++
+			}
+		}
+		if childCount < 2 {
+			issues = append(issues, newBehaviourTreeValidationIssue(node.NodeId, common.BtValidateSimpleParallelIncomplete, node.NodeId, childCount))
+			continue // It's Unknown Which One Is The Background Child Yet
+		}
+		if mainChild := simpleParallelMainChild(node.NodeId, doc); mainChild.NodeType != Node_Task {
+			issues = append(issues, newBehaviourTreeValidationIssue(mainChild.NodeId, common.BtSimpleParallelInvalidMainTask, mainChild.NodeId, node.NodeId))
+		}
+	}
+
+	//Run Subtree Without Asset Pass
+	for _, node := range doc.Nodes {
+		if node.NodeType == Node_RunSubtree && GetRunSubtreeAssetId(node.Settings) == "" {
+			issues = append(issues, newBehaviourTreeValidationIssue(node.NodeId, common.BtValidateRunSubtreeWithoutAsset, node.NodeId))
+		}
+	}
+
+	return issues
+}
+
+// BehaviourTreeFilterNewIssues picks the issues which are not existed in the previous issues,
+// it's used to avoid blocking all modifications of a document which is already broken before
+func BehaviourTreeFilterNewIssues(prevIssues []BehaviourTreeValidationIssue, issues []BehaviourTreeValidationIssue) []BehaviourTreeValidationIssue {
+	newIssues := make([]BehaviourTreeValidationIssue, 0)
+	for _, issue := range issues {
+		existed := slices.ContainsFunc(prevIssues, func(prevIssue BehaviourTreeValidationIssue) bool {
+			return prevIssue.NodeId == issue.NodeId && prevIssue.AttachmentId == issue.AttachmentId && prevIssue.ErrCode == issue.ErrCode
+		})
+		if !existed {
+			newIssues = append(newIssues, issue)
+		}
+	}
+	return newIssues
+}
@@ -1 +1,272 @@
 package content_modifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+	"math"
+	"reflect"
+)
+
+// "bool" : true / false
+// "int" : integer number
+// "float" : number
+// "string" : string
+// "vector" : {"x": number, "y": number, "z": number}
+// "object" : the reference of a runtime object, it's always null as default
+// "enum" : one of the enumValues
+const (
+	BlackBoardKey_Bool   = "bool"
+	BlackBoardKey_Int    = "int"
+	BlackBoardKey_Float  = "float"
+	BlackBoardKey_String = "string"
+	BlackBoardKey_Vector = "vector"
+	BlackBoardKey_Object = "object"
+	BlackBoardKey_Enum   = "enum"
+)
+
+func isValidBlackBoardKeyType(keyType string) bool {
+	return keyType == BlackBoardKey_Bool || keyType == BlackBoardKey_Int || keyType == BlackBoardKey_Float || keyType == BlackBoardKey_String ||
+		keyType == BlackBoardKey_Vector || keyType == BlackBoardKey_Object || keyType == BlackBoardKey_Enum
+}
+
+type BlackBoardVector struct {
+	X *float64 `json:"x"`
+	Y *float64 `json:"y"`
+	Z *float64 `json:"z"`
+}
+
+// BlackBoardKey is identified by its name, which is used by the other assets to refer to it
+type BlackBoardKey struct {
+	KeyName      string          `json:"name" binding:"required"`
+	KeyType      string          `json:"type" binding:"required"`
+	DefaultValue json.RawMessage `json:"defaultValue" binding:"omitempty"` // the zero value of the type when absent
+	Description  string          `json:"description" binding:"omitempty"`
+	EnumValues   []string        `json:"enumValues,omitempty" binding:"omitempty"` // only for enum
+}
+
+type BlackBoardDocumentation struct {
+	ModifyTimeStamp int64
+	Keys            []BlackBoardKey
+}
+
+type BlackBoardKeyDiffInfo struct {
+	ModifiedKeyName string         `json:"modifiedKeyName" binding:"required"` // the name before modified
+	PreModifiedKey  *BlackBoardKey `json:"preModifiedKey" binding:"required"`
+	PostModifiedKey *BlackBoardKey `json:"postModifiedKey" binding:"required"`
+}
+
+func BlackBoardCreateEmptyContent() (errCode common.ErrorCode, errMsg string, content string) {
+	blackBoardDocumentation := BlackBoardDocumentation{
+		Keys: []BlackBoardKey{},
+	}
+
+	b, err := json.Marshal(blackBoardDocumentation)
+	if err != nil {
+		return common.SerializationError, common.SerializationError.GetMsg(), ""
+	}
+	return common.Success, "", string(b)
+}
+
+// BlackBoardDeserialize also accepts the empty content of the black boards which are created before the content is supported
+func BlackBoardDeserialize(content string, doc *BlackBoardDocumentation) error {
+	if content == "" {
+		doc.Keys = []BlackBoardKey{}
+		return nil
+	}
+	return json.Unmarshal([]byte(content), doc)
+}
+
+func blackBoardKeyIndex(keyName string, doc *BlackBoardDocumentation) int {
+	return slices.IndexFunc(doc.Keys, func(k BlackBoardKey) bool {
+		return k.KeyName == keyName
+	})
+}
+
+func checkBlackBoardKeyName(keyName string, doc *BlackBoardDocumentation) (common.ErrorCode, string) {
+	if keyName == "" {
+		return common.BbInvalidKeyName, common.BbInvalidKeyName.GetMsgFormat(keyName)
+	}
+	if blackBoardKeyIndex(keyName, doc) > -1 {
+		return common.BbDuplicatedKeyName, common.BbDuplicatedKeyName.GetMsgFormat(keyName)
+	}
+	return common.Success, ""
+}
+
+// normalizeBlackBoardKey checks the type, the enum values and the default value, the zero value of the type is used when the default value is absent
+func normalizeBlackBoardKey(key *BlackBoardKey) (common.ErrorCode, string) {
+	if !isValidBlackBoardKeyType(key.KeyType) {
+		return common.BbInvalidKeyType, common.BbInvalidKeyType.GetMsgFormat(key.KeyType)
+	}
+
+	if key.KeyType != BlackBoardKey_Enum {
+		key.EnumValues = nil
+	} else {
+		if len(key.EnumValues) == 0 {
+			return common.BbInvalidEnumValues, common.BbInvalidEnumValues.GetMsgFormat(key.KeyName)
+		}
+		for i, enumValue := range key.EnumValues {
+			if enumValue == "" || slices.Index(key.EnumValues, enumValue) != i {
+				return common.BbInvalidEnumValues, common.BbInvalidEnumValues.GetMsgFormat(key.KeyName)
+			}
+		}
+	}
+
+	if len(key.DefaultValue) == 0 || string(key.DefaultValue) == "null" {
+		key.DefaultValue = blackBoardZeroValue(key)
+		return common.Success, ""
+	}
+	if !CheckBlackBoardValue(key, key.DefaultValue) {
+		return common.BbInvalidDefaultValue, common.BbInvalidDefaultValue.GetMsgFormat(string(key.DefaultValue), key.KeyName, key.KeyType)
+	}
+	key.DefaultValue = compactJSON(key.DefaultValue)
+	return common.Success, ""
+}
+
+func compactJSON(value json.RawMessage) json.RawMessage {
+	var buffer bytes.Buffer
+	if json.Compact(&buffer, value) != nil {
+		return value
+	}
+	return buffer.Bytes()
+}
+
+func blackBoardZeroValue(key *BlackBoardKey) json.RawMessage {
+	switch key.KeyType {
+	case BlackBoardKey_Bool:
+		return json.RawMessage("false")
+	case BlackBoardKey_Int, BlackBoardKey_Float:
+		return json.RawMessage("0")
+	case BlackBoardKey_String:
+		return json.RawMessage(`""`)
+	case BlackBoardKey_Vector:
+		return json.RawMessage(`{"x":0,"y":0,"z":0}`)
+	case BlackBoardKey_Enum:
+		zeroValue, _ := json.Marshal(key.EnumValues[0])
+		return zeroValue
+	}
+	return json.RawMessage("null")
+}
+
+// CheckBlackBoardValue tells whether the JSON value is acceptable for the key
+func CheckBlackBoardValue(key *BlackBoardKey, value json.RawMessage) bool {
+	switch key.KeyType {
+	case BlackBoardKey_Bool:
+		var b bool
+		return json.Unmarshal(value, &b) == nil
+	case BlackBoardKey_Int:
+		var f float64
+		return json.Unmarshal(value, &f) == nil && f == math.Trunc(f)
+	case BlackBoardKey_Float:
+		var f float64
+		return json.Unmarshal(value, &f) == nil
+	case BlackBoardKey_String:
+		var s string
+		return json.Unmarshal(value, &s) == nil
+	case BlackBoardKey_Vector:
+		var v BlackBoardVector
+		return json.Unmarshal(value, &v) == nil && v.X != nil && v.Y != nil && v.Z != nil
+	case BlackBoardKey_Object:
+		return string(value) == "null"
+	case BlackBoardKey_Enum:
+		var s string
+		return json.Unmarshal(value, &s) == nil && slices.Contains(key.EnumValues, s)
+	}
+	return false
+}
+
+func BlackBoardCreateKey(newKey BlackBoardKey, doc *BlackBoardDocumentation) (common.ErrorCode, string, []BlackBoardKeyDiffInfo) {
+	errCode, errMsg := checkBlackBoardKeyName(newKey.KeyName, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	errCode, errMsg = normalizeBlackBoardKey(&newKey)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	doc.Keys = append(doc.Keys, newKey)
+	return common.Success, "", []BlackBoardKeyDiffInfo{{newKey.KeyName, nil, &newKey}}
+}
+
+func BlackBoardRenameKey(keyName string, newKeyName string, doc *BlackBoardDocumentation) (common.ErrorCode, string, []BlackBoardKeyDiffInfo) {
+	kIdx := blackBoardKeyIndex(keyName, doc)
+	if kIdx < 0 {
+		return common.BbInvalidKey, common.BbInvalidKey.GetMsgFormat(keyName), nil
+	}
+	if keyName == newKeyName {
+		return common.Success, "", []BlackBoardKeyDiffInfo{}
+	}
+	errCode, errMsg := checkBlackBoardKeyName(newKeyName, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	preModifiedKey := doc.Keys[kIdx]
+	doc.Keys[kIdx].KeyName = newKeyName
+	postModifiedKey := doc.Keys[kIdx]
+	return common.Success, "", []BlackBoardKeyDiffInfo{{keyName, &preModifiedKey, &postModifiedKey}}
+}
+
+func BlackBoardRemoveKey(keyNames []string, doc *BlackBoardDocumentation) (common.ErrorCode, string, []BlackBoardKeyDiffInfo) {
+	for _, keyName := range keyNames {
+		if blackBoardKeyIndex(keyName, doc) < 0 {
+			return common.BbInvalidKey, common.BbInvalidKey.GetMsgFormat(keyName), nil
+		}
+	}
+
+	diffInfos := make([]BlackBoardKeyDiffInfo, 0, len(keyNames))
+	reserveKeys := make([]BlackBoardKey, 0, len(doc.Keys))
+	for _, existKey := range doc.Keys {
+		if slices.Contains(keyNames, existKey.KeyName) {
+			diffInfos = append(diffInfos, BlackBoardKeyDiffInfo{existKey.KeyName, &existKey, nil})
+		} else {
+			reserveKeys = append(reserveKeys, existKey)
+		}
+	}
+	doc.Keys = reserveKeys
+	return common.Success, "", diffInfos
+}
+
+// BlackBoardUpdateKey replaces the type, default value, description and enum values of the key, the name is not changed
+func BlackBoardUpdateKey(updatedKey BlackBoardKey, doc *BlackBoardDocumentation) (common.ErrorCode, string, []BlackBoardKeyDiffInfo) {
+	kIdx := blackBoardKeyIndex(updatedKey.KeyName, doc)
+	if kIdx < 0 {
+		return common.BbInvalidKey, common.BbInvalidKey.GetMsgFormat(updatedKey.KeyName), nil
+	}
+	errCode, errMsg := normalizeBlackBoardKey(&updatedKey)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+
+	preModifiedKey := doc.Keys[kIdx]
+	doc.Keys[kIdx] = updatedKey
+	postModifiedKey := doc.Keys[kIdx]
+	return common.Success, "", []BlackBoardKeyDiffInfo{{updatedKey.KeyName, &preModifiedKey, &postModifiedKey}}
+}
+
+// BlackBoardDiffKeys compares two documents by the key names, the keys only in prevDoc are removed, the ones only in postDoc are created
+// and the changed ones are updated. it describes the replacing of the whole document like restoring a version
+func BlackBoardDiffKeys(prevDoc *BlackBoardDocumentation, postDoc *BlackBoardDocumentation) []BlackBoardKeyDiffInfo {
+	diffInfos := make([]BlackBoardKeyDiffInfo, 0)
+	for _, prevKey := range prevDoc.Keys {
+		preModifiedKey := prevKey
+		kIdx := blackBoardKeyIndex(prevKey.KeyName, postDoc)
+		if kIdx < 0 {
+			diffInfos = append(diffInfos, BlackBoardKeyDiffInfo{prevKey.KeyName, &preModifiedKey, nil})
+			continue
+		}
+		postModifiedKey := postDoc.Keys[kIdx]
+		if !reflect.DeepEqual(preModifiedKey, postModifiedKey) {
+			diffInfos = append(diffInfos, BlackBoardKeyDiffInfo{prevKey.KeyName, &preModifiedKey, &postModifiedKey})
+		}
+	}
+	for _, postKey := range postDoc.Keys {
+		if blackBoardKeyIndex(postKey.KeyName, prevDoc) < 0 {
+			postModifiedKey := postKey
+			diffInfos = append(diffInfos, BlackBoardKeyDiffInfo{postKey.KeyName, nil, &postModifiedKey})
+		}
+	}
+	return diffInfos
+}
@@ -1 +1,521 @@
 package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+	"math"
+)
+
+// The facts of the GOAP world state are named values, only the discrete types are able to be planned with
+const (
+	GoapFact_Bool = BlackBoardKey_Bool
+	GoapFact_Int  = BlackBoardKey_Int
+	GoapFact_Enum = BlackBoardKey_Enum
+)
+
+const (
+	GoapEffect_Set = "set"
+	GoapEffect_Add = "add" // only for int
+)
+
+type GoapFact struct {
+	FactName     string          `json:"name" binding:"required"`
+	FactType     string          `json:"type" binding:"required"`
+	DefaultValue json.RawMessage `json:"defaultValue" binding:"omitempty"` // the zero value of the type when absent
+	Description  string          `json:"description" binding:"omitempty"`
+	EnumValues   []string        `json:"enumValues,omitempty" binding:"omitempty"` // only for enum
+}
+
+// GoapCondition compares a fact with the value, the operators are the ones of the blackboard condition except isSet and isNotSet
+type GoapCondition struct {
+	FactName string          `json:"fact" binding:"required"`
+	Operator string          `json:"operator" binding:"required"`
+	Value    json.RawMessage `json:"value" binding:"required"`
+}
+
+type GoapEffect struct {
+	FactName  string          `json:"fact" binding:"required"`
+	Operation string          `json:"operation" binding:"required"`
+	Value     json.RawMessage `json:"value" binding:"required"`
+}
+
+type GoapAction struct {
+	ActionId      string          `json:"id" binding:"omitempty"` // assigned when created
+	ActionName    string          `json:"name" binding:"required"`
+	Cost          float64         `json:"cost" binding:"omitempty"`
+	Preconditions []GoapCondition `json:"preconditions" binding:"omitempty"`
+	Effects       []GoapEffect    `json:"effects" binding:"omitempty"`
+	Description   string          `json:"description" binding:"omitempty"`
+}
+
+type GoapGoal struct {
+	GoalId      string          `json:"id" binding:"omitempty"` // assigned when created
+	GoalName    string          `json:"name" binding:"required"`
+	Priority    int             `json:"priority" binding:"omitempty"`
+	Conditions  []GoapCondition `json:"conditions" binding:"omitempty"`
+	Description string          `json:"description" binding:"omitempty"`
+}
+
+type GoapDocumentation struct {
+	ModifyTimeStamp int64
+	WorldState      []GoapFact
+	Actions         []GoapAction
+	Goals           []GoapGoal
+}
+
+type GoapFactDiffInfo struct {
+	ModifiedFactName string    `json:"modifiedFactName" binding:"required"` // the name before modified
+	PreModifiedFact  *GoapFact `json:"preModifiedFact" binding:"required"`
+	PostModifiedFact *GoapFact `json:"postModifiedFact" binding:"required"`
+}
+
+type GoapActionDiffInfo struct {
+	ModifiedActionId   string      `json:"modifiedActionId" binding:"required"`
+	PreModifiedAction  *GoapAction `json:"preModifiedAction" binding:"required"`
+	PostModifiedAction *GoapAction `json:"postModifiedAction" binding:"required"`
+}
+
+type GoapGoalDiffInfo struct {
+	ModifiedGoalId   string    `json:"modifiedGoalId" binding:"required"`
+	PreModifiedGoal  *GoapGoal `json:"preModifiedGoal" binding:"required"`
+	PostModifiedGoal *GoapGoal `json:"postModifiedGoal" binding:"required"`
+}
+
+// GoapDiffInfos collects all kinds of diff infos produced by one modification of the document
+type GoapDiffInfos struct {
+	FactDiffInfos   []GoapFactDiffInfo   `json:"diffFactsInfos"`
+	ActionDiffInfos []GoapActionDiffInfo `json:"diffActionsInfos"`
+	GoalDiffInfos   []GoapGoalDiffInfo   `json:"diffGoalsInfos"`
+}
+
+func (diffInfos *GoapDiffInfos) IsEmpty() bool {
+	return len(diffInfos.FactDiffInfos) == 0 && len(diffInfos.ActionDiffInfos) == 0 && len(diffInfos.GoalDiffInfos) == 0
+}
+
+func GoapCreateEmptyContent() (errCode common.ErrorCode, errMsg string, content string) {
+	goapDocumentation := GoapDocumentation{
+		WorldState: []GoapFact{},
+		Actions:    []GoapAction{},
+		Goals:      []GoapGoal{},
+	}
+
+	b, err := json.Marshal(goapDocumentation)
+	if err != nil {
+		return common.SerializationError, common.SerializationError.GetMsg(), ""
+	}
+	return common.Success, "", string(b)
+}
+
+func GoapDeserialize(content string, doc *GoapDocumentation) error {
+	err := json.Unmarshal([]byte(content), doc)
+	if err != nil {
+		return err
+	}
+	if doc.WorldState == nil {
+		doc.WorldState = []GoapFact{}
+	}
+	if doc.Actions == nil {
+		doc.Actions = []GoapAction{}
+	}
+	if doc.Goals == nil {
+		doc.Goals = []GoapGoal{}
+	}
+	return nil
+}
+
+// asBlackBoardKey shares the value checking with the black board keys
+func (fact *GoapFact) asBlackBoardKey() *BlackBoardKey {
+	return &BlackBoardKey{fact.FactName, fact.FactType, fact.DefaultValue, fact.Description, fact.EnumValues}
+}
+
+// CheckValue tells whether the JSON value is acceptable for the fact
+func (fact *GoapFact) CheckValue(value json.RawMessage) bool {
+	return len(value) > 0 && CheckBlackBoardValue(fact.asBlackBoardKey(), value)
+}
+
+func goapFactIndex(factName string, doc *GoapDocumentation) int {
+	return slices.IndexFunc(doc.WorldState, func(f GoapFact) bool {
+		return f.FactName == factName
+	})
+}
+
+func goapActionIndex(actionId string, doc *GoapDocumentation) int {
+	return slices.IndexFunc(doc.Actions, func(a GoapAction) bool {
+		return a.ActionId == actionId
+	})
+}
+
+func goapGoalIndex(goalId string, doc *GoapDocumentation) int {
+	return slices.IndexFunc(doc.Goals, func(g GoapGoal) bool {
+		return g.GoalId == goalId
+	})
+}
+
+func checkGoapFactName(factName string, doc *GoapDocumentation) (common.ErrorCode, string) {
+	if factName == "" {
+		return common.GoapInvalidFactName, common.GoapInvalidFactName.GetMsgFormat(factName)
+	}
+	if goapFactIndex(factName, doc) > -1 {
+		return common.GoapDuplicatedFactName, common.GoapDuplicatedFactName.GetMsgFormat(factName)
+	}
+	return common.Success, ""
+}
+
+// normalizeGoapFact checks the type, the enum values and the default value like the black board keys
+func normalizeGoapFact(fact *GoapFact) (common.ErrorCode, string) {
+	if fact.FactType != GoapFact_Bool && fact.FactType != GoapFact_Int && fact.FactType != GoapFact_Enum {
+		return common.GoapInvalidFactType, common.GoapInvalidFactType.GetMsgFormat(fact.FactType)
+	}
+	key := fact.asBlackBoardKey()
+	errCode, _ := normalizeBlackBoardKey(key)
+	switch errCode {
+	case common.Success:
+	case common.BbInvalidEnumValues:
+		return common.GoapInvalidEnumValues, common.GoapInvalidEnumValues.GetMsgFormat(fact.FactName)
+	default:
+		return common.GoapInvalidDefaultValue, common.GoapInvalidDefaultValue.GetMsgFormat(string(fact.DefaultValue), fact.FactName, fact.FactType)
+	}
+	fact.DefaultValue = key.DefaultValue
+	fact.EnumValues = key.EnumValues
+	return common.Success, ""
+}
+
+func checkGoapCondition(condition *GoapCondition, doc *GoapDocumentation) (common.ErrorCode, string) {
+	fIdx := goapFactIndex(condition.FactName, doc)
+	if fIdx < 0 {
+		return common.GoapInvalidCondition, common.GoapInvalidCondition.GetMsgFormat(condition.FactName, "the fact is absent")
+	}
+	fact := &doc.WorldState[fIdx]
+	switch condition.Operator {
+	case BlackboardOperator_Equal, BlackboardOperator_NotEqual:
+	case BlackboardOperator_Less, BlackboardOperator_LessOrEqual, BlackboardOperator_Greater, BlackboardOperator_GreaterOrEqual:
+		if fact.FactType != GoapFact_Int {
+			return common.GoapInvalidCondition, common.GoapInvalidCondition.GetMsgFormat(condition.FactName, condition.Operator+" is only for int")
+		}
+	default:
+		return common.GoapInvalidCondition, common.GoapInvalidCondition.GetMsgFormat(condition.FactName, "unknown operator "+condition.Operator)
+	}
+	if !fact.CheckValue(condition.Value) {
+		return common.GoapInvalidCondition, common.GoapInvalidCondition.GetMsgFormat(condition.FactName, "the value "+string(condition.Value)+" is not a "+fact.FactType)
+	}
+	condition.Value = compactJSON(condition.Value)
+	return common.Success, ""
+}
+
+func checkGoapEffect(effect *GoapEffect, doc *GoapDocumentation) (common.ErrorCode, string) {
+	fIdx := goapFactIndex(effect.FactName, doc)
+	if fIdx < 0 {
+		return common.GoapInvalidEffect, common.GoapInvalidEffect.GetMsgFormat(effect.FactName, "the fact is absent")
+	}
+	fact := &doc.WorldState[fIdx]
+	switch effect.Operation {
+	case GoapEffect_Set:
+	case GoapEffect_Add:
+		if fact.FactType != GoapFact_Int {
+			return common.GoapInvalidEffect, common.GoapInvalidEffect.GetMsgFormat(effect.FactName, "add is only for int")
+		}
+	default:
+		return common.GoapInvalidEffect, common.GoapInvalidEffect.GetMsgFormat(effect.FactName, "unknown operation "+effect.Operation)
+	}
+	if !fact.CheckValue(effect.Value) {
+		return common.GoapInvalidEffect, common.GoapInvalidEffect.GetMsgFormat(effect.FactName, "the value "+string(effect.Value)+" is not a "+fact.FactType)
+	}
+	effect.Value = compactJSON(effect.Value)
+	return common.Success, ""
+}
+
+func checkGoapConditions(conditions []GoapCondition, doc *GoapDocumentation) (common.ErrorCode, string) {
+	for i := range conditions {
+		errCode, errMsg := checkGoapCondition(&conditions[i], doc)
+		if errCode != common.Success {
+			return errCode, errMsg
+		}
+	}
+	return common.Success, ""
+}
+
+// normalizeGoapAction checks the name, the cost, the preconditions and the effects of the action
+func normalizeGoapAction(action *GoapAction, doc *GoapDocumentation) (common.ErrorCode, string) {
+	if action.ActionName == "" {
+		return common.GoapInvalidActionName, common.GoapInvalidActionName.GetMsgFormat(action.ActionName)
+	}
+	if slices.ContainsFunc(doc.Actions, func(a GoapAction) bool { return a.ActionName == action.ActionName && a.ActionId != action.ActionId }) {
+		return common.GoapDuplicatedActionName, common.GoapDuplicatedActionName.GetMsgFormat(action.ActionName)
+	}
+	if action.Cost < 0 || math.IsInf(action.Cost, 0) || math.IsNaN(action.Cost) {
+		return common.GoapInvalidActionCost, common.GoapInvalidActionCost.GetMsgFormat(action.Cost, action.ActionName)
+	}
+	if action.Preconditions == nil {
+		action.Preconditions = []GoapCondition{}
+	}
+	if action.Effects == nil {
+		action.Effects = []GoapEffect{}
+	}
+	errCode, errMsg := checkGoapConditions(action.Preconditions, doc)
+	if errCode != common.Success {
+		return errCode, errMsg
+	}
+	for i := range action.Effects {
+		errCode, errMsg = checkGoapEffect(&action.Effects[i], doc)
+		if errCode != common.Success {
+			return errCode, errMsg
+		}
+	}
+	return common.Success, ""
+}
+
+func normalizeGoapGoal(goal *GoapGoal, doc *GoapDocumentation) (common.ErrorCode, string) {
+	if goal.GoalName == "" {
+		return common.GoapInvalidGoalName, common.GoapInvalidGoalName.GetMsgFormat(goal.GoalName)
+	}
+	if slices.ContainsFunc(doc.Goals, func(g GoapGoal) bool { return g.GoalName == goal.GoalName && g.GoalId != goal.GoalId }) {
+		return common.GoapDuplicatedGoalName, common.GoapDuplicatedGoalName.GetMsgFormat(goal.GoalName)
+	}
+	if goal.Conditions == nil {
+		goal.Conditions = []GoapCondition{}
+	}
+	return checkGoapConditions(goal.Conditions, doc)
+}
+
+func GoapCreateFact(newFact GoapFact, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	errCode, errMsg := checkGoapFactName(newFact.FactName, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+	errCode, errMsg = normalizeGoapFact(&newFact)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	doc.WorldState = append(doc.WorldState, newFact)
+	return common.Success, "", GoapDiffInfos{FactDiffInfos: []GoapFactDiffInfo{{newFact.FactName, nil, &newFact}}}
+}
+
+// GoapUpdateFact replaces the type, default value, description and enum values of the fact,
+// it's rejected when the conditions or the effects referring to the fact become invalid
+func GoapUpdateFact(updatedFact GoapFact, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	fIdx := goapFactIndex(updatedFact.FactName, doc)
+	if fIdx < 0 {
+		return common.GoapInvalidFact, common.GoapInvalidFact.GetMsgFormat(updatedFact.FactName), GoapDiffInfos{}
+	}
+	errCode, errMsg := normalizeGoapFact(&updatedFact)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	preModifiedFact := doc.WorldState[fIdx]
+	doc.WorldState[fIdx] = updatedFact
+	for i := range doc.Actions {
+		errCode, errMsg = normalizeGoapAction(&doc.Actions[i], doc)
+		if errCode != common.Success {
+			doc.WorldState[fIdx] = preModifiedFact
+			return errCode, errMsg, GoapDiffInfos{}
+		}
+	}
+	for i := range doc.Goals {
+		errCode, errMsg = normalizeGoapGoal(&doc.Goals[i], doc)
+		if errCode != common.Success {
+			doc.WorldState[fIdx] = preModifiedFact
+			return errCode, errMsg, GoapDiffInfos{}
+		}
+	}
+	postModifiedFact := doc.WorldState[fIdx]
+	return common.Success, "", GoapDiffInfos{FactDiffInfos: []GoapFactDiffInfo{{updatedFact.FactName, &preModifiedFact, &postModifiedFact}}}
+}
+
+// GoapRenameFact renames the fact and the references in the actions and the goals as well
+func GoapRenameFact(factName string, newFactName string, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	fIdx := goapFactIndex(factName, doc)
+	if fIdx < 0 {
+		return common.GoapInvalidFact, common.GoapInvalidFact.GetMsgFormat(factName), GoapDiffInfos{}
+	}
+	if factName == newFactName {
+		return common.Success, "", GoapDiffInfos{}
+	}
+	errCode, errMsg := checkGoapFactName(newFactName, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	diffInfos := GoapDiffInfos{
+		ActionDiffInfos: make([]GoapActionDiffInfo, 0),
+		GoalDiffInfos:   make([]GoapGoalDiffInfo, 0),
+	}
+	preModifiedFact := doc.WorldState[fIdx]
+	doc.WorldState[fIdx].FactName = newFactName
+	postModifiedFact := doc.WorldState[fIdx]
+	diffInfos.FactDiffInfos = []GoapFactDiffInfo{{factName, &preModifiedFact, &postModifiedFact}}
+
+	renameConditions := func(conditions []GoapCondition) ([]GoapCondition, bool) {
+		if !slices.ContainsFunc(conditions, func(c GoapCondition) bool { return c.FactName == factName }) {
+			return conditions, false
+		}
+		renamed := slices.Clone(conditions)
+		for i := range renamed {
+			if renamed[i].FactName == factName {
+				renamed[i].FactName = newFactName
+			}
+		}
+		return renamed, true
+	}
+	for i := range doc.Actions {
+		action := &doc.Actions[i]
+		preModifiedAction := *action
+		var preconditionsRenamed, effectsRenamed bool
+		action.Preconditions, preconditionsRenamed = renameConditions(action.Preconditions)
+		if slices.ContainsFunc(action.Effects, func(e GoapEffect) bool { return e.FactName == factName }) {
+			action.Effects = slices.Clone(action.Effects)
+			for j := range action.Effects {
+				if action.Effects[j].FactName == factName {
+					action.Effects[j].FactName = newFactName
+				}
+			}
+			effectsRenamed = true
+		}
+		if preconditionsRenamed || effectsRenamed {
+			postModifiedAction := *action
+			diffInfos.ActionDiffInfos = append(diffInfos.ActionDiffInfos, GoapActionDiffInfo{action.ActionId, &preModifiedAction, &postModifiedAction})
+		}
+	}
+	for i := range doc.Goals {
+		goal := &doc.Goals[i]
+		preModifiedGoal := *goal
+		var renamed bool
+		goal.Conditions, renamed = renameConditions(goal.Conditions)
+		if renamed {
+			postModifiedGoal := *goal
+			diffInfos.GoalDiffInfos = append(diffInfos.GoalDiffInfos, GoapGoalDiffInfo{goal.GoalId, &preModifiedGoal, &postModifiedGoal})
+		}
+	}
+	return common.Success, "", diffInfos
+}
+
+// GoapRemoveFact is rejected when the fact is still referred by an action or a goal
+func GoapRemoveFact(factNames []string, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	for _, factName := range factNames {
+		if goapFactIndex(factName, doc) < 0 {
+			return common.GoapInvalidFact, common.GoapInvalidFact.GetMsgFormat(factName), GoapDiffInfos{}
+		}
+		refersToFact := func(c GoapCondition) bool { return c.FactName == factName }
+		for _, action := range doc.Actions {
+			if slices.ContainsFunc(action.Preconditions, refersToFact) || slices.ContainsFunc(action.Effects, func(e GoapEffect) bool { return e.FactName == factName }) {
+				return common.GoapFactInUse, common.GoapFactInUse.GetMsgFormat(factName, "Action", action.ActionName), GoapDiffInfos{}
+			}
+		}
+		for _, goal := range doc.Goals {
+			if slices.ContainsFunc(goal.Conditions, refersToFact) {
+				return common.GoapFactInUse, common.GoapFactInUse.GetMsgFormat(factName, "Goal", goal.GoalName), GoapDiffInfos{}
+			}
+		}
+	}
+
+	diffInfos := make([]GoapFactDiffInfo, 0, len(factNames))
+	reserveFacts := make([]GoapFact, 0, len(doc.WorldState))
+	for _, existFact := range doc.WorldState {
+		if slices.Contains(factNames, existFact.FactName) {
+			diffInfos = append(diffInfos, GoapFactDiffInfo{existFact.FactName, &existFact, nil})
+		} else {
+			reserveFacts = append(reserveFacts, existFact)
+		}
+	}
+	doc.WorldState = reserveFacts
+	return common.Success, "", GoapDiffInfos{FactDiffInfos: diffInfos}
+}
+
+func GoapCreateAction(newAction GoapAction, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	newAction.ActionId = uuid.New().String()
+	errCode, errMsg := normalizeGoapAction(&newAction, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	doc.Actions = append(doc.Actions, newAction)
+	return common.Success, "", GoapDiffInfos{ActionDiffInfos: []GoapActionDiffInfo{{newAction.ActionId, nil, &newAction}}}
+}
+
+// GoapUpdateAction replaces the whole action identified by the id
+func GoapUpdateAction(updatedAction GoapAction, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	aIdx := goapActionIndex(updatedAction.ActionId, doc)
+	if aIdx < 0 {
+		return common.GoapInvalidActionId, common.GoapInvalidActionId.GetMsgFormat(updatedAction.ActionId), GoapDiffInfos{}
+	}
+	errCode, errMsg := normalizeGoapAction(&updatedAction, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	preModifiedAction := doc.Actions[aIdx]
+	doc.Actions[aIdx] = updatedAction
+	postModifiedAction := doc.Actions[aIdx]
+	return common.Success, "", GoapDiffInfos{ActionDiffInfos: []GoapActionDiffInfo{{updatedAction.ActionId, &preModifiedAction, &postModifiedAction}}}
+}
+
+func GoapRemoveAction(actionIds []string, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	for _, actionId := range actionIds {
+		if goapActionIndex(actionId, doc) < 0 {
+			return common.GoapInvalidActionId, common.GoapInvalidActionId.GetMsgFormat(actionId), GoapDiffInfos{}
+		}
+	}
+
+	diffInfos := make([]GoapActionDiffInfo, 0, len(actionIds))
+	reserveActions := make([]GoapAction, 0, len(doc.Actions))
+	for _, existAction := range doc.Actions {
+		if slices.Contains(actionIds, existAction.ActionId) {
+			diffInfos = append(diffInfos, GoapActionDiffInfo{existAction.ActionId, &existAction, nil})
+		} else {
+			reserveActions = append(reserveActions, existAction)
+		}
+	}
+	doc.Actions = reserveActions
+	return common.Success, "", GoapDiffInfos{ActionDiffInfos: diffInfos}
+}
+
+func GoapCreateGoal(newGoal GoapGoal, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	newGoal.GoalId = uuid.New().String()
+	errCode, errMsg := normalizeGoapGoal(&newGoal, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	doc.Goals = append(doc.Goals, newGoal)
+	return common.Success, "", GoapDiffInfos{GoalDiffInfos: []GoapGoalDiffInfo{{newGoal.GoalId, nil, &newGoal}}}
+}
+
+// GoapUpdateGoal replaces the whole goal identified by the id
+func GoapUpdateGoal(updatedGoal GoapGoal, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	gIdx := goapGoalIndex(updatedGoal.GoalId, doc)
+	if gIdx < 0 {
+		return common.GoapInvalidGoalId, common.GoapInvalidGoalId.GetMsgFormat(updatedGoal.GoalId), GoapDiffInfos{}
+	}
+	errCode, errMsg := normalizeGoapGoal(&updatedGoal, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, GoapDiffInfos{}
+	}
+
+	preModifiedGoal := doc.Goals[gIdx]
+	doc.Goals[gIdx] = updatedGoal
+	postModifiedGoal := doc.Goals[gIdx]
+	return common.Success, "", GoapDiffInfos{GoalDiffInfos: []GoapGoalDiffInfo{{updatedGoal.GoalId, &preModifiedGoal, &postModifiedGoal}}}
+}
+
+func GoapRemoveGoal(goalIds []string, doc *GoapDocumentation) (common.ErrorCode, string, GoapDiffInfos) {
+	for _, goalId := range goalIds {
+		if goapGoalIndex(goalId, doc) < 0 {
+			return common.GoapInvalidGoalId, common.GoapInvalidGoalId.GetMsgFormat(goalId), GoapDiffInfos{}
+		}
+	}
+
+	diffInfos := make([]GoapGoalDiffInfo, 0, len(goalIds))
+	reserveGoals := make([]GoapGoal, 0, len(doc.Goals))
+	for _, existGoal := range doc.Goals {
+		if slices.Contains(goalIds, existGoal.GoalId) {
+			diffInfos = append(diffInfos, GoapGoalDiffInfo{existGoal.GoalId, &existGoal, nil})
+		} else {
+			reserveGoals = append(reserveGoals, existGoal)
+		}
+	}
+	doc.Goals = reserveGoals
+	return common.Success, "", GoapDiffInfos{GoalDiffInfos: diffInfos}
+}
@@ -0,0 +1,344 @@
+package content_modifier
+
+import (
+	"container/heap"
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	GoapPlanDefaultMaxExpansions = 10000
+	GoapPlanLimitMaxExpansions   = 1000000
+)
+
+type GoapPlanStep struct {
+	ActionId   string  `json:"actionId" binding:"required"`
+	ActionName string  `json:"actionName" binding:"required"`
+	Cost       float64 `json:"cost" binding:"required"`
+}
+
+// GoapUnsatisfiableCondition is a condition which is never satisfied in any state the search has reached,
+// ActionId is empty when it's a condition of the goal
+type GoapUnsatisfiableCondition struct {
+	ActionId   string        `json:"actionId,omitempty"`
+	ActionName string        `json:"actionName,omitempty"`
+	Condition  GoapCondition `json:"condition" binding:"required"`
+}
+
+type GoapPlanResult struct {
+	Found        bool           `json:"found" binding:"required"`
+	Steps        []GoapPlanStep `json:"steps" binding:"required"`
+	TotalCost    float64        `json:"totalCost" binding:"required"`
+	Expansions   int            `json:"expansions" binding:"required"`
+	LimitReached bool           `json:"limitReached" binding:"required"` // the search is stopped by the expansion limit before the space is exhausted
+
+	UnsatisfiableConditions []GoapUnsatisfiableCondition `json:"unsatisfiableConditions,omitempty"`
+}
+
+// goapPlanState keeps the facts in the order of the world state, bool is 0 / 1 and enum is the index of the value
+type goapPlanState []int64
+
+func (state goapPlanState) key() string {
+	var builder strings.Builder
+	for _, value := range state {
+		builder.WriteString(strconv.FormatInt(value, 10))
+		builder.WriteByte(',')
+	}
+	return builder.String()
+}
+
+type goapPlanCondition struct {
+	factIdx  int
+	operator string
+	value    int64
+}
+
+func (condition *goapPlanCondition) satisfied(state goapPlanState) bool {
+	factValue := state[condition.factIdx]
+	switch condition.operator {
+	case BlackboardOperator_Equal:
+		return factValue == condition.value
+	case BlackboardOperator_NotEqual:
+		return factValue != condition.value
+	case BlackboardOperator_Less:
+		return factValue < condition.value
+	case BlackboardOperator_LessOrEqual:
+		return factValue <= condition.value
+	case BlackboardOperator_Greater:
+		return factValue > condition.value
+	default:
+		return factValue >= condition.value
+	}
+}
+
+type goapPlanEffect struct {
+	factIdx   int
+	operation string
+	value     int64
+}
+
+type goapPlanAction struct {
+	action        *GoapAction
+	preconditions []goapPlanCondition
+	effects       []goapPlanEffect
+}
+
+// goapFactValue converts the JSON value of the fact into the planning value, the value must have been checked
+func goapFactValue(fact *GoapFact, value json.RawMessage) int64 {
+	switch fact.FactType {
+	case GoapFact_Bool:
+		var b bool
+		_ = json.Unmarshal(value, &b)
+		if b {
+			return 1
+		}
+		return 0
+	case GoapFact_Int:
+		var f float64
+		_ = json.Unmarshal(value, &f)
+		return int64(f)
+	default:
+		var s string
+		_ = json.Unmarshal(value, &s)
+		return int64(slices.Index(fact.EnumValues, s))
+	}
+}
+
+func compileGoapConditions(conditions []GoapCondition, doc *GoapDocumentation) []goapPlanCondition {
+	compiled := make([]goapPlanCondition, 0, len(conditions))
+	for _, condition := range conditions {
+		fIdx := goapFactIndex(condition.FactName, doc)
+		compiled = append(compiled, goapPlanCondition{fIdx, condition.Operator, goapFactValue(&doc.WorldState[fIdx], condition.Value)})
+	}
+	return compiled
+}
+
+type goapPlanNode struct {
+	state      goapPlanState
+	cost       float64
+	estimation float64
+	sequence   int // keeps the search deterministic for the nodes with the same estimation
+	parent     *goapPlanNode
+	action     *goapPlanAction
+	heapIdx    int
+}
+
+type goapPlanOpenList []*goapPlanNode
+
+func (openList goapPlanOpenList) Len() int { return len(openList) }
+func (openList goapPlanOpenList) Less(i, j int) bool {
+	if openList[i].estimation != openList[j].estimation {
+		return openList[i].estimation < openList[j].estimation
+	}
+	return openList[i].sequence < openList[j].sequence
+}
+func (openList goapPlanOpenList) Swap(i, j int) {
+	openList[i], openList[j] = openList[j], openList[i]
+	openList[i].heapIdx = i
+	openList[j].heapIdx = j
+}
+func (openList *goapPlanOpenList) Push(x any) {
+	node := x.(*goapPlanNode)
+	node.heapIdx = len(*openList)
+	*openList = append(*openList, node)
+}
+func (openList *goapPlanOpenList) Pop() any {
+	old := *openList
+	node := old[len(old)-1]
+	*openList = old[:len(old)-1]
+	node.heapIdx = -1
+	return node
+}
+
+// GoapPlan searches the cheapest sequence of actions from the initial state to the state satisfying all goal conditions with A*.
+// the facts absent in initialState take their default values. the heuristic is the cheapest action cost while the goal is
+// unsatisfied, which never overestimates, so the first plan found is the cheapest one
+func GoapPlan(doc *GoapDocumentation, initialState map[string]json.RawMessage, goalConditions []GoapCondition, maxExpansions int) (common.ErrorCode, string, *GoapPlanResult) {
+	// Check And Compile The Inputs
+	if maxExpansions == 0 {
+		maxExpansions = GoapPlanDefaultMaxExpansions
+	}
+	if maxExpansions < 0 || maxExpansions > GoapPlanLimitMaxExpansions {
+		return common.GoapPlanInvalidMaxExpansions, common.GoapPlanInvalidMaxExpansions.GetMsgFormat(maxExpansions, GoapPlanLimitMaxExpansions), nil
+	}
+
+	startState := make(goapPlanState, len(doc.WorldState))
+	for i := range doc.WorldState {
+		startState[i] = goapFactValue(&doc.WorldState[i], doc.WorldState[i].DefaultValue)
+	}
+	for factName, value := range initialState {
+		fIdx := goapFactIndex(factName, doc)
+		if fIdx < 0 {
+			return common.GoapPlanInvalidInitialState, common.GoapPlanInvalidInitialState.GetMsgFormat(factName, "the fact is absent"), nil
+		}
+		if !doc.WorldState[fIdx].CheckValue(value) {
+			return common.GoapPlanInvalidInitialState, common.GoapPlanInvalidInitialState.GetMsgFormat(factName, "the value "+string(value)+" is not a "+doc.WorldState[fIdx].FactType), nil
+		}
+		startState[fIdx] = goapFactValue(&doc.WorldState[fIdx], value)
+	}
+
+	goalConditions = slices.Clone(goalConditions)
+	errCode, errMsg := checkGoapConditions(goalConditions, doc)
+	if errCode != common.Success {
+		return errCode, errMsg, nil
+	}
+	goal := compileGoapConditions(goalConditions, doc)
+
+	actions := make([]goapPlanAction, 0, len(doc.Actions))
+	minActionCost := -1.0
+	for i := range doc.Actions {
+		action := &doc.Actions[i]
+		planAction := goapPlanAction{action: action, preconditions: compileGoapConditions(action.Preconditions, doc)}
+		for _, effect := range action.Effects {
+			fIdx := goapFactIndex(effect.FactName, doc)
+			planAction.effects = append(planAction.effects, goapPlanEffect{fIdx, effect.Operation, goapFactValue(&doc.WorldState[fIdx], effect.Value)})
+		}
+		actions = append(actions, planAction)
+		if minActionCost < 0 || action.Cost < minActionCost {
+			minActionCost = action.Cost
+		}
+	}
+	if minActionCost < 0 {
+		minActionCost = 0
+	}
+
+	allSatisfied := func(conditions []goapPlanCondition, state goapPlanState) bool {
+		for i := range conditions {
+			if !conditions[i].satisfied(state) {
+				return false
+			}
+		}
+		return true
+	}
+	estimate := func(state goapPlanState) float64 {
+		if allSatisfied(goal, state) {
+			return 0
+		}
+		return minActionCost
+	}
+
+	// The Conditions Ever Satisfied Are Recorded For Reporting The Unsatisfiable Ones
+	goalSatisfiedEver := make([]bool, len(goal))
+	preconditionsSatisfiedEver := make([][]bool, len(actions))
+	for i := range actions {
+		preconditionsSatisfiedEver[i] = make([]bool, len(actions[i].preconditions))
+	}
+	recordSatisfied := func(state goapPlanState) {
+		for i := range goal {
+			goalSatisfiedEver[i] = goalSatisfiedEver[i] || goal[i].satisfied(state)
+		}
+		for i := range actions {
+			for j := range actions[i].preconditions {
+				preconditionsSatisfiedEver[i][j] = preconditionsSatisfiedEver[i][j] || actions[i].preconditions[j].satisfied(state)
+			}
+		}
+	}
+
+	// A* Search
+	result := &GoapPlanResult{Steps: make([]GoapPlanStep, 0)}
+	sequence := 0
+	openList := &goapPlanOpenList{}
+	openNodes := map[string]*goapPlanNode{}
+	closedStates := map[string]bool{}
+	startNode := &goapPlanNode{state: startState, estimation: estimate(startState)}
+	heap.Push(openList, startNode)
+	openNodes[startState.key()] = startNode
+
+	for openList.Len() > 0 {
+		node := heap.Pop(openList).(*goapPlanNode)
+		stateKey := node.state.key()
+		delete(openNodes, stateKey)
+		recordSatisfied(node.state)
+
+		if allSatisfied(goal, node.state) {
+			result.Found = true
+			result.TotalCost = node.cost
+			for n := node; n.action != nil; n = n.parent {
+				result.Steps = append(result.Steps, GoapPlanStep{n.action.action.ActionId, n.action.action.ActionName, n.action.action.Cost})
+			}
+			slices.Reverse(result.Steps)
+			return common.Success, "", result
+		}
+
+		if result.Expansions >= maxExpansions {
+			result.LimitReached = true
+			break
+		}
+		result.Expansions++
+		closedStates[stateKey] = true
+
+		for i := range actions {
+			action := &actions[i]
+			if !allSatisfied(action.preconditions, node.state) {
+				continue
+			}
+			nextState := slices.Clone(node.state)
+			for _, effect := range action.effects {
+				if effect.operation == GoapEffect_Add {
+					nextState[effect.factIdx] += effect.value
+				} else {
+					nextState[effect.factIdx] = effect.value
+				}
+			}
+			nextKey := nextState.key()
+			if closedStates[nextKey] {
+				continue
+			}
+			nextCost := node.cost + action.action.Cost
+			if openNode, exist := openNodes[nextKey]; exist {
+				if nextCost < openNode.cost {
+					openNode.cost, openNode.parent, openNode.action = nextCost, node, action
+					openNode.estimation = nextCost + estimate(nextState)
+					heap.Fix(openList, openNode.heapIdx)
+				}
+				continue
+			}
+			sequence++
+			nextNode := &goapPlanNode{state: nextState, cost: nextCost, estimation: nextCost + estimate(nextState), sequence: sequence, parent: node, action: action}
+			heap.Push(openList, nextNode)
+			openNodes[nextKey] = nextNode
+		}
+	}
+
+	// No Plan, Report The Goal Conditions Never Satisfied In The Reached States, And The Preconditions Never Satisfied
+	// Of The Actions Which Are Able To Change Those Facts, Transitively
+	relevantFacts := make([]bool, len(doc.WorldState))
+	for i := range goal {
+		if !goalSatisfiedEver[i] {
+			relevantFacts[goal[i].factIdx] = true
+			result.UnsatisfiableConditions = append(result.UnsatisfiableConditions, GoapUnsatisfiableCondition{Condition: goalConditions[i]})
+		}
+	}
+	relevantActions := make([]bool, len(actions))
+	for changed := true; changed; {
+		changed = false
+		for i := range actions {
+			if relevantActions[i] || !slices.ContainsFunc(actions[i].effects, func(e goapPlanEffect) bool { return relevantFacts[e.factIdx] }) {
+				continue
+			}
+			relevantActions[i], changed = true, true
+			for j := range actions[i].preconditions {
+				if !preconditionsSatisfiedEver[i][j] {
+					relevantFacts[actions[i].preconditions[j].factIdx] = true
+				}
+			}
+		}
+	}
+	for i := range actions {
+		if !relevantActions[i] {
+			continue
+		}
+		for j := range actions[i].preconditions {
+			if !preconditionsSatisfiedEver[i][j] {
+				result.UnsatisfiableConditions = append(result.UnsatisfiableConditions, GoapUnsatisfiableCondition{actions[i].action.ActionId, actions[i].action.ActionName, actions[i].action.Preconditions[j]})
+			}
+		}
+	}
+	if result.LimitReached {
+		return common.GoapPlanExpansionLimitReached, common.GoapPlanExpansionLimitReached.GetMsgFormat(maxExpansions), result
+	}
+	return common.GoapPlanNotFound, common.GoapPlanNotFound.GetMsgFormat(len(result.UnsatisfiableConditions)), result
+}
@@ -0,0 +1,144 @@
+package content_modifier
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"testing"
+)
+
+func goapTestCondition(factName string, operator string, value string) GoapCondition {
+	return GoapCondition{FactName: factName, Operator: operator, Value: json.RawMessage(value)}
+}
+
+func goapTestAction(actionName string, cost float64, preconditions []GoapCondition, effects ...GoapEffect) GoapAction {
+	return GoapAction{ActionId: actionName, ActionName: actionName, Cost: cost, Preconditions: preconditions, Effects: effects}
+}
+
+func goapTestEffect(factName string, operation string, value string) GoapEffect {
+	return GoapEffect{FactName: factName, Operation: operation, Value: json.RawMessage(value)}
+}
+
+func goapPlanActionNames(result *GoapPlanResult) []string {
+	names := make([]string, 0, len(result.Steps))
+	for _, step := range result.Steps {
+		names = append(names, step.ActionName)
+	}
+	return names
+}
+
+func TestGoapPlanPrefersTheCheapestPlan(t *testing.T) {
+	doc := &GoapDocumentation{
+		WorldState: []GoapFact{
+			{FactName: "atForest", FactType: GoapFact_Bool},
+			{FactName: "hasWood", FactType: GoapFact_Bool},
+		},
+		Actions: []GoapAction{
+			goapTestAction("BuyWood", 10, nil, goapTestEffect("hasWood", GoapEffect_Set, "true")),
+			goapTestAction("WalkToForest", 1, nil, goapTestEffect("atForest", GoapEffect_Set, "true")),
+			goapTestAction("ChopTree", 2, []GoapCondition{goapTestCondition("atForest", BlackboardOperator_Equal, "true")}, goapTestEffect("hasWood", GoapEffect_Set, "true")),
+		},
+	}
+
+	errCode, errMsg, result := GoapPlan(doc, nil, []GoapCondition{goapTestCondition("hasWood", BlackboardOperator_Equal, "true")}, 0)
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	names := goapPlanActionNames(result)
+	if !result.Found || result.TotalCost != 3 || len(names) != 2 || names[0] != "WalkToForest" || names[1] != "ChopTree" {
+		t.Fatalf("the cheapest plan isn't found: %v with the cost %v", names, result.TotalCost)
+	}
+
+	//The Shorter Plan Wins When It Becomes The Cheaper One
+	doc.Actions[0].Cost = 2
+	errCode, errMsg, result = GoapPlan(doc, nil, []GoapCondition{goapTestCondition("hasWood", BlackboardOperator_Equal, "true")}, 0)
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	if names = goapPlanActionNames(result); result.TotalCost != 2 || len(names) != 1 || names[0] != "BuyWood" {
+		t.Fatalf("the cheapest plan isn't found: %v with the cost %v", names, result.TotalCost)
+	}
+}
+
+func TestGoapPlanReportsUnsatisfiableConditions(t *testing.T) {
+	doc := &GoapDocumentation{
+		WorldState: []GoapFact{
+			{FactName: "hasKey", FactType: GoapFact_Bool},
+			{FactName: "doorOpen", FactType: GoapFact_Bool},
+			{FactName: "hasAxe", FactType: GoapFact_Bool},
+			{FactName: "treeCut", FactType: GoapFact_Bool},
+		},
+		Actions: []GoapAction{
+			goapTestAction("OpenDoor", 1, []GoapCondition{goapTestCondition("hasKey", BlackboardOperator_Equal, "true")}, goapTestEffect("doorOpen", GoapEffect_Set, "true")),
+			goapTestAction("CutTree", 1, []GoapCondition{goapTestCondition("hasAxe", BlackboardOperator_Equal, "true")}, goapTestEffect("treeCut", GoapEffect_Set, "true")),
+		},
+	}
+
+	errCode, _, result := GoapPlan(doc, nil, []GoapCondition{goapTestCondition("doorOpen", BlackboardOperator_Equal, "true")}, 0)
+	if errCode != common.GoapPlanNotFound || result == nil || result.Found || result.LimitReached {
+		t.Fatalf("the unreachable goal isn't reported as not found: %d %+v", errCode, result)
+	}
+	//The Precondition Of The Unrelated Action Isn't Reported
+	unsatisfiable := result.UnsatisfiableConditions
+	if len(unsatisfiable) != 2 ||
+		unsatisfiable[0].ActionId != "" || unsatisfiable[0].Condition.FactName != "doorOpen" ||
+		unsatisfiable[1].ActionId != "OpenDoor" || unsatisfiable[1].Condition.FactName != "hasKey" {
+		t.Fatalf("the unsatisfiable conditions aren't reported: %+v", unsatisfiable)
+	}
+}
+
+func TestGoapPlanExpansionLimit(t *testing.T) {
+	doc := &GoapDocumentation{
+		WorldState: []GoapFact{{FactName: "count", FactType: GoapFact_Int}},
+		Actions:    []GoapAction{goapTestAction("Increase", 1, nil, goapTestEffect("count", GoapEffect_Add, "1"))},
+	}
+
+	//The Count Never Goes Down, But The Search Space Is Unbounded
+	errCode, _, result := GoapPlan(doc, nil, []GoapCondition{goapTestCondition("count", BlackboardOperator_Less, "0")}, 50)
+	if errCode != common.GoapPlanExpansionLimitReached || result == nil || result.Found || !result.LimitReached || result.Expansions != 50 {
+		t.Fatalf("the search isn't stopped by the expansion limit: %d %+v", errCode, result)
+	}
+
+	errCode, _, _ = GoapPlan(doc, nil, []GoapCondition{goapTestCondition("count", BlackboardOperator_Less, "0")}, GoapPlanLimitMaxExpansions+1)
+	if errCode != common.GoapPlanInvalidMaxExpansions {
+		t.Fatalf("the expansion limit over %d is accepted: %d", GoapPlanLimitMaxExpansions, errCode)
+	}
+}
+
+func TestGoapPlanNumericOperatorBoundaries(t *testing.T) {
+	doc := &GoapDocumentation{
+		WorldState: []GoapFact{{FactName: "count", FactType: GoapFact_Int, DefaultValue: json.RawMessage("5")}},
+		Actions:    []GoapAction{goapTestAction("Increase", 1, nil, goapTestEffect("count", GoapEffect_Add, "1"))},
+	}
+
+	testCases := []struct {
+		operator string
+		value    string
+		steps    int // -1 when there is no plan
+	}{
+		{BlackboardOperator_Equal, "5", 0},
+		{BlackboardOperator_Equal, "7", 2},
+		{BlackboardOperator_Equal, "4", -1},
+		{BlackboardOperator_NotEqual, "5", 1},
+		{BlackboardOperator_NotEqual, "6", 0},
+		{BlackboardOperator_Less, "6", 0},
+		{BlackboardOperator_Less, "5", -1},
+		{BlackboardOperator_LessOrEqual, "5", 0},
+		{BlackboardOperator_LessOrEqual, "4", -1},
+		{BlackboardOperator_Greater, "4", 0},
+		{BlackboardOperator_Greater, "5", 1},
+		{BlackboardOperator_GreaterOrEqual, "5", 0},
+		{BlackboardOperator_GreaterOrEqual, "6", 1},
+	}
+	for _, testCase := range testCases {
+		errCode, _, result := GoapPlan(doc, nil, []GoapCondition{goapTestCondition("count", testCase.operator, testCase.value)}, 100)
+		if testCase.steps < 0 {
+			if errCode == common.Success || result.Found {
+				t.Errorf("count %s %s is planned with %v", testCase.operator, testCase.value, goapPlanActionNames(result))
+			}
+			continue
+		}
+		if errCode != common.Success || len(result.Steps) != testCase.steps {
+			t.Errorf("count %s %s is planned with %d step(s), expected %d", testCase.operator, testCase.value, len(result.Steps), testCase.steps)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package content_modifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"io"
+	"strings"
+)
+
+// The settings schemas are registered per solution by category and type name:
+// "node" by the node type, "task" by the taskType in the settings of bt_task,
+// "descriptor" by the descriptor type and "service" by the service type
+const (
+	SettingsSchemaCategory_Node       = "node"
+	SettingsSchemaCategory_Task       = "task"
+	SettingsSchemaCategory_Descriptor = "descriptor"
+	SettingsSchemaCategory_Service    = "service"
+
+	// TaskTypeKey is the key in the settings of bt_task which tells what the task is
+	TaskTypeKey = "taskType"
+
+	settingsSchemaURL = "mem:///settings.schema.json"
+)
+
+// The keys written by the archive itself, the settings are merged into the same object so they must not use them
+var (
+	ReservedNodeSettingsKeys       = []string{"id", "parentId", "type", "order"}
+	ReservedDescriptorSettingsKeys = []string{"id", "type", "order", "attachTo"}
+	ReservedServiceSettingsKeys    = []string{"id", "type", "order", "attachTo", "tickInterval", "randomDeviation"}
+)
+
+func IsValidSettingsSchemaCategory(category string) bool {
+	return category == SettingsSchemaCategory_Node || category == SettingsSchemaCategory_Task ||
+		category == SettingsSchemaCategory_Descriptor || category == SettingsSchemaCategory_Service
+}
+
+// SettingsFieldError is one violation of the settings, the field is the JSON pointer of the violating value
+type SettingsFieldError struct {
+	Field   string `json:"field" binding:"required"`
+	Message string `json:"message" binding:"required"`
+}
+
+// checkSettingsObject checks the settings is absent or a JSON object without any reserved key
+func checkSettingsObject(settings json.RawMessage, reservedKeys []string) (common.ErrorCode, string) {
+	if len(settings) == 0 || string(settings) == "null" {
+		return common.Success, ""
+	}
+	settingsMap := make(map[string]json.RawMessage)
+	err := json.Unmarshal(settings, &settingsMap)
+	if err != nil {
+		return common.BtSettingsNotObject, common.BtSettingsNotObject.GetMsgFormat(err.Error())
+	}
+	for _, reservedKey := range reservedKeys {
+		if _, exist := settingsMap[reservedKey]; exist {
+			return common.BtSettingsReservedKey, common.BtSettingsReservedKey.GetMsgFormat(reservedKey)
+		}
+	}
+	return common.Success, ""
+}
+
+// GetTaskType returns the taskType in the settings of bt_task, empty string is returned when it's absent
+func GetTaskType(settings json.RawMessage) string {
+	var taskSettings struct {
+		TaskType string `json:"taskType"`
+	}
+	if len(settings) > 0 {
+		_ = json.Unmarshal(settings, &taskSettings)
+	}
+	return taskSettings.TaskType
+}
+
+// CompileSettingsSchema compiles the registered schema, referring to any external document is refused
+func CompileSettingsSchema(schema string) (common.ErrorCode, string, *jsonschema.Schema) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, errors.New("loading external schema is not supported: " + url)
+	}
+	err := compiler.AddResource(settingsSchemaURL, strings.NewReader(schema))
+	if err == nil {
+		var compiledSchema *jsonschema.Schema
+		compiledSchema, err = compiler.Compile(settingsSchemaURL)
+		if err == nil {
+			return common.Success, "", compiledSchema
+		}
+	}
+	return common.InvalidSettingsSchema, common.InvalidSettingsSchema.GetMsgFormat(err.Error()), nil
+}
+
+// ValidateSettings returns all violations of the settings, the absent settings are validated as an empty object
+func ValidateSettings(compiledSchema *jsonschema.Schema, settings json.RawMessage) []SettingsFieldError {
+	fieldErrors := make([]SettingsFieldError, 0)
+	if len(settings) == 0 || string(settings) == "null" {
+		settings = json.RawMessage("{}")
+	}
+	var instance interface{}
+	decoder := json.NewDecoder(bytes.NewReader(settings))
+	decoder.UseNumber()
+	err := decoder.Decode(&instance)
+	if err != nil {
+		return append(fieldErrors, SettingsFieldError{"", err.Error()})
+	}
+
+	err = compiledSchema.Validate(instance)
+	var validationError *jsonschema.ValidationError
+	if !errors.As(err, &validationError) {
+		if err != nil {
+			fieldErrors = append(fieldErrors, SettingsFieldError{"", err.Error()})
+		}
+		return fieldErrors
+	}
+
+	// Only The Leaves Are Reported, The Others Are Just Summaries Of Their Causes
+	var collectLeaves func(ve *jsonschema.ValidationError)
+	collectLeaves = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			fieldErrors = append(fieldErrors, SettingsFieldError{ve.InstanceLocation, ve.Message})
+			return
+		}
+		for _, cause := range ve.Causes {
+			collectLeaves(cause)
+		}
+	}
+	collectLeaves(validationError)
+	return fieldErrors
+}
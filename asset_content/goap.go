@@ -0,0 +1,472 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type BaseGoapModificationReq struct {
+	AssetId        string `json:"assetId" binding:"required"`
+	CurrentVersion string `json:"currentVersion" binding:"required"`
+	Author         string `json:"author" binding:"max=64"` // optional, it's kept in the version of the asset
+}
+
+func (req *BaseGoapModificationReq) GetAssetID() string {
+	return req.AssetId
+}
+
+func (req *BaseGoapModificationReq) GetCurrentVersion() string {
+	return req.CurrentVersion
+}
+
+func (req *BaseGoapModificationReq) GetAuthor() string {
+	return req.Author
+}
+
+type CreateGoapFactReq struct {
+	BaseGoapModificationReq
+	Fact content_modifier.GoapFact `json:"fact" binding:"required"`
+}
+
+type UpdateGoapFactReq struct {
+	BaseGoapModificationReq
+	Fact content_modifier.GoapFact `json:"fact" binding:"required"`
+}
+
+type RenameGoapFactReq struct {
+	BaseGoapModificationReq
+	FactName    string `json:"factName" binding:"required"`
+	NewFactName string `json:"newFactName" binding:"required"`
+}
+
+type RemoveGoapFactReq struct {
+	BaseGoapModificationReq
+	FactNames []string `json:"factNames" binding:"required"`
+}
+
+type CreateGoapActionReq struct {
+	BaseGoapModificationReq
+	Action content_modifier.GoapAction `json:"action" binding:"required"`
+}
+
+type UpdateGoapActionReq struct {
+	BaseGoapModificationReq
+	Action content_modifier.GoapAction `json:"action" binding:"required"`
+}
+
+type RemoveGoapActionReq struct {
+	BaseGoapModificationReq
+	ActionIds []string `json:"actionIds" binding:"required"`
+}
+
+type CreateGoapGoalReq struct {
+	BaseGoapModificationReq
+	Goal content_modifier.GoapGoal `json:"goal" binding:"required"`
+}
+
+type UpdateGoapGoalReq struct {
+	BaseGoapModificationReq
+	Goal content_modifier.GoapGoal `json:"goal" binding:"required"`
+}
+
+type RemoveGoapGoalReq struct {
+	BaseGoapModificationReq
+	GoalIds []string `json:"goalIds" binding:"required"`
+}
+
+type GoapModification struct {
+	DiffFactsInfos   []content_modifier.GoapFactDiffInfo   `json:"diffFactsInfos" binding:"required"`
+	DiffActionsInfos []content_modifier.GoapActionDiffInfo `json:"diffActionsInfos" binding:"required"`
+	DiffGoalsInfos   []content_modifier.GoapGoalDiffInfo   `json:"diffGoalsInfos" binding:"required"`
+	PrevVersion      string                                `json:"prevVersion" binding:"required"`
+	NewVersion       string                                `json:"newVersion" binding:"required"`
+}
+
+type ArchivedGoap struct {
+	AssetName    string `json:"assetName" binding:"required"`
+	AssetId      string `json:"assetId" binding:"required"`
+	AssetVersion string `json:"assetVersion" binding:"required"`
+
+	GoapWorldState string `json:"goapWorldState" binding:"required"`
+	GoapActions    string `json:"goapActions" binding:"required"`
+	GoapGoals      string `json:"goapGoals" binding:"required"`
+}
+
+func CreateGoapFactAPI(context *gin.Context) {
+	var req CreateGoapFactReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *CreateGoapFactReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapCreateFact(req.Fact, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func UpdateGoapFactAPI(context *gin.Context) {
+	var req UpdateGoapFactReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *UpdateGoapFactReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapUpdateFact(req.Fact, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RenameGoapFactAPI(context *gin.Context) {
+	var req RenameGoapFactReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *RenameGoapFactReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapRenameFact(req.FactName, req.NewFactName, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RemoveGoapFactAPI(context *gin.Context) {
+	var req RemoveGoapFactReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *RemoveGoapFactReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapRemoveFact(req.FactNames, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func CreateGoapActionAPI(context *gin.Context) {
+	var req CreateGoapActionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *CreateGoapActionReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapCreateAction(req.Action, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func UpdateGoapActionAPI(context *gin.Context) {
+	var req UpdateGoapActionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *UpdateGoapActionReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapUpdateAction(req.Action, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RemoveGoapActionAPI(context *gin.Context) {
+	var req RemoveGoapActionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *RemoveGoapActionReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapRemoveAction(req.ActionIds, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func CreateGoapGoalAPI(context *gin.Context) {
+	var req CreateGoapGoalReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *CreateGoapGoalReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapCreateGoal(req.Goal, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func UpdateGoapGoalAPI(context *gin.Context) {
+	var req UpdateGoapGoalReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *UpdateGoapGoalReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapUpdateGoal(req.Goal, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+func RemoveGoapGoalAPI(context *gin.Context) {
+	var req RemoveGoapGoalReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, modificationInfo := passGoapDocumentModification(&req, func(req *RemoveGoapGoalReq, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos) {
+		return content_modifier.GoapRemoveGoal(req.GoalIds, goapDoc)
+	})
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":          errCode,
+		"errMessage":       errMsg,
+		"modificationInfo": modificationInfo,
+	})
+}
+
+// passGoapDocumentModification is the optimistic versioning like the black board, the stale request is always rejected
+func passGoapDocumentModification[T AssetModifier](req T, goapModify func(req T, goapDoc *content_modifier.GoapDocumentation) (common.ErrorCode, string, content_modifier.GoapDiffInfos)) (common.ErrorCode, string, *GoapModification) {
+	var errCode = common.Success
+	var errMsg = ""
+	var modificationInfo *GoapModification = nil
+
+	err := db.Storage.Transaction(func(repo db.Repository) error {
+		var assetDetail *common.AssetDetailInfo
+		//Querying Pass
+		{
+			var err error
+			assetDetail, err = repo.GetAssetForUpdate(req.GetAssetID())
+			if err != nil {
+				errCode = common.DataBaseError
+				errMsg = err.Error()
+				return err
+			}
+			if assetDetail.AssetType != "GOAP" {
+				eMsg := common.MismatchedAssetType.GetMsgFormat(assetDetail.AssetId, assetDetail.AssetType, "GOAP")
+				errCode, errMsg = common.MismatchedAssetType, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Version Checking Pass
+		{
+			if assetDetail.AssetVersion != req.GetCurrentVersion() {
+				eMsg := common.InvalidAssetVersion.GetMsgFormat(assetDetail.AssetVersion, req.GetCurrentVersion())
+				errCode, errMsg = common.InvalidAssetVersion, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Deserialization Pass
+		var goapDoc content_modifier.GoapDocumentation
+		{
+			err := content_modifier.GoapDeserialize(assetDetail.AssetContent, &goapDoc)
+			if err != nil {
+				eMsg := common.DeserializationError.GetMsg()
+				errCode, errMsg = common.DeserializationError, eMsg
+				return errors.New(eMsg)
+			}
+		}
+
+		//Real Modified Logic Pass
+		var diffInfos content_modifier.GoapDiffInfos
+		{
+			errCode, errMsg, diffInfos = goapModify(req, &goapDoc)
+			if errCode != common.Success {
+				return errors.New(errMsg)
+			}
+		}
+
+		//Write Modification
+		newVersion := assetDetail.AssetVersion
+		if !diffInfos.IsEmpty() { // just need real write data when there are some diffInfos
+			//Serialization
+			modifiedContent, err := json.Marshal(goapDoc)
+			if err != nil {
+				errCode = common.SerializationError
+				eMsg := errCode.GetMsg()
+				errMsg = eMsg
+				return errors.New(eMsg)
+			}
+
+			//DB Update
+			newVersion = uuid.New().String()
+			assetDetail.AssetVersion = newVersion
+			assetDetail.AssetContent = string(modifiedContent)
+
+			err = repo.SaveAsset(assetDetail)
+			if err != nil {
+				errCode = common.DataBaseError
+				eMsg := errCode.GetMsg()
+				errMsg = eMsg
+				return errors.New(eMsg)
+			}
+
+			//Version Recording
+			err = RecordAssetVersion(repo, assetDetail, req.GetCurrentVersion(), "", req.GetAuthor())
+			if err != nil {
+				errCode = common.DataBaseError
+				errMsg = err.Error()
+				return err
+			}
+		}
+
+		//All Pass
+		//Calculate Modification Info
+		modificationInfo = &GoapModification{
+			DiffFactsInfos:   diffInfos.FactDiffInfos,
+			DiffActionsInfos: diffInfos.ActionDiffInfos,
+			DiffGoalsInfos:   diffInfos.GoalDiffInfos,
+			PrevVersion:      req.GetCurrentVersion(),
+			NewVersion:       newVersion,
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+		return errCode, errMsg, nil
+	}
+	return common.Success, "", modificationInfo
+}
+
+func GetArchivedGoapAsset(assetDetail *common.AssetDetailInfo) (common.ErrorCode, string, *ArchivedGoap) {
+
+	if assetDetail.AssetType != "GOAP" {
+		return common.ArchiveAssetsUnexpectAssetType, common.ArchiveAssetsUnexpectAssetType.GetMsgFormat(assetDetail.AssetType, "GOAP"), nil
+	}
+
+	var goapDoc content_modifier.GoapDocumentation
+	//Deserialize
+	err := content_modifier.GoapDeserialize(assetDetail.AssetContent, &goapDoc)
+	if err != nil {
+		return common.DeserializationError, err.Error(), nil
+	}
+
+	var archivedDoc ArchivedGoap
+	archivedDoc.AssetName = assetDetail.AssetName
+	archivedDoc.AssetId = assetDetail.AssetId
+	archivedDoc.AssetVersion = assetDetail.AssetVersion
+
+	{
+		serializationWorldState, err := json.Marshal(&goapDoc.WorldState)
+		if err != nil {
+			return common.SerializationError, err.Error(), nil
+		}
+		archivedDoc.GoapWorldState = string(serializationWorldState)
+	}
+
+	{
+		serializationActions, err := json.Marshal(&goapDoc.Actions)
+		if err != nil {
+			return common.SerializationError, err.Error(), nil
+		}
+		archivedDoc.GoapActions = string(serializationActions)
+	}
+
+	{
+		serializationGoals, err := json.Marshal(&goapDoc.Goals)
+		if err != nil {
+			return common.SerializationError, err.Error(), nil
+		}
+		archivedDoc.GoapGoals = string(serializationGoals)
+	}
+
+	return common.Success, "", &archivedDoc
+}
@@ -0,0 +1,101 @@
+package asset_content
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"testing"
+)
+
+type goapModificationResponse struct {
+	ErrCode          common.ErrorCode  `json:"errCode"`
+	ErrMessage       string            `json:"errMessage"`
+	ModificationInfo *GoapModification `json:"modificationInfo"`
+}
+
+func modifyGoap(t *testing.T, router *gin.Engine, name string, req gin.H, errCode common.ErrorCode) *GoapModification {
+	t.Helper()
+	var resp goapModificationResponse
+	callAPI(t, router, name, req, &resp)
+	if resp.ErrCode != errCode {
+		t.Fatalf("%s responds the errCode %d (%s), expected %d", name, resp.ErrCode, resp.ErrMessage, errCode)
+	}
+	return resp.ModificationInfo
+}
+
+func readGoap(t *testing.T, assetId string) content_modifier.GoapDocumentation {
+	t.Helper()
+	assetDetail, err := db.Storage.GetAsset(assetId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var goapDoc content_modifier.GoapDocumentation
+	if err = content_modifier.GoapDeserialize(assetDetail.AssetContent, &goapDoc); err != nil {
+		t.Fatal(err)
+	}
+	return goapDoc
+}
+
+func TestGoapFactsActionsGoals(t *testing.T) {
+	router, assetSetId := newTestRouter(t)
+	errCode, errMsg, content := content_modifier.GoapCreateEmptyContent()
+	if errCode != common.Success {
+		t.Fatal(errMsg)
+	}
+	assetId, version := createTestAsset(t, assetSetId, "GOAP", "Planner", content)
+	modificationReq := func(fields gin.H) gin.H {
+		fields["assetId"], fields["currentVersion"] = assetId, assetVersionOf(t, assetId)
+		return fields
+	}
+	hasWood := gin.H{"fact": "hasWood", "operator": content_modifier.BlackboardOperator_Equal, "value": true}
+
+	modificationInfo := modifyGoap(t, router, "CreateGoapFact", modificationReq(gin.H{"fact": gin.H{"name": "hasWood", "type": content_modifier.GoapFact_Bool}}), common.Success)
+	if modificationInfo.PrevVersion != version || len(modificationInfo.DiffFactsInfos) != 1 {
+		t.Fatalf("the fact isn't created as a new version: %+v", modificationInfo)
+	}
+	modifyGoap(t, router, "CreateGoapFact", modificationReq(gin.H{"fact": gin.H{"name": "hasWood", "type": content_modifier.GoapFact_Int}}), common.GoapDuplicatedFactName)
+
+	modificationInfo = modifyGoap(t, router, "CreateGoapAction", modificationReq(gin.H{"action": gin.H{"name": "ChopTree", "cost": 2,
+		"effects": []gin.H{{"fact": "hasWood", "operation": content_modifier.GoapEffect_Set, "value": true}}}}), common.Success)
+	actionId := modificationInfo.DiffActionsInfos[0].ModifiedActionId
+	modifyGoap(t, router, "CreateGoapAction", modificationReq(gin.H{"action": gin.H{"name": "Fly",
+		"preconditions": []gin.H{{"fact": "hasWings", "operator": content_modifier.BlackboardOperator_Equal, "value": true}}}}), common.GoapInvalidCondition)
+	modifyGoap(t, router, "UpdateGoapAction", modificationReq(gin.H{"action": gin.H{"id": actionId, "name": "ChopTree", "cost": 3,
+		"effects": []gin.H{{"fact": "hasWood", "operation": content_modifier.GoapEffect_Set, "value": true}}}}), common.Success)
+
+	modificationInfo = modifyGoap(t, router, "CreateGoapGoal", modificationReq(gin.H{"goal": gin.H{"name": "GetWood", "conditions": []gin.H{hasWood}}}), common.Success)
+	goalId := modificationInfo.DiffGoalsInfos[0].ModifiedGoalId
+
+	//The Fact Referred By The Action And The Goal Is Not Able To Be Removed
+	modifyGoap(t, router, "RemoveGoapFact", modificationReq(gin.H{"factNames": []string{"hasWood"}}), common.GoapFactInUse)
+	modifyGoap(t, router, "RenameGoapFact", modificationReq(gin.H{"factName": "hasWood", "newFactName": "woodCollected"}), common.Success)
+	goapDoc := readGoap(t, assetId)
+	if len(goapDoc.Actions) != 1 || goapDoc.Actions[0].Cost != 3 || goapDoc.Actions[0].Effects[0].FactName != "woodCollected" || goapDoc.Goals[0].Conditions[0].FactName != "woodCollected" {
+		t.Fatalf("the action isn't updated or the references aren't renamed: %+v", goapDoc)
+	}
+
+	//The Plan Is Previewed From The Current Version
+	var planResp struct {
+		ErrCode    common.ErrorCode                 `json:"errCode"`
+		ErrMessage string                           `json:"errMessage"`
+		Plan       *content_modifier.GoapPlanResult `json:"plan"`
+	}
+	callAPI(t, router, "PlanGOAP", gin.H{"assetId": assetId, "goalId": goalId}, &planResp)
+	if planResp.ErrCode != common.Success || len(planResp.Plan.Steps) != 1 || planResp.Plan.Steps[0].ActionId != actionId {
+		t.Fatalf("the plan isn't found: %d (%s) %+v", planResp.ErrCode, planResp.ErrMessage, planResp.Plan)
+	}
+	callAPI(t, router, "PlanGOAP", gin.H{"assetId": uuid.New().String(), "goalId": goalId}, &planResp)
+	if planResp.ErrCode != common.InvalidAsset {
+		t.Fatalf("planning with an unknown asset responds the errCode %d (%s)", planResp.ErrCode, planResp.ErrMessage)
+	}
+
+	modifyGoap(t, router, "RemoveGoapGoal", modificationReq(gin.H{"goalIds": []string{goalId}}), common.Success)
+	modifyGoap(t, router, "RemoveGoapAction", modificationReq(gin.H{"actionIds": []string{actionId}}), common.Success)
+	modifyGoap(t, router, "RemoveGoapAction", modificationReq(gin.H{"actionIds": []string{actionId}}), common.GoapInvalidActionId)
+	modifyGoap(t, router, "RemoveGoapFact", modificationReq(gin.H{"factNames": []string{"woodCollected"}}), common.Success)
+	if goapDoc = readGoap(t, assetId); len(goapDoc.WorldState) != 0 || len(goapDoc.Actions) != 0 || len(goapDoc.Goals) != 0 {
+		t.Fatalf("the document isn't emptied: %+v", goapDoc)
+	}
+}
@@ -0,0 +1,91 @@
+package asset_content
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"golang.org/x/exp/slices"
+	"net/http"
+)
+
+type PlanGoapReq struct {
+	AssetId        string                           `json:"assetId" binding:"required"`
+	InitialState   map[string]json.RawMessage       `json:"initialState" binding:"omitempty"`   // the facts absent take their default values
+	GoalId         string                           `json:"goalId" binding:"omitempty"`         // one of the goals in the asset
+	GoalConditions []content_modifier.GoapCondition `json:"goalConditions" binding:"omitempty"` // or an ad hoc goal
+	MaxExpansions  int                              `json:"maxExpansions" binding:"omitempty"`
+}
+
+// PlanGoapAPI previews the cheapest plan of the current version of the asset, nothing is modified
+func PlanGoapAPI(context *gin.Context) {
+	var req PlanGoapReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	errCode, errMsg, goapDoc := loadGoapDocument(req.AssetId)
+	if errCode != common.Success {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    errCode,
+			"errMessage": errMsg,
+		})
+		return
+	}
+
+	goalConditions := req.GoalConditions
+	if req.GoalId != "" {
+		gIdx := slices.IndexFunc(goapDoc.Goals, func(g content_modifier.GoapGoal) bool {
+			return g.GoalId == req.GoalId
+		})
+		if gIdx < 0 {
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.GoapPlanInvalidGoal,
+				"errMessage": common.GoapPlanInvalidGoal.GetMsgFormat(req.GoalId),
+			})
+			return
+		}
+		goalConditions = goapDoc.Goals[gIdx].Conditions
+	} else if len(goalConditions) == 0 {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.GoapPlanInvalidGoal,
+			"errMessage": common.GoapPlanInvalidGoal.GetMsgFormat(req.GoalId),
+		})
+		return
+	}
+
+	errCode, errMsg, plan := content_modifier.GoapPlan(goapDoc, req.InitialState, goalConditions, req.MaxExpansions)
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":    errCode,
+		"errMessage": errMsg,
+		"plan":       plan,
+	})
+}
+
+func loadGoapDocument(assetId string) (common.ErrorCode, string, *content_modifier.GoapDocumentation) {
+	assetDetail, err := db.Storage.GetAsset(assetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return common.InvalidAsset, common.InvalidAsset.GetMsgFormat(assetId), nil
+	}
+	if err != nil {
+		return common.DataBaseError, err.Error(), nil
+	}
+	if assetDetail.AssetType != "GOAP" {
+		return common.MismatchedAssetType, common.MismatchedAssetType.GetMsgFormat(assetDetail.AssetId, assetDetail.AssetType, "GOAP"), nil
+	}
+
+	//Deserialization
+	var goapDoc content_modifier.GoapDocumentation
+	err = content_modifier.GoapDeserialize(assetDetail.AssetContent, &goapDoc)
+	if err != nil {
+		return common.DeserializationError, common.DeserializationError.GetMsg(), nil
+	}
+	return common.Success, "", &goapDoc
+}
@@ -11,4 +11,48 @@ func InitializeAssetManagement(router *gin.RouterGroup) {
 
 	router.POST("GetDetailInfoAboutBehaviourTreeNode", GetDetailInfoAboutBehaviourTreeNodeAPI)
 	router.POST("UpdateBehaviourTreeNodeSettings", UpdateBehaviourTreeNodeSettingsAPI)
+	router.POST("ValidateBehaviourTree", ValidateBehaviourTreeAPI)
+	router.POST("SimulateBehaviourTree", SimulateBehaviourTreeAPI)
+	router.POST("ApplyBehaviourTreeOperations", ApplyBehaviourTreeOperationsAPI)
+	router.POST("DuplicateBehaviourTreeSubtree", DuplicateBehaviourTreeSubtreeAPI)
+	router.POST("AutoLayoutBehaviourTree", AutoLayoutBehaviourTreeAPI)
+	router.POST("BindBehaviourTreeBlackBoard", BindBehaviourTreeBlackBoardAPI)
+
+	router.POST("CreateBehaviourTreeDescriptor", CreateBehaviourTreeDescriptorAPI)
+	router.POST("RemoveBehaviourTreeDescriptor", RemoveBehaviourTreeDescriptorAPI)
+	router.POST("ReorderBehaviourTreeDescriptors", ReorderBehaviourTreeDescriptorsAPI)
+	router.POST("UpdateBehaviourTreeDescriptorSettings", UpdateBehaviourTreeDescriptorSettingsAPI)
+	router.POST("MoveBehaviourTreeDescriptor", MoveBehaviourTreeDescriptorAPI)
+
+	router.POST("CreateBehaviourTreeService", CreateBehaviourTreeServiceAPI)
+	router.POST("RemoveBehaviourTreeService", RemoveBehaviourTreeServiceAPI)
+	router.POST("ReorderBehaviourTreeServices", ReorderBehaviourTreeServicesAPI)
+	router.POST("UpdateBehaviourTreeServiceSettings", UpdateBehaviourTreeServiceSettingsAPI)
+
+	router.POST("UndoBehaviourTreeModification", UndoBehaviourTreeModificationAPI)
+	router.POST("RedoBehaviourTreeModification", RedoBehaviourTreeModificationAPI)
+	router.POST("GetBehaviourTreeUndoRedoState", GetBehaviourTreeUndoRedoStateAPI)
+
+	router.GET("SubscribeBehaviourTreeModifications", SubscribeBehaviourTreeModificationsAPI)
+
+	router.POST("ListAssetVersions", ListAssetVersionsAPI)
+	router.POST("ReadAssetVersion", ReadAssetVersionAPI)
+	router.POST("RestoreAssetVersion", RestoreAssetVersionAPI)
+
+	router.POST("CreateBlackBoardKey", CreateBlackBoardKeyAPI)
+	router.POST("RenameBlackBoardKey", RenameBlackBoardKeyAPI)
+	router.POST("RemoveBlackBoardKey", RemoveBlackBoardKeyAPI)
+	router.POST("UpdateBlackBoardKey", UpdateBlackBoardKeyAPI)
+
+	router.POST("CreateGoapFact", CreateGoapFactAPI)
+	router.POST("UpdateGoapFact", UpdateGoapFactAPI)
+	router.POST("RenameGoapFact", RenameGoapFactAPI)
+	router.POST("RemoveGoapFact", RemoveGoapFactAPI)
+	router.POST("CreateGoapAction", CreateGoapActionAPI)
+	router.POST("UpdateGoapAction", UpdateGoapActionAPI)
+	router.POST("RemoveGoapAction", RemoveGoapActionAPI)
+	router.POST("CreateGoapGoal", CreateGoapGoalAPI)
+	router.POST("UpdateGoapGoal", UpdateGoapGoalAPI)
+	router.POST("RemoveGoapGoal", RemoveGoapGoalAPI)
+	router.POST("PlanGOAP", PlanGoapAPI)
 }
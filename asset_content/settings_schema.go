@@ -0,0 +1,146 @@
+package asset_content
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+)
+
+func querySolutionIdOfAsset(repo db.Repository, assetId string) (string, error) {
+	assetDetail, err := repo.GetAsset(assetId)
+	if err != nil {
+		return "", err
+	}
+	assetSet, err := repo.GetAssetSet(assetDetail.AssetSetId)
+	if err != nil {
+		return "", err
+	}
+	return assetSet.SolutionId, nil
+}
+
+// settingsSchemaValidator validates the settings with the schemas registered in a solution, the schemas are compiled on demand
+type settingsSchemaValidator struct {
+	schemaItems     map[string]*common.SettingsSchemaItem
+	compiledSchemas map[string]*jsonschema.Schema
+}
+
+func newSettingsSchemaValidator(repo db.Repository, solutionId string) (*settingsSchemaValidator, error) {
+	schemaItems, err := repo.ListSettingsSchemas(solutionId)
+	if err != nil {
+		return nil, err
+	}
+	validator := &settingsSchemaValidator{
+		schemaItems:     make(map[string]*common.SettingsSchemaItem, len(schemaItems)),
+		compiledSchemas: make(map[string]*jsonschema.Schema),
+	}
+	for i := range schemaItems {
+		validator.schemaItems[schemaItems[i].Category+"/"+schemaItems[i].TypeName] = &schemaItems[i]
+	}
+	return validator, nil
+}
+
+// validate returns nil when there is no schema registered for the type
+func (validator *settingsSchemaValidator) validate(category string, typeName string, settings json.RawMessage) []content_modifier.SettingsFieldError {
+	schemaKey := category + "/" + typeName
+	compiledSchema, exist := validator.compiledSchemas[schemaKey]
+	if !exist {
+		schemaItem, registered := validator.schemaItems[schemaKey]
+		if !registered {
+			return nil
+		}
+		errCode, errMsg, schema := content_modifier.CompileSettingsSchema(string(schemaItem.Schema))
+		if errCode != common.Success {
+			return []content_modifier.SettingsFieldError{{Field: "", Message: errMsg}}
+		}
+		validator.compiledSchemas[schemaKey] = schema
+		compiledSchema = schema
+	}
+	fieldErrors := content_modifier.ValidateSettings(compiledSchema, settings)
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return fieldErrors
+}
+
+func newSettingsSchemaIssue(nodeId string, attachmentId string, category string, typeName string, fieldErrors []content_modifier.SettingsFieldError) content_modifier.BehaviourTreeValidationIssue {
+	return content_modifier.BehaviourTreeValidationIssue{
+		NodeId:       nodeId,
+		AttachmentId: attachmentId,
+		ErrCode:      common.BtSettingsSchemaViolation,
+		ErrMessage:   common.BtSettingsSchemaViolation.GetMsgFormat(category, typeName, fieldErrors[0].Field, fieldErrors[0].Message),
+		FieldErrors:  fieldErrors,
+	}
+}
+
+func settingsChanged(preType string, preSettings json.RawMessage, postType string, postSettings json.RawMessage) bool {
+	return preType != postType || !bytes.Equal(preSettings, postSettings)
+}
+
+// checkBehaviourTreeSettingsSchemas validates the settings of the elements which are created or whose settings are updated by the modification
+func checkBehaviourTreeSettingsSchemas(repo db.Repository, assetId string, diffInfos content_modifier.BehaviourTreeDiffInfos) ([]content_modifier.BehaviourTreeValidationIssue, error) {
+	issues := make([]content_modifier.BehaviourTreeValidationIssue, 0)
+
+	//Filter The Elements Whose Settings Are Changed
+	nodes := make([]*content_modifier.LogicBtNode, 0)
+	for _, info := range diffInfos.NodeDiffInfos {
+		post := info.PostModifiedNode
+		if post != nil && (info.PreModifiedNode == nil || settingsChanged(info.PreModifiedNode.NodeType, info.PreModifiedNode.Settings, post.NodeType, post.Settings)) {
+			nodes = append(nodes, post)
+		}
+	}
+	descriptors := make([]*content_modifier.LogicBtDescriptor, 0)
+	for _, info := range diffInfos.DescriptorDiffInfos {
+		post := info.PostModifiedDescriptor
+		if post != nil && (info.PreModifiedDescriptor == nil || settingsChanged(info.PreModifiedDescriptor.DescriptorType, info.PreModifiedDescriptor.Settings, post.DescriptorType, post.Settings)) {
+			descriptors = append(descriptors, post)
+		}
+	}
+	services := make([]*content_modifier.LogicBtService, 0)
+	for _, info := range diffInfos.ServiceDiffInfos {
+		post := info.PostModifiedService
+		if post != nil && (info.PreModifiedService == nil || settingsChanged(info.PreModifiedService.ServiceType, info.PreModifiedService.Settings, post.ServiceType, post.Settings)) {
+			services = append(services, post)
+		}
+	}
+	if len(nodes) == 0 && len(descriptors) == 0 && len(services) == 0 {
+		return issues, nil
+	}
+
+	solutionId, err := querySolutionIdOfAsset(repo, assetId)
+	if err != nil {
+		return nil, err
+	}
+	validator, err := newSettingsSchemaValidator(repo, solutionId)
+	if err != nil {
+		return nil, err
+	}
+
+	//Validation
+	for _, node := range nodes {
+		if fieldErrors := validator.validate(content_modifier.SettingsSchemaCategory_Node, node.NodeType, node.Settings); fieldErrors != nil {
+			issues = append(issues, newSettingsSchemaIssue(node.NodeId, "", content_modifier.SettingsSchemaCategory_Node, node.NodeType, fieldErrors))
+		}
+		if node.NodeType != content_modifier.Node_Task {
+			continue
+		}
+		if taskType := content_modifier.GetTaskType(node.Settings); taskType != "" {
+			if fieldErrors := validator.validate(content_modifier.SettingsSchemaCategory_Task, taskType, node.Settings); fieldErrors != nil {
+				issues = append(issues, newSettingsSchemaIssue(node.NodeId, "", content_modifier.SettingsSchemaCategory_Task, taskType, fieldErrors))
+			}
+		}
+	}
+	for _, descriptor := range descriptors {
+		if fieldErrors := validator.validate(content_modifier.SettingsSchemaCategory_Descriptor, descriptor.DescriptorType, descriptor.Settings); fieldErrors != nil {
+			issues = append(issues, newSettingsSchemaIssue(descriptor.AttachTo, descriptor.DescriptorId, content_modifier.SettingsSchemaCategory_Descriptor, descriptor.DescriptorType, fieldErrors))
+		}
+	}
+	for _, service := range services {
+		if fieldErrors := validator.validate(content_modifier.SettingsSchemaCategory_Service, service.ServiceType, service.Settings); fieldErrors != nil {
+			issues = append(issues, newSettingsSchemaIssue(service.AttachTo, service.ServiceId, content_modifier.SettingsSchemaCategory_Service, service.ServiceType, fieldErrors))
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,83 @@
+package simulator
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"reflect"
+)
+
+// Descriptor_Blackboard is the descriptor type of the blackboard condition decorator,
+// the node it's attached to fails without running when the condition is not satisfied
+const Descriptor_Blackboard = content_modifier.Descriptor_Blackboard
+
+const (
+	Operator_IsSet          = content_modifier.BlackboardOperator_IsSet
+	Operator_IsNotSet       = content_modifier.BlackboardOperator_IsNotSet
+	Operator_Equal          = content_modifier.BlackboardOperator_Equal
+	Operator_NotEqual       = content_modifier.BlackboardOperator_NotEqual
+	Operator_Less           = content_modifier.BlackboardOperator_Less
+	Operator_LessOrEqual    = content_modifier.BlackboardOperator_LessOrEqual
+	Operator_Greater        = content_modifier.BlackboardOperator_Greater
+	Operator_GreaterOrEqual = content_modifier.BlackboardOperator_GreaterOrEqual
+)
+
+type blackboardCondition struct {
+	Key      string      `json:"key"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+func parseBlackboardCondition(descriptor content_modifier.LogicBtDescriptor) (common.ErrorCode, string, blackboardCondition) {
+	var condition blackboardCondition
+	if len(descriptor.Settings) > 0 {
+		err := json.Unmarshal(descriptor.Settings, &condition)
+		if err != nil {
+			return common.BtSimulationInvalidCondition, common.BtSimulationInvalidCondition.GetMsgFormat(descriptor.DescriptorId, err.Error()), condition
+		}
+	}
+	if condition.Key == "" {
+		return common.BtSimulationInvalidCondition, common.BtSimulationInvalidCondition.GetMsgFormat(descriptor.DescriptorId, "key is absent"), condition
+	}
+	switch condition.Operator {
+	case Operator_IsSet, Operator_IsNotSet, Operator_Equal, Operator_NotEqual:
+	case Operator_Less, Operator_LessOrEqual, Operator_Greater, Operator_GreaterOrEqual:
+		if _, isNumber := condition.Value.(float64); !isNumber {
+			return common.BtSimulationInvalidCondition, common.BtSimulationInvalidCondition.GetMsgFormat(descriptor.DescriptorId, "the value must be a number for "+condition.Operator), condition
+		}
+	default:
+		return common.BtSimulationInvalidCondition, common.BtSimulationInvalidCondition.GetMsgFormat(descriptor.DescriptorId, "unknown operator "+condition.Operator), condition
+	}
+	return common.Success, "", condition
+}
+
+// evaluate compares the blackboard value with the condition value, the values are the ones decoded from JSON
+func (condition *blackboardCondition) evaluate(blackboard map[string]interface{}) bool {
+	value, exist := blackboard[condition.Key]
+	switch condition.Operator {
+	case Operator_IsSet:
+		return exist && value != nil
+	case Operator_IsNotSet:
+		return !exist || value == nil
+	case Operator_Equal:
+		return exist && reflect.DeepEqual(value, condition.Value)
+	case Operator_NotEqual:
+		return !exist || !reflect.DeepEqual(value, condition.Value)
+	}
+
+	number, isNumber := value.(float64)
+	if !isNumber {
+		return false
+	}
+	expected := condition.Value.(float64)
+	switch condition.Operator {
+	case Operator_Less:
+		return number < expected
+	case Operator_LessOrEqual:
+		return number <= expected
+	case Operator_Greater:
+		return number > expected
+	default:
+		return number >= expected
+	}
+}
@@ -0,0 +1,272 @@
+package simulator
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	Status_Success = "success"
+	Status_Failure = "failure"
+	Status_Running = "running"
+	Status_Aborted = "aborted" // the running node is stopped by its simple parallel parent
+
+	DefaultTicks = 10
+	MaxTicks     = 1000
+)
+
+// Script drives the simulation. the results of a task are consumed one per tick in which the task is ticked,
+// the last one is repeated when they are used up, and the task without any result always succeeds.
+// the run subtree nodes are simulated as tasks because the referred assets are not loaded
+type Script struct {
+	Ticks             int                    `json:"ticks" binding:"omitempty"` // use DefaultTicks when it's absent
+	StopOnCompletion  bool                   `json:"stopOnCompletion" binding:"omitempty"`
+	TaskResults       map[string][]string    `json:"taskResults" binding:"omitempty"` // node id -> results
+	InitialBlackboard map[string]interface{} `json:"initialBlackboard" binding:"omitempty"`
+}
+
+type NodeVisit struct {
+	NodeId   string `json:"nodeId" binding:"required"`
+	NodeType string `json:"nodeType" binding:"required"`
+	Status   string `json:"status" binding:"required"`
+}
+
+// TickTrace records the nodes visited in a tick in the order they are finished
+type TickTrace struct {
+	Tick   int         `json:"tick" binding:"required"`
+	Status string      `json:"status" binding:"required"` // the status of the root
+	Visits []NodeVisit `json:"visits" binding:"required"`
+}
+
+type simulation struct {
+	script     Script
+	nodeById   map[string]*content_modifier.LogicBtNode
+	children   map[string][]*content_modifier.LogicBtNode
+	conditions map[string][]blackboardCondition
+	blackboard map[string]interface{}
+
+	taskTickCount   map[string]int
+	runningNodeIds  map[string]bool
+	runningChildIdx map[string]int            // the running child of selector and sequence
+	parallelStates  map[string]*parallelState // the state of running simple parallel
+	visits          []NodeVisit
+}
+
+type parallelState struct {
+	mainStatus string // empty when the main task is still running
+}
+
+func checkStatus(status string) bool {
+	return status == Status_Success || status == Status_Failure || status == Status_Running
+}
+
+// Simulate ticks the document with the script, the document must pass the structure check
+func Simulate(script Script, doc *content_modifier.BehaviourTreeDocumentation) (common.ErrorCode, string, []TickTrace) {
+	if script.Ticks == 0 {
+		script.Ticks = DefaultTicks
+	}
+	if script.Ticks < 0 || script.Ticks > MaxTicks {
+		return common.BtSimulationInvalidTicks, common.BtSimulationInvalidTicks.GetMsgFormat(script.Ticks, MaxTicks), nil
+	}
+	for nodeId, results := range script.TaskResults {
+		for _, result := range results {
+			if !checkStatus(result) {
+				return common.BtSimulationInvalidTaskResult, common.BtSimulationInvalidTaskResult.GetMsgFormat(result, nodeId), nil
+			}
+		}
+	}
+
+	sim := &simulation{
+		script:          script,
+		nodeById:        make(map[string]*content_modifier.LogicBtNode, len(doc.Nodes)),
+		children:        make(map[string][]*content_modifier.LogicBtNode, len(doc.Nodes)),
+		conditions:      make(map[string][]blackboardCondition),
+		blackboard:      make(map[string]interface{}, len(script.InitialBlackboard)),
+		taskTickCount:   make(map[string]int),
+		runningNodeIds:  make(map[string]bool),
+		runningChildIdx: make(map[string]int),
+		parallelStates:  make(map[string]*parallelState),
+	}
+	for key, value := range script.InitialBlackboard {
+		sim.blackboard[key] = value
+	}
+
+	var root *content_modifier.LogicBtNode
+	for i := range doc.Nodes {
+		node := &doc.Nodes[i]
+		sim.nodeById[node.NodeId] = node
+		if node.ParentId != "" {
+			sim.children[node.ParentId] = append(sim.children[node.ParentId], node)
+		}
+		if node.NodeType == content_modifier.Node_Root {
+			root = node
+		}
+	}
+	if root == nil {
+		return common.BtValidateInvalidRootCount, common.BtValidateInvalidRootCount.GetMsgFormat(0), nil
+	}
+	for _, children := range sim.children {
+		slices.SortStableFunc(children, func(a, b *content_modifier.LogicBtNode) int {
+			return a.Order - b.Order
+		})
+	}
+
+	// The Descriptors Are Ordered By Their Order, Only The Blackboard Conditions Take Effect
+	descriptors := slices.Clone(doc.Descriptors)
+	slices.SortStableFunc(descriptors, func(a, b content_modifier.LogicBtDescriptor) int {
+		return a.Order - b.Order
+	})
+	for _, descriptor := range descriptors {
+		if descriptor.DescriptorType != Descriptor_Blackboard {
+			continue
+		}
+		errCode, errMsg, condition := parseBlackboardCondition(descriptor)
+		if errCode != common.Success {
+			return errCode, errMsg, nil
+		}
+		sim.conditions[descriptor.AttachTo] = append(sim.conditions[descriptor.AttachTo], condition)
+	}
+
+	traces := make([]TickTrace, 0, script.Ticks)
+	for tick := 0; tick < script.Ticks; tick++ {
+		sim.visits = make([]NodeVisit, 0)
+		status := sim.tick(root)
+		traces = append(traces, TickTrace{tick, status, sim.visits})
+		if script.StopOnCompletion && status != Status_Running {
+			break
+		}
+	}
+	return common.Success, "", traces
+}
+
+func (sim *simulation) visit(node *content_modifier.LogicBtNode, status string) string {
+	if status == Status_Running {
+		sim.runningNodeIds[node.NodeId] = true
+	} else {
+		delete(sim.runningNodeIds, node.NodeId)
+	}
+	sim.visits = append(sim.visits, NodeVisit{node.NodeId, node.NodeType, status})
+	return status
+}
+
+func (sim *simulation) tick(node *content_modifier.LogicBtNode) string {
+	// The Conditions Are Only Checked When The Node Is Entered, The Running One Is Resumed Directly
+	if !sim.runningNodeIds[node.NodeId] {
+		for _, condition := range sim.conditions[node.NodeId] {
+			if !condition.evaluate(sim.blackboard) {
+				return sim.visit(node, Status_Failure)
+			}
+		}
+	}
+
+	switch node.NodeType {
+	case content_modifier.Node_Root:
+		children := sim.children[node.NodeId]
+		if len(children) == 0 {
+			return sim.visit(node, Status_Failure)
+		}
+		return sim.visit(node, sim.tick(children[0]))
+	case content_modifier.Node_Selector, content_modifier.Node_Sequence:
+		return sim.visit(node, sim.tickComposite(node))
+	case content_modifier.Node_SimpleParallel:
+		return sim.visit(node, sim.tickSimpleParallel(node))
+	default: // Task And Run Subtree
+		return sim.visit(node, sim.tickTask(node))
+	}
+}
+
+func (sim *simulation) tickTask(node *content_modifier.LogicBtNode) string {
+	results := sim.script.TaskResults[node.NodeId]
+	if len(results) == 0 {
+		return Status_Success
+	}
+	tickCount := sim.taskTickCount[node.NodeId]
+	sim.taskTickCount[node.NodeId]++
+	return results[min(tickCount, len(results)-1)]
+}
+
+// tickComposite runs the children in order, the selector stops at the first success and the sequence stops at the first failure
+func (sim *simulation) tickComposite(node *content_modifier.LogicBtNode) string {
+	stopStatus, passStatus := Status_Failure, Status_Success
+	if node.NodeType == content_modifier.Node_Selector {
+		stopStatus, passStatus = Status_Success, Status_Failure
+	}
+
+	children := sim.children[node.NodeId]
+	startIdx := 0
+	if runningIdx, running := sim.runningChildIdx[node.NodeId]; running {
+		startIdx = runningIdx
+		delete(sim.runningChildIdx, node.NodeId)
+	}
+	for childIdx := startIdx; childIdx < len(children); childIdx++ {
+		status := sim.tick(children[childIdx])
+		if status == Status_Running {
+			sim.runningChildIdx[node.NodeId] = childIdx
+			return Status_Running
+		}
+		if status == stopStatus {
+			return stopStatus
+		}
+	}
+	if len(children) == 0 {
+		return Status_Failure
+	}
+	return passStatus
+}
+
+// tickSimpleParallel runs the main task and the background child in the same tick, the background child is restarted
+// when it's finished before the main task. the result is always the result of the main task
+func (sim *simulation) tickSimpleParallel(node *content_modifier.LogicBtNode) string {
+	children := sim.children[node.NodeId]
+	if len(children) == 0 {
+		return Status_Failure
+	}
+	state, running := sim.parallelStates[node.NodeId]
+	if !running {
+		state = &parallelState{}
+		sim.parallelStates[node.NodeId] = state
+	}
+
+	if state.mainStatus == "" {
+		if mainStatus := sim.tick(children[0]); mainStatus != Status_Running {
+			state.mainStatus = mainStatus
+		}
+	}
+
+	var background *content_modifier.LogicBtNode
+	if len(children) > 1 {
+		background = children[1]
+	}
+	delayed := content_modifier.GetSimpleParallelFinishMode(node.Settings) == content_modifier.SimpleParallelFinishMode_Delayed
+	switch {
+	case background == nil:
+	case state.mainStatus == "":
+		sim.tick(background)
+	case delayed:
+		if sim.runningNodeIds[background.NodeId] && sim.tick(background) == Status_Running {
+			return Status_Running
+		}
+	default:
+		sim.abort(background)
+	}
+
+	if state.mainStatus == "" {
+		return Status_Running
+	}
+	delete(sim.parallelStates, node.NodeId)
+	return state.mainStatus
+}
+
+// abort stops the running nodes in the subtree and clears their states
+func (sim *simulation) abort(node *content_modifier.LogicBtNode) {
+	if !sim.runningNodeIds[node.NodeId] {
+		return
+	}
+	for _, child := range sim.children[node.NodeId] {
+		sim.abort(child)
+	}
+	delete(sim.runningChildIdx, node.NodeId)
+	delete(sim.parallelStates, node.NodeId)
+	sim.visit(node, Status_Aborted)
+}
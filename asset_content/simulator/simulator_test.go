@@ -0,0 +1,204 @@
+package simulator
+
+import (
+	"encoding/json"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+	"strings"
+	"testing"
+)
+
+// newTestDocument builds the document from the nodes written as "id:type:parentId", the children are ordered as they are listed
+func newTestDocument(nodes ...string) *content_modifier.BehaviourTreeDocumentation {
+	doc := &content_modifier.BehaviourTreeDocumentation{}
+	for order, node := range nodes {
+		fields := strings.Split(node, ":")
+		doc.Nodes = append(doc.Nodes, content_modifier.LogicBtNode{NodeId: fields[0], NodeType: fields[1], ParentId: fields[2], Order: order})
+	}
+	return doc
+}
+
+func withSettings(doc *content_modifier.BehaviourTreeDocumentation, nodeId string, settings string) *content_modifier.BehaviourTreeDocumentation {
+	for i := range doc.Nodes {
+		if doc.Nodes[i].NodeId == nodeId {
+			doc.Nodes[i].Settings = json.RawMessage(settings)
+		}
+	}
+	return doc
+}
+
+func withBlackboardCondition(doc *content_modifier.BehaviourTreeDocumentation, nodeId string, condition string) *content_modifier.BehaviourTreeDocumentation {
+	doc.Descriptors = append(doc.Descriptors, content_modifier.LogicBtDescriptor{
+		DescriptorId:   "condition-" + nodeId,
+		AttachTo:       nodeId,
+		DescriptorType: Descriptor_Blackboard,
+		Settings:       json.RawMessage(condition),
+	})
+	return doc
+}
+
+// formatVisits writes the visits of the tick as "id:status" in the order they are finished
+func formatVisits(trace TickTrace) []string {
+	visits := make([]string, 0, len(trace.Visits))
+	for _, visit := range trace.Visits {
+		visits = append(visits, visit.NodeId+":"+visit.Status)
+	}
+	return visits
+}
+
+func TestSimulate(t *testing.T) {
+	const (
+		root     = "root:" + content_modifier.Node_Root + ":"
+		selector = "composite:" + content_modifier.Node_Selector + ":root"
+		sequence = "composite:" + content_modifier.Node_Sequence + ":root"
+		parallel = "composite:" + content_modifier.Node_SimpleParallel + ":root"
+		taskA    = "a:" + content_modifier.Node_Task + ":composite"
+		taskB    = "b:" + content_modifier.Node_Task + ":composite"
+	)
+
+	testCases := []struct {
+		name   string
+		doc    *content_modifier.BehaviourTreeDocumentation
+		script Script
+		visits [][]string // the visits of every tick
+	}{
+		{
+			name:   "selector stops at the first success",
+			doc:    newTestDocument(root, selector, taskA, taskB),
+			script: Script{Ticks: 1, TaskResults: map[string][]string{"a": {Status_Failure}}},
+			visits: [][]string{{"a:failure", "b:success", "composite:success", "root:success"}},
+		},
+		{
+			name:   "sequence stops at the first failure",
+			doc:    newTestDocument(root, sequence, taskA, taskB),
+			script: Script{Ticks: 1, TaskResults: map[string][]string{"a": {Status_Failure}}},
+			visits: [][]string{{"a:failure", "composite:failure", "root:failure"}},
+		},
+		{
+			name:   "sequence resumes the running child on the next tick",
+			doc:    newTestDocument(root, sequence, taskA, taskB),
+			script: Script{Ticks: 2, TaskResults: map[string][]string{"b": {Status_Running, Status_Success}}},
+			visits: [][]string{
+				{"a:success", "b:running", "composite:running", "root:running"},
+				{"b:success", "composite:success", "root:success"},
+			},
+		},
+		{
+			name:   "selector resumes the running child on the next tick",
+			doc:    newTestDocument(root, selector, taskA, taskB),
+			script: Script{Ticks: 2, TaskResults: map[string][]string{"a": {Status_Failure}, "b": {Status_Running, Status_Failure}}},
+			visits: [][]string{
+				{"a:failure", "b:running", "composite:running", "root:running"},
+				{"b:failure", "composite:failure", "root:failure"},
+			},
+		},
+		{
+			name: "simple parallel aborts the background child when the main task finishes immediately",
+			doc:  withSettings(newTestDocument(root, parallel, taskA, taskB), "composite", `{"finishMode":"immediate"}`),
+			script: Script{Ticks: 2, TaskResults: map[string][]string{
+				"a": {Status_Running, Status_Failure},
+				"b": {Status_Running},
+			}},
+			visits: [][]string{
+				{"a:running", "b:running", "composite:running", "root:running"},
+				{"a:failure", "b:aborted", "composite:failure", "root:failure"},
+			},
+		},
+		{
+			name: "simple parallel restarts the background child finished before the main task",
+			doc:  withSettings(newTestDocument(root, parallel, taskA, taskB), "composite", `{"finishMode":"immediate"}`),
+			script: Script{Ticks: 3, TaskResults: map[string][]string{
+				"a": {Status_Running, Status_Running, Status_Success},
+				"b": {Status_Failure},
+			}},
+			visits: [][]string{
+				{"a:running", "b:failure", "composite:running", "root:running"},
+				{"a:running", "b:failure", "composite:running", "root:running"},
+				{"a:success", "composite:success", "root:success"},
+			},
+		},
+		{
+			name: "simple parallel waits for the background child in the delayed mode",
+			doc:  withSettings(newTestDocument(root, parallel, taskA, taskB), "composite", `{"finishMode":"delayed"}`),
+			script: Script{Ticks: 3, TaskResults: map[string][]string{
+				"a": {Status_Running, Status_Success},
+				"b": {Status_Running, Status_Running, Status_Failure},
+			}},
+			visits: [][]string{
+				{"a:running", "b:running", "composite:running", "root:running"},
+				{"a:success", "b:running", "composite:running", "root:running"},
+				{"b:failure", "composite:success", "root:success"},
+			},
+		},
+		{
+			name:   "blackboard condition passes with the script value",
+			doc:    withBlackboardCondition(newTestDocument(root, sequence, taskA), "a", `{"key":"hp","operator":"greater","value":10}`),
+			script: Script{Ticks: 1, InitialBlackboard: map[string]interface{}{"hp": 20.0}},
+			visits: [][]string{{"a:success", "composite:success", "root:success"}},
+		},
+		{
+			name:   "blackboard condition fails the node without running it",
+			doc:    withBlackboardCondition(newTestDocument(root, sequence, taskA), "a", `{"key":"hp","operator":"greater","value":10}`),
+			script: Script{Ticks: 1, TaskResults: map[string][]string{"a": {Status_Running}}, InitialBlackboard: map[string]interface{}{"hp": 10.0}},
+			visits: [][]string{{"a:failure", "composite:failure", "root:failure"}},
+		},
+		{
+			name:   "blackboard condition fails on the absent key",
+			doc:    withBlackboardCondition(newTestDocument(root, sequence, taskA), "a", `{"key":"target","operator":"isSet"}`),
+			script: Script{Ticks: 1},
+			visits: [][]string{{"a:failure", "composite:failure", "root:failure"}},
+		},
+		{
+			name:   "the last result is repeated when the script is used up",
+			doc:    newTestDocument(root, sequence, taskA),
+			script: Script{Ticks: 3, TaskResults: map[string][]string{"a": {Status_Running, Status_Failure}}},
+			visits: [][]string{
+				{"a:running", "composite:running", "root:running"},
+				{"a:failure", "composite:failure", "root:failure"},
+				{"a:failure", "composite:failure", "root:failure"},
+			},
+		},
+		{
+			name:   "the simulation stops on completion",
+			doc:    newTestDocument(root, sequence, taskA),
+			script: Script{Ticks: 5, StopOnCompletion: true, TaskResults: map[string][]string{"a": {Status_Running, Status_Success}}},
+			visits: [][]string{
+				{"a:running", "composite:running", "root:running"},
+				{"a:success", "composite:success", "root:success"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			errCode, errMsg, traces := Simulate(testCase.script, testCase.doc)
+			if errCode != common.Success {
+				t.Fatal(errMsg)
+			}
+			if len(traces) != len(testCase.visits) {
+				t.Fatalf("%d tick(s) are simulated, expected %d", len(traces), len(testCase.visits))
+			}
+			for tick, trace := range traces {
+				if visits := formatVisits(trace); !slices.Equal(visits, testCase.visits[tick]) {
+					t.Errorf("tick %d visits %v, expected %v", tick, visits, testCase.visits[tick])
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateInvalidScript(t *testing.T) {
+	doc := newTestDocument("root:"+content_modifier.Node_Root+":", "a:"+content_modifier.Node_Task+":root")
+
+	if errCode, _, _ := Simulate(Script{Ticks: MaxTicks + 1}, doc); errCode != common.BtSimulationInvalidTicks {
+		t.Fatalf("%d ticks are accepted", MaxTicks+1)
+	}
+	if errCode, _, _ := Simulate(Script{TaskResults: map[string][]string{"a": {"done"}}}, doc); errCode != common.BtSimulationInvalidTaskResult {
+		t.Fatal("the unknown task result is accepted")
+	}
+	doc = withBlackboardCondition(doc, "a", `{"key":"hp","operator":"greater","value":"high"}`)
+	if errCode, _, _ := Simulate(Script{}, doc); errCode != common.BtSimulationInvalidCondition {
+		t.Fatal("the numeric comparison with a string is accepted")
+	}
+}
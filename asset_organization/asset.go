@@ -4,11 +4,11 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content"
 	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
 	"github.com/xxponline/messy-monster-ai-editor/common"
 	"github.com/xxponline/messy-monster-ai-editor/db"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 	"net/http"
 )
 
@@ -16,6 +16,7 @@ type CreateAssetReq struct {
 	AssetSetId string `json:"assetSetId" binding:"required"`
 	AssetType  string `json:"assetType" binding:"required"`
 	AssetName  string `json:"assetName" binding:"required"`
+	Author     string `json:"author" binding:"max=64"` // optional, it's kept in the initial version of the asset
 }
 
 type ListAssetsReq struct {
@@ -31,12 +32,20 @@ type ReadAssetReq struct {
 }
 
 type RenameAssetReq struct {
-	AssetId string `json:"assetId" binding:"required"`
-	NewName string `json:"newName" binding:"required"`
+	AssetId        string `json:"assetId" binding:"required"`
+	CurrentVersion string `json:"currentVersion" binding:"required"`
+	NewName        string `json:"newName" binding:"required"`
 }
 
 type RemoveAssetReq struct {
-	AssetId string `json:"assetId" binding:"required"`
+	AssetId        string `json:"assetId" binding:"required"`
+	CurrentVersion string `json:"currentVersion" binding:"required"`
+}
+
+type MoveAssetReq struct {
+	AssetId          string `json:"assetId" binding:"required"`
+	CurrentVersion   string `json:"currentVersion" binding:"required"`
+	TargetAssetSetId string `json:"targetAssetSetId" binding:"required"`
 }
 
 func CreateAssetAPI(context *gin.Context) {
@@ -69,6 +78,23 @@ func CreateAssetAPI(context *gin.Context) {
 				return
 			}
 		case "BlackBoard":
+			errCode, errMsg, initialContent = content_modifier.BlackBoardCreateEmptyContent()
+			if errCode != common.Success {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    errCode,
+					"errMessage": errMsg,
+				})
+				return
+			}
+		case "GOAP":
+			errCode, errMsg, initialContent = content_modifier.GoapCreateEmptyContent()
+			if errCode != common.Success {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    errCode,
+					"errMessage": errMsg,
+				})
+				return
+			}
 		default:
 			context.JSON(http.StatusOK, gin.H{
 				"errCode":    common.InvalidAssetType,
@@ -78,21 +104,19 @@ func CreateAssetAPI(context *gin.Context) {
 		}
 	}
 
-	err := db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var err error
+	err := db.Storage.Transaction(func(repo db.Repository) error {
+		//Asset Set Checking Pass
+		_, err := queryAssetSetPass(context, repo, req.AssetSetId)
+		if err != nil {
+			return err
+		}
 
 		//Duplicated Asset Name Checking Pass
-		{
-			var count int64
-			db.GormDatabase.Model(&common.AssetDetailInfo{}).Where("assetSetId = ? AND assetName = ?", req.AssetSetId, req.AssetName).Count(&count)
-			if count > 0 {
-				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.DuplicatedAssetName,
-					"errMessage": common.DuplicatedAssetName.GetMsgFormat(req.AssetName),
-				})
-				return errors.New(common.DuplicatedAssetName.GetMsgFormat(req.AssetName))
-			}
+		err = checkDuplicatedAssetNamePass(context, repo, req.AssetSetId, req.AssetName)
+		if err != nil {
+			return err
 		}
+
 		//Creating Pass
 		newAssetId := uuid.New().String()
 		{
@@ -105,7 +129,14 @@ func CreateAssetAPI(context *gin.Context) {
 				AssetContent: initialContent,
 			}
 
-			err = db.GormDatabase.Create(&newAssetItem).Error
+			err = repo.CreateAsset(&newAssetItem)
+			if err != nil {
+				respondSavingError(context, err, common.DuplicatedAssetName, req.AssetName)
+				return err
+			}
+
+			//Version Recording
+			err = asset_content.RecordAssetVersion(repo, &newAssetItem, "", "", req.Author)
 			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
 					"errCode":    common.DataBaseError,
@@ -117,7 +148,7 @@ func CreateAssetAPI(context *gin.Context) {
 		//Querying Pass
 		var assetItems []common.AssetSummaryInfoItem
 		{
-			err = db.GormDatabase.Find(&assetItems, "assetSetId = ?", req.AssetSetId).Error
+			assetItems, err = repo.ListAssets([]string{req.AssetSetId})
 			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
 					"errCode":    common.DataBaseError,
@@ -142,8 +173,272 @@ func CreateAssetAPI(context *gin.Context) {
 	}
 }
 
-func RenameAssetAPI(ctx *gin.Context) {
+// checkAssetVersionPass queries the asset for update and checks the version of the request, the error response is written when failed.
+// the version is about the content, so it's kept by the rename and the move
+func checkAssetVersionPass(context *gin.Context, repo db.Repository, assetId string, currentVersion string) (*common.AssetDetailInfo, error) {
+	assetDetail, err := repo.GetAssetForUpdate(assetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		errMsg := common.InvalidAsset.GetMsgFormat(assetId)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAsset,
+			"errMessage": errMsg,
+		})
+		return nil, errors.New(errMsg)
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return nil, err
+	}
+
+	if assetDetail.AssetVersion != currentVersion {
+		errMsg := common.InvalidAssetVersion.GetMsgFormat(assetDetail.AssetVersion, currentVersion)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAssetVersion,
+			"errMessage": errMsg,
+		})
+		return nil, errors.New(errMsg)
+	}
+	return assetDetail, nil
+}
+
+// checkDuplicatedAssetNamePass checks the name isn't used by the assets of the asset set, the error response is written when failed
+func checkDuplicatedAssetNamePass(context *gin.Context, repo db.Repository, assetSetId string, assetName string) error {
+	count, err := repo.CountAssetsByName(assetSetId, assetName, "")
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return err
+	}
+	if count > 0 {
+		errMsg := common.DuplicatedAssetName.GetMsgFormat(assetName)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DuplicatedAssetName,
+			"errMessage": errMsg,
+		})
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+func queryAssetSummaryInfosPass(context *gin.Context, repo db.Repository, assetSetId string) ([]common.AssetSummaryInfoItem, error) {
+	assetItems, err := repo.ListAssets([]string{assetSetId})
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return nil, err
+	}
+	return assetItems, nil
+}
+
+func RenameAssetAPI(context *gin.Context) {
+	var req RenameAssetReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Version Checking Pass
+		assetDetail, err := checkAssetVersionPass(context, repo, req.AssetId, req.CurrentVersion)
+		if err != nil {
+			return err
+		}
+
+		//Duplicated Asset Name Checking Pass
+		if assetDetail.AssetName != req.NewName {
+			err = checkDuplicatedAssetNamePass(context, repo, assetDetail.AssetSetId, req.NewName)
+			if err != nil {
+				return err
+			}
+		}
+
+		//Renaming Pass
+		assetDetail.AssetName = req.NewName
+		err = repo.SaveAsset(assetDetail)
+		if err != nil {
+			respondSavingError(context, err, common.DuplicatedAssetName, req.NewName)
+			return err
+		}
+
+		//Querying Pass
+		assetItems, err := queryAssetSummaryInfosPass(context, repo, assetDetail.AssetSetId)
+		if err != nil {
+			return err
+		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":           common.Success,
+			"errMessage":        "",
+			"assetSummaryInfos": assetItems,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+// RemoveAssetAPI deletes the asset, its modification history and versions are deleted by the foreign key, the assets referring to it are reported by their validations
+func RemoveAssetAPI(context *gin.Context) {
+	var req RemoveAssetReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	var removedAssetType string
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Version Checking Pass
+		assetDetail, err := checkAssetVersionPass(context, repo, req.AssetId, req.CurrentVersion)
+		if err != nil {
+			return err
+		}
+		removedAssetType = assetDetail.AssetType
+
+		//Deleting Pass
+		err = repo.DeleteAsset(req.AssetId)
+		if err != nil {
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.DataBaseError,
+				"errMessage": err.Error(),
+			})
+			return err
+		}
+
+		//Querying Pass
+		assetItems, err := queryAssetSummaryInfosPass(context, repo, assetDetail.AssetSetId)
+		if err != nil {
+			return err
+		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":           common.Success,
+			"errMessage":        "",
+			"assetSummaryInfos": assetItems,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+		return
+	}
+
+	//Closing The Subscriptions After The Removal Is Committed
+	if removedAssetType == "BehaviourTree" {
+		asset_content.DropBehaviourTreeSubscriptions(req.AssetId)
+	}
+}
+
+// MoveAssetAPI moves the asset to another asset set in the same solution, so the references between the assets are kept valid
+func MoveAssetAPI(context *gin.Context) {
+	var req MoveAssetReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Version Checking Pass
+		assetDetail, err := checkAssetVersionPass(context, repo, req.AssetId, req.CurrentVersion)
+		if err != nil {
+			return err
+		}
+
+		//Target Asset Set Checking Pass
+		{
+			var sourceAssetSet, targetAssetSet *common.AssetSetInfoItem
+			targetAssetSet, err = repo.GetAssetSet(req.TargetAssetSetId)
+			if errors.Is(err, db.ErrRecordNotFound) {
+				errMsg := common.InvalidAssetSet.GetMsgFormat(req.TargetAssetSetId)
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.InvalidAssetSet,
+					"errMessage": errMsg,
+				})
+				return errors.New(errMsg)
+			}
+			if err == nil {
+				sourceAssetSet, err = repo.GetAssetSet(assetDetail.AssetSetId)
+			}
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+			if sourceAssetSet.SolutionId != targetAssetSet.SolutionId {
+				errMsg := common.MoveAssetAcrossSolutions.GetMsgFormat(req.TargetAssetSetId)
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.MoveAssetAcrossSolutions,
+					"errMessage": errMsg,
+				})
+				return errors.New(errMsg)
+			}
+		}
+
+		//Duplicated Asset Name Checking Pass
+		if assetDetail.AssetSetId != req.TargetAssetSetId {
+			err = checkDuplicatedAssetNamePass(context, repo, req.TargetAssetSetId, assetDetail.AssetName)
+			if err != nil {
+				return err
+			}
+		}
+
+		//Moving Pass
+		sourceAssetSetId := assetDetail.AssetSetId
+		assetDetail.AssetSetId = req.TargetAssetSetId
+		err = repo.SaveAsset(assetDetail)
+		if err != nil {
+			respondSavingError(context, err, common.DuplicatedAssetName, assetDetail.AssetName)
+			return err
+		}
+
+		//Querying Pass
+		sourceAssetItems, err := queryAssetSummaryInfosPass(context, repo, sourceAssetSetId)
+		if err != nil {
+			return err
+		}
+		assetItems, err := queryAssetSummaryInfosPass(context, repo, req.TargetAssetSetId)
+		if err != nil {
+			return err
+		}
 
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":                 common.Success,
+			"errMessage":              "",
+			"assetSummaryInfos":       assetItems,
+			"sourceAssetSummaryInfos": sourceAssetItems,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
 }
 
 func ListAssetsAPI(context *gin.Context) {
@@ -157,9 +452,13 @@ func ListAssetsAPI(context *gin.Context) {
 		return
 	}
 
-	var assetItems []common.AssetSummaryInfoItem
+	_, err = queryAssetSetPass(context, db.Storage, req.AssetSetId)
+	if err != nil {
+		zap.S().Error(err)
+		return
+	}
 
-	err = db.GormDatabase.Find(&assetItems, "assetSetId = ?", req.AssetSetId).Error
+	assetItems, err := db.Storage.ListAssets([]string{req.AssetSetId})
 	if err != nil {
 		context.JSON(http.StatusOK, gin.H{
 			"errCode":    common.DataBaseError,
@@ -186,9 +485,13 @@ func ListAssetsByMultipleAssetSetsAPI(context *gin.Context) {
 		return
 	}
 
-	var assetItems []common.AssetSummaryInfoItem
+	err = checkAssetSetsPass(context, db.Storage, req.AssetSetIds)
+	if err != nil {
+		zap.S().Error(err)
+		return
+	}
 
-	err = db.GormDatabase.Find(&assetItems, "assetSetId IN ?", req.AssetSetIds).Error
+	assetItems, err := db.Storage.ListAssets(req.AssetSetIds)
 	if err != nil {
 		context.JSON(http.StatusOK, gin.H{
 			"errCode":    common.DataBaseError,
@@ -215,8 +518,14 @@ func ReadAssetAPI(context *gin.Context) {
 		return
 	}
 
-	var assetDetail common.AssetDetailInfo
-	err = db.GormDatabase.First(&assetDetail, "id = ?", req.AssetId).Error
+	assetDetail, err := db.Storage.GetAsset(req.AssetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAsset,
+			"errMessage": common.InvalidAsset.GetMsgFormat(req.AssetId),
+		})
+		return
+	}
 	if err != nil {
 		context.JSON(http.StatusOK, gin.H{
 			"errCode":    common.DataBaseError,
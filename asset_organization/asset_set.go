@@ -8,7 +8,7 @@ import (
 	"github.com/xxponline/messy-monster-ai-editor/common"
 	"github.com/xxponline/messy-monster-ai-editor/db"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
+	"golang.org/x/exp/slices"
 	"net/http"
 )
 
@@ -21,6 +21,21 @@ type CreateAssetSetReq struct {
 	AssetSetName string `json:"assetSetName" binding:"required"`
 }
 
+type RenameAssetSetReq struct {
+	AssetSetId string `json:"assetSetId" binding:"required"`
+	NewName    string `json:"newName" binding:"required"`
+}
+
+type ReorderAssetSetsReq struct {
+	SolutionId         string   `json:"solutionId" binding:"required"`
+	OrderedAssetSetIds []string `json:"orderedAssetSetIds" binding:"required"` // all the asset sets of the solution in the new order
+}
+
+type RemoveAssetSetReq struct {
+	AssetSetId     string `json:"assetSetId" binding:"required"`
+	ConfirmVersion string `json:"confirmVersion" binding:"required"` // must be the current version of the solution owning the asset set
+}
+
 type GetAssetSetArchiveReq struct {
 	AssetSetIds []string `json:"assetSetIds" binding:"required"`
 }
@@ -29,6 +44,8 @@ type AssetSetArchive struct {
 	AssetSetName        string                                `json:"assetSetName" binding:"required"`
 	AssetSetId          string                                `json:"assetSetId" binding:"required"`
 	BehaviourTreeAssets []asset_content.ArchivedBehaviourTree `json:"behaviourTreeAssets" binding:"required"`
+	BlackBoardAssets    []asset_content.ArchivedBlackBoard    `json:"blackBoardAssets" binding:"required"`
+	GoapAssets          []asset_content.ArchivedGoap          `json:"goapAssets" binding:"required"`
 }
 
 func ListAssetSetsAPI(context *gin.Context) {
@@ -42,9 +59,15 @@ func ListAssetSetsAPI(context *gin.Context) {
 		return
 	}
 
+	_, err = querySolutionPass(context, db.Storage, req.SolutionId)
+	if err != nil {
+		zap.S().Error(err)
+		return
+	}
+
 	var assetSetInfos []common.AssetSetInfoItem
 
-	err = db.GormDatabase.Find(&assetSetInfos).Error
+	assetSetInfos, err = db.Storage.ListAssetSets(req.SolutionId)
 
 	if err != nil {
 		context.JSON(http.StatusOK, gin.H{
@@ -72,38 +95,43 @@ func CreateAssetSetAPI(context *gin.Context) {
 		return
 	}
 
-	db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var err error
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Solution Checking Pass
+		_, err := querySolutionPass(context, repo, req.SolutionId)
+		if err != nil {
+			return err
+		}
+
 		//Duplicated Name Checking Pass
+		err = checkDuplicatedAssetSetNamePass(context, repo, req.SolutionId, req.AssetSetName, "")
+		if err != nil {
+			return err
+		}
+
+		//Create New Asset Set Item Pass, The New One Is Placed Last
+		newAssetSetId := uuid.New().String()
 		{
-			var count int64
-			tx.Model(&common.AssetSetInfoItem{}).Where("solutionId = ? AND assetSetName = ?", req.SolutionId, req.AssetSetName).Count(&count)
-			if count > 0 {
-				errMsg := common.DuplicatedAssetSetName.GetMsgFormat(req.AssetSetName)
-				errors.New(errMsg)
+			existAssetSetInfos, err := repo.ListAssetSets(req.SolutionId)
+			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.DuplicatedSolutionName,
-					"errMessage": errMsg,
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
 				})
 				return err
 			}
-		}
 
-		//Create New Asset Set Item Pass
-		newAssetSetId := uuid.New().String()
-		{
 			newAssetSetItem := common.AssetSetInfoItem{
 				AssetSetId:   newAssetSetId,
 				AssetSetName: req.AssetSetName,
 				SolutionId:   req.SolutionId,
 			}
+			if len(existAssetSetInfos) > 0 {
+				newAssetSetItem.SortOrder = existAssetSetInfos[len(existAssetSetInfos)-1].SortOrder + 1
+			}
 
-			err = tx.Create(&newAssetSetItem).Error
+			err = repo.CreateAssetSet(&newAssetSetItem)
 			if err != nil {
-				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.DataBaseError,
-					"errMessage": err.Error(),
-				})
+				respondSavingError(context, err, common.DuplicatedAssetSetName, req.AssetSetName)
 				return err
 			}
 		}
@@ -111,7 +139,8 @@ func CreateAssetSetAPI(context *gin.Context) {
 		//Query Pass
 		var assetSetInfos []common.AssetSetInfoItem
 		{
-			err := tx.Find(&assetSetInfos).Error
+			var err error
+			assetSetInfos, err = repo.ListAssetSets(req.SolutionId)
 			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
 					"errCode":    common.DataBaseError,
@@ -137,6 +166,288 @@ func CreateAssetSetAPI(context *gin.Context) {
 	}
 }
 
+// queryAssetSetPass queries the asset set, the error response is written when failed
+func queryAssetSetPass(context *gin.Context, repo db.Repository, assetSetId string) (*common.AssetSetInfoItem, error) {
+	assetSetItem, err := repo.GetAssetSet(assetSetId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		errMsg := common.InvalidAssetSet.GetMsgFormat(assetSetId)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidAssetSet,
+			"errMessage": errMsg,
+		})
+		return nil, errors.New(errMsg)
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return nil, err
+	}
+	return assetSetItem, nil
+}
+
+// checkDuplicatedAssetSetNamePass checks the name isn't used by the other asset sets of the solution, the error response is written when failed
+func checkDuplicatedAssetSetNamePass(context *gin.Context, repo db.Repository, solutionId string, assetSetName string, excludedAssetSetId string) error {
+	count, err := repo.CountAssetSetsByName(solutionId, assetSetName, excludedAssetSetId)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return err
+	}
+	if count > 0 {
+		errMsg := common.DuplicatedAssetSetName.GetMsgFormat(assetSetName)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DuplicatedAssetSetName,
+			"errMessage": errMsg,
+		})
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+// checkAssetSetsPass checks all the asset sets exist, the error response is written when failed
+func checkAssetSetsPass(context *gin.Context, repo db.Repository, assetSetIds []string) error {
+	existAssetSets, err := repo.GetAssetSets(assetSetIds)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return err
+	}
+	for _, assetSetId := range assetSetIds {
+		if !slices.ContainsFunc(existAssetSets, func(setItem common.AssetSetInfoItem) bool { return setItem.AssetSetId == assetSetId }) {
+			errMsg := common.InvalidAssetSet.GetMsgFormat(assetSetId)
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.InvalidAssetSet,
+				"errMessage": errMsg,
+			})
+			return errors.New(errMsg)
+		}
+	}
+	return nil
+}
+
+func RenameAssetSetAPI(context *gin.Context) {
+	var req RenameAssetSetReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Querying Pass
+		assetSetItem, err := queryAssetSetPass(context, repo, req.AssetSetId)
+		if err != nil {
+			return err
+		}
+
+		//Duplicated Name Checking Pass
+		err = checkDuplicatedAssetSetNamePass(context, repo, assetSetItem.SolutionId, req.NewName, req.AssetSetId)
+		if err != nil {
+			return err
+		}
+
+		//Update Pass
+		{
+			assetSetItem.AssetSetName = req.NewName
+			err = repo.SaveAssetSet(assetSetItem)
+			if err != nil {
+				respondSavingError(context, err, common.DuplicatedAssetSetName, req.NewName)
+				return err
+			}
+		}
+
+		//Query Pass
+		var assetSetInfos []common.AssetSetInfoItem
+		{
+			var err error
+			assetSetInfos, err = repo.ListAssetSets(assetSetItem.SolutionId)
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.Success,
+			"errMessage": "",
+			"assetSets":  assetSetInfos,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+// ReorderAssetSetsAPI arranges the order of listing the asset sets of the solution, the solution version is kept since nothing in the asset sets is modified
+func ReorderAssetSetsAPI(context *gin.Context) {
+	var req ReorderAssetSetsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Solution Checking Pass
+		_, err := querySolutionPass(context, repo, req.SolutionId)
+		if err != nil {
+			return err
+		}
+
+		//Mismatch Checking Pass
+		existAssetSetInfos, err := repo.ListAssetSets(req.SolutionId)
+		if err != nil {
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.DataBaseError,
+				"errMessage": err.Error(),
+			})
+			return err
+		}
+		mismatched := len(existAssetSetInfos) != len(req.OrderedAssetSetIds) || slices.ContainsFunc(existAssetSetInfos, func(setItem common.AssetSetInfoItem) bool {
+			return !slices.Contains(req.OrderedAssetSetIds, setItem.AssetSetId)
+		})
+		if mismatched {
+			errMsg := common.ReorderAssetSetsMismatch.GetMsgFormat(req.SolutionId)
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.ReorderAssetSetsMismatch,
+				"errMessage": errMsg,
+			})
+			return errors.New(errMsg)
+		}
+
+		//Update Pass
+		for _, setItem := range existAssetSetInfos {
+			sortOrder := slices.Index(req.OrderedAssetSetIds, setItem.AssetSetId)
+			if setItem.SortOrder == sortOrder {
+				continue
+			}
+			err = repo.UpdateAssetSetSortOrder(setItem.AssetSetId, sortOrder)
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//Query Pass
+		var assetSetInfos []common.AssetSetInfoItem
+		{
+			var err error
+			assetSetInfos, err = repo.ListAssetSets(req.SolutionId)
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.Success,
+			"errMessage": "",
+			"assetSets":  assetSetInfos,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+// RemoveAssetSetAPI deletes the asset set, all of its assets are deleted by the foreign keys, the solution version is renewed
+// so the other pending confirmations of the solution are invalidated
+func RemoveAssetSetAPI(context *gin.Context) {
+	var req RemoveAssetSetReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Querying Pass
+		assetSetItem, err := queryAssetSetPass(context, repo, req.AssetSetId)
+		if err != nil {
+			return err
+		}
+
+		//Confirmation Checking Pass
+		existSolutionItem, err := checkSolutionVersionPass(context, repo, assetSetItem.SolutionId, req.ConfirmVersion)
+		if err != nil {
+			return err
+		}
+
+		//Cascade Deleting Pass
+		{
+			err = repo.DeleteAssetSet(req.AssetSetId)
+			if err == nil {
+				existSolutionItem.SolutionVersion = uuid.New().String()
+				err = repo.SaveSolution(existSolutionItem)
+			}
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//Query Pass
+		var assetSetInfos []common.AssetSetInfoItem
+		{
+			var err error
+			assetSetInfos, err = repo.ListAssetSets(assetSetItem.SolutionId)
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":         common.Success,
+			"errMessage":      "",
+			"assetSets":       assetSetInfos,
+			"solutionVersion": existSolutionItem.SolutionVersion,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
 func GetArchivedAssetSetsAPI(context *gin.Context) {
 	var req GetAssetSetArchiveReq
 	err := context.BindJSON(&req)
@@ -184,16 +495,21 @@ func doGetArchivedAssetSets(req *GetAssetSetArchiveReq) (common.ErrorCode, strin
 	errMsg = ""
 	errCode = common.Success
 
-	err := db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var err error
+	err := db.Storage.Transaction(func(repo db.Repository) error {
 		// ready asset set data
 		allArchives = make([]AssetSetArchive, 0, len(req.AssetSetIds))
 		{
-			var assetSets []common.AssetSetInfoItem
-			err = tx.Find(&assetSets, "id IN ?", req.AssetSetIds).Error
+			assetSets, err := repo.GetAssetSets(req.AssetSetIds)
 			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
 				return err
 			}
+			for _, assetSetId := range req.AssetSetIds {
+				if !slices.ContainsFunc(assetSets, func(setItem common.AssetSetInfoItem) bool { return setItem.AssetSetId == assetSetId }) {
+					errCode, errMsg = common.InvalidAssetSet, common.InvalidAssetSet.GetMsgFormat(assetSetId)
+					return errors.New(errMsg)
+				}
+			}
 
 			for _, setItem := range assetSets {
 				allArchives = append(allArchives, AssetSetArchive{
@@ -203,18 +519,19 @@ func doGetArchivedAssetSets(req *GetAssetSetArchiveReq) (common.ErrorCode, strin
 			}
 		}
 
-		// process the behaviour trees
+		// process the behaviour trees, the black boards and the GOAP assets
 		{
-			var assetItems []common.AssetDetailInfo
-
-			err = tx.Find(&assetItems).Error
+			assetItems, err := repo.ListAssetDetails(req.AssetSetIds)
 			if err != nil {
+				errCode, errMsg = common.DataBaseError, err.Error()
 				return err
 			}
 
 			for i, _ := range allArchives {
 				archive := &allArchives[i]
 				archivedBtAssets := make([]asset_content.ArchivedBehaviourTree, 0, 8)
+				archivedBbAssets := make([]asset_content.ArchivedBlackBoard, 0, 8)
+				archivedGoapAssets := make([]asset_content.ArchivedGoap, 0, 8)
 
 				for itemIdx, _ := range assetItems {
 					if archive.AssetSetId == assetItems[itemIdx].AssetSetId {
@@ -227,14 +544,32 @@ func doGetArchivedAssetSets(req *GetAssetSetArchiveReq) (common.ErrorCode, strin
 							}
 							archivedBtAssets = append(archivedBtAssets, *archivedBtAsset)
 							break
+						case "BlackBoard":
+							var archivedBbAsset *asset_content.ArchivedBlackBoard
+							errCode, errMsg, archivedBbAsset = asset_content.GetArchivedBlackBoardAsset(&assetItems[itemIdx])
+							if errCode != common.Success {
+								return errors.New(errMsg)
+							}
+							archivedBbAssets = append(archivedBbAssets, *archivedBbAsset)
+							break
+						case "GOAP":
+							var archivedGoapAsset *asset_content.ArchivedGoap
+							errCode, errMsg, archivedGoapAsset = asset_content.GetArchivedGoapAsset(&assetItems[itemIdx])
+							if errCode != common.Success {
+								return errors.New(errMsg)
+							}
+							archivedGoapAssets = append(archivedGoapAssets, *archivedGoapAsset)
+							break
 						default:
 							errCode = common.ArchiveAssetsInvalidAssetType
-							errMsg = common.ArchiveAssetsInvalidAssetType.GetMsgFormat(&assetItems[itemIdx].AssetType)
+							errMsg = common.ArchiveAssetsInvalidAssetType.GetMsgFormat(assetItems[itemIdx].AssetType)
 							return errors.New(errMsg)
 						}
 					}
 				}
 				archive.BehaviourTreeAssets = archivedBtAssets
+				archive.BlackBoardAssets = archivedBbAssets
+				archive.GoapAssets = archivedGoapAssets
 			}
 		}
 
@@ -7,13 +7,26 @@ func InitializeAssetManagement(router *gin.RouterGroup) {
 	router.POST("CreateSolution", CreateSolutionAPI)
 	router.POST("GetSolutionDetail", GetSolutionDetailAPI)
 	router.POST("SubmitSolutionMeta", SubmitSolutionMetaAPI)
+	router.POST("RenameSolution", RenameSolutionAPI)
+	router.POST("RemoveSolution", RemoveSolutionAPI)
+	router.POST("ReorderSolutions", ReorderSolutionsAPI)
+
+	router.POST("RegisterSettingsSchema", RegisterSettingsSchemaAPI)
+	router.POST("RemoveSettingsSchema", RemoveSettingsSchemaAPI)
+	router.POST("ListSettingsSchemas", ListSettingsSchemasAPI)
 
 	router.POST("ListAssetSets", ListAssetSetsAPI)
 	router.POST("CreateAssetSet", CreateAssetSetAPI)
+	router.POST("RenameAssetSet", RenameAssetSetAPI)
+	router.POST("RemoveAssetSet", RemoveAssetSetAPI)
+	router.POST("ReorderAssetSets", ReorderAssetSetsAPI)
 	router.POST("GetArchivedAssetSets", GetArchivedAssetSetsAPI)
 
 	router.POST("CreateAsset", CreateAssetAPI)
 	router.POST("ListAssets", ListAssetsAPI)
 	router.POST("ListAssetsByMultipleAssetSets", ListAssetsByMultipleAssetSetsAPI)
 	router.POST("ReadAsset", ReadAssetAPI)
+	router.POST("RenameAsset", RenameAssetAPI)
+	router.POST("RemoveAsset", RemoveAssetAPI)
+	router.POST("MoveAsset", MoveAssetAPI)
 }
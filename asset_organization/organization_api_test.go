@@ -0,0 +1,429 @@
+package asset_organization
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type apiResponse struct {
+	ErrCode    common.ErrorCode `json:"errCode"`
+	ErrMessage string           `json:"errMessage"`
+}
+
+type solutionsResponse struct {
+	apiResponse
+	NewSolutionId  string                           `json:"newSolutionId"`
+	Solutions      []common.SolutionSummaryInfoItem `json:"solutions"`
+	SolutionDetail *common.SolutionDetailInfo       `json:"solutionDetail"`
+}
+
+type assetSetsResponse struct {
+	apiResponse
+	NewAssetSetId   string                    `json:"newAssetSetId"`
+	AssetSets       []common.AssetSetInfoItem `json:"assetSets"`
+	SolutionVersion string                    `json:"solutionVersion"`
+}
+
+type assetsResponse struct {
+	apiResponse
+	NewAssetId              string                        `json:"newAssetId"`
+	AssetSummaryInfos       []common.AssetSummaryInfoItem `json:"assetSummaryInfos"`
+	SourceAssetSummaryInfos []common.AssetSummaryInfoItem `json:"sourceAssetSummaryInfos"`
+	AssetDocument           *common.AssetDetailInfo       `json:"assetDocument"`
+}
+
+// newTestRouter serves the handlers with an empty in-memory repository
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	db.Storage = db.NewMemoryRepository()
+	router := gin.New()
+	InitializeAssetManagement(router.Group("API/AssetManagement"))
+	return router
+}
+
+// callAPI posts the request as JSON, or gets the API when req is nil, and decodes the response into resp
+func callAPI(t *testing.T, router *gin.Engine, name string, req interface{}, resp interface{}) {
+	t.Helper()
+	method, body := http.MethodGet, []byte(nil)
+	if req != nil {
+		var err error
+		method = http.MethodPost
+		body, err = json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, "/API/AssetManagement/"+name, bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("%s responds the status %d", name, recorder.Code)
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), resp)
+	if err != nil {
+		t.Fatalf("%s responds the invalid JSON %s: %v", name, recorder.Body.String(), err)
+	}
+}
+
+func expectErrCode(t *testing.T, name string, resp *apiResponse, errCode common.ErrorCode) {
+	t.Helper()
+	if resp.ErrCode != errCode {
+		t.Fatalf("%s responds the errCode %d (%s), expected %d", name, resp.ErrCode, resp.ErrMessage, errCode)
+	}
+}
+
+func createTestSolution(t *testing.T, router *gin.Engine, solutionName string) string {
+	t.Helper()
+	var resp solutionsResponse
+	callAPI(t, router, "CreateSolution", gin.H{"solutionName": solutionName}, &resp)
+	expectErrCode(t, "CreateSolution", &resp.apiResponse, common.Success)
+	return resp.NewSolutionId
+}
+
+func createTestAssetSet(t *testing.T, router *gin.Engine, solutionId string, assetSetName string) string {
+	t.Helper()
+	var resp assetSetsResponse
+	callAPI(t, router, "CreateAssetSet", gin.H{"solutionId": solutionId, "assetSetName": assetSetName}, &resp)
+	expectErrCode(t, "CreateAssetSet", &resp.apiResponse, common.Success)
+	return resp.NewAssetSetId
+}
+
+func createTestAsset(t *testing.T, router *gin.Engine, assetSetId string, assetType string, assetName string) string {
+	t.Helper()
+	var resp assetsResponse
+	callAPI(t, router, "CreateAsset", gin.H{"assetSetId": assetSetId, "assetType": assetType, "assetName": assetName}, &resp)
+	expectErrCode(t, "CreateAsset", &resp.apiResponse, common.Success)
+	return resp.NewAssetId
+}
+
+func solutionVersion(t *testing.T, router *gin.Engine, solutionId string) string {
+	t.Helper()
+	var resp solutionsResponse
+	callAPI(t, router, "GetSolutionDetail", gin.H{"solutionId": solutionId}, &resp)
+	expectErrCode(t, "GetSolutionDetail", &resp.apiResponse, common.Success)
+	return resp.SolutionDetail.SolutionVersion
+}
+
+func TestSolutionCreateListRename(t *testing.T) {
+	router := newTestRouter()
+	firstId := createTestSolution(t, router, "First")
+	secondId := createTestSolution(t, router, "Second")
+
+	var createResp solutionsResponse
+	callAPI(t, router, "CreateSolution", gin.H{"solutionName": "First"}, &createResp)
+	expectErrCode(t, "CreateSolution", &createResp.apiResponse, common.DuplicatedSolutionName)
+
+	var listResp solutionsResponse
+	callAPI(t, router, "ListSolutions", nil, &listResp)
+	expectErrCode(t, "ListSolutions", &listResp.apiResponse, common.Success)
+	if len(listResp.Solutions) != 2 || listResp.Solutions[0].SolutionId != firstId || listResp.Solutions[1].SolutionId != secondId {
+		t.Fatalf("the solutions aren't listed in the creating order: %+v", listResp.Solutions)
+	}
+
+	version := solutionVersion(t, router, secondId)
+	var renameResp solutionsResponse
+	callAPI(t, router, "RenameSolution", gin.H{"solutionId": secondId, "currentVersion": version, "newName": "First"}, &renameResp)
+	expectErrCode(t, "RenameSolution", &renameResp.apiResponse, common.DuplicatedSolutionName)
+	callAPI(t, router, "RenameSolution", gin.H{"solutionId": secondId, "currentVersion": "stale", "newName": "Renamed"}, &renameResp)
+	expectErrCode(t, "RenameSolution", &renameResp.apiResponse, common.InvalidSolutionVersion)
+
+	renameResp = solutionsResponse{}
+	callAPI(t, router, "RenameSolution", gin.H{"solutionId": secondId, "currentVersion": version, "newName": "Renamed"}, &renameResp)
+	expectErrCode(t, "RenameSolution", &renameResp.apiResponse, common.Success)
+	if renameResp.SolutionDetail.SolutionName != "Renamed" || renameResp.SolutionDetail.SolutionVersion == version {
+		t.Fatalf("the solution isn't renamed with a new version: %+v", renameResp.SolutionDetail)
+	}
+}
+
+func TestAssetSetAndAssetCreateRename(t *testing.T) {
+	router := newTestRouter()
+	solutionId := createTestSolution(t, router, "Solution")
+	assetSetId := createTestAssetSet(t, router, solutionId, "Set")
+	otherAssetSetId := createTestAssetSet(t, router, solutionId, "Other")
+
+	var assetSetResp assetSetsResponse
+	callAPI(t, router, "CreateAssetSet", gin.H{"solutionId": solutionId, "assetSetName": "Set"}, &assetSetResp)
+	expectErrCode(t, "CreateAssetSet", &assetSetResp.apiResponse, common.DuplicatedAssetSetName)
+	callAPI(t, router, "RenameAssetSet", gin.H{"assetSetId": otherAssetSetId, "newName": "Set"}, &assetSetResp)
+	expectErrCode(t, "RenameAssetSet", &assetSetResp.apiResponse, common.DuplicatedAssetSetName)
+	callAPI(t, router, "RenameAssetSet", gin.H{"assetSetId": otherAssetSetId, "newName": "Renamed"}, &assetSetResp)
+	expectErrCode(t, "RenameAssetSet", &assetSetResp.apiResponse, common.Success)
+
+	assetId := createTestAsset(t, router, assetSetId, "BehaviourTree", "Tree")
+	createTestAsset(t, router, assetSetId, "BlackBoard", "Board")
+	var assetResp assetsResponse
+	callAPI(t, router, "CreateAsset", gin.H{"assetSetId": assetSetId, "assetType": "BlackBoard", "assetName": "Tree"}, &assetResp)
+	expectErrCode(t, "CreateAsset", &assetResp.apiResponse, common.DuplicatedAssetName)
+
+	callAPI(t, router, "ReadAsset", gin.H{"assetId": assetId}, &assetResp)
+	expectErrCode(t, "ReadAsset", &assetResp.apiResponse, common.Success)
+	assetVersion := assetResp.AssetDocument.AssetVersion
+	callAPI(t, router, "RenameAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion, "newName": "Board"}, &assetResp)
+	expectErrCode(t, "RenameAsset", &assetResp.apiResponse, common.DuplicatedAssetName)
+
+	assetResp = assetsResponse{}
+	callAPI(t, router, "RenameAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion, "newName": "Renamed"}, &assetResp)
+	expectErrCode(t, "RenameAsset", &assetResp.apiResponse, common.Success)
+	if len(assetResp.AssetSummaryInfos) != 2 {
+		t.Fatalf("the assets of the asset set aren't listed after renaming: %+v", assetResp.AssetSummaryInfos)
+	}
+}
+
+func assetVersion(t *testing.T, router *gin.Engine, assetId string) string {
+	t.Helper()
+	var resp assetsResponse
+	callAPI(t, router, "ReadAsset", gin.H{"assetId": assetId}, &resp)
+	expectErrCode(t, "ReadAsset", &resp.apiResponse, common.Success)
+	return resp.AssetDocument.AssetVersion
+}
+
+func TestAssetMoveAndRemove(t *testing.T) {
+	router := newTestRouter()
+	solutionId := createTestSolution(t, router, "Solution")
+	sourceAssetSetId := createTestAssetSet(t, router, solutionId, "Source")
+	targetAssetSetId := createTestAssetSet(t, router, solutionId, "Target")
+	otherAssetSetId := createTestAssetSet(t, router, createTestSolution(t, router, "Other"), "Set")
+	assetId := createTestAsset(t, router, sourceAssetSetId, "BehaviourTree", "Tree")
+	createTestAsset(t, router, targetAssetSetId, "BlackBoard", "Tree")
+
+	var resp assetsResponse
+	callAPI(t, router, "MoveAsset", gin.H{"assetId": assetId, "currentVersion": "stale", "targetAssetSetId": targetAssetSetId}, &resp)
+	expectErrCode(t, "MoveAsset", &resp.apiResponse, common.InvalidAssetVersion)
+	callAPI(t, router, "MoveAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion(t, router, assetId), "targetAssetSetId": otherAssetSetId}, &resp)
+	expectErrCode(t, "MoveAsset", &resp.apiResponse, common.MoveAssetAcrossSolutions)
+	callAPI(t, router, "MoveAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion(t, router, assetId), "targetAssetSetId": targetAssetSetId}, &resp)
+	expectErrCode(t, "MoveAsset", &resp.apiResponse, common.DuplicatedAssetName)
+
+	thirdAssetSetId := createTestAssetSet(t, router, solutionId, "Third")
+	resp = assetsResponse{}
+	callAPI(t, router, "MoveAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion(t, router, assetId), "targetAssetSetId": thirdAssetSetId}, &resp)
+	expectErrCode(t, "MoveAsset", &resp.apiResponse, common.Success)
+	if len(resp.SourceAssetSummaryInfos) != 0 || len(resp.AssetSummaryInfos) != 1 || resp.AssetSummaryInfos[0].AssetId != assetId {
+		t.Fatalf("the asset isn't listed in the target asset set only: %+v %+v", resp.SourceAssetSummaryInfos, resp.AssetSummaryInfos)
+	}
+
+	callAPI(t, router, "RemoveAsset", gin.H{"assetId": assetId, "currentVersion": "stale"}, &resp)
+	expectErrCode(t, "RemoveAsset", &resp.apiResponse, common.InvalidAssetVersion)
+	resp = assetsResponse{}
+	callAPI(t, router, "RemoveAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion(t, router, assetId)}, &resp)
+	expectErrCode(t, "RemoveAsset", &resp.apiResponse, common.Success)
+	if len(resp.AssetSummaryInfos) != 0 {
+		t.Fatalf("the removed asset is still listed: %+v", resp.AssetSummaryInfos)
+	}
+	callAPI(t, router, "ReadAsset", gin.H{"assetId": assetId}, &resp)
+	expectErrCode(t, "ReadAsset", &resp.apiResponse, common.InvalidAsset)
+}
+
+func TestRemoveCascades(t *testing.T) {
+	router := newTestRouter()
+	solutionId := createTestSolution(t, router, "Solution")
+	removedAssetSetId := createTestAssetSet(t, router, solutionId, "Removed")
+	keptAssetSetId := createTestAssetSet(t, router, solutionId, "Kept")
+	removedAssetId := createTestAsset(t, router, removedAssetSetId, "BehaviourTree", "Tree")
+	keptAssetId := createTestAsset(t, router, keptAssetSetId, "BehaviourTree", "Tree")
+
+	//Removing The Asset Set Removes Its Assets Only
+	var assetSetResp assetSetsResponse
+	callAPI(t, router, "RemoveAssetSet", gin.H{"assetSetId": removedAssetSetId, "confirmVersion": "stale"}, &assetSetResp)
+	expectErrCode(t, "RemoveAssetSet", &assetSetResp.apiResponse, common.InvalidSolutionVersion)
+	callAPI(t, router, "RemoveAssetSet", gin.H{"assetSetId": removedAssetSetId, "confirmVersion": solutionVersion(t, router, solutionId)}, &assetSetResp)
+	expectErrCode(t, "RemoveAssetSet", &assetSetResp.apiResponse, common.Success)
+	if len(assetSetResp.AssetSets) != 1 || assetSetResp.AssetSets[0].AssetSetId != keptAssetSetId {
+		t.Fatalf("the removed asset set is still listed: %+v", assetSetResp.AssetSets)
+	}
+
+	var assetResp assetsResponse
+	callAPI(t, router, "ReadAsset", gin.H{"assetId": removedAssetId}, &assetResp)
+	expectErrCode(t, "ReadAsset", &assetResp.apiResponse, common.InvalidAsset)
+	callAPI(t, router, "ReadAsset", gin.H{"assetId": keptAssetId}, &assetResp)
+	expectErrCode(t, "ReadAsset", &assetResp.apiResponse, common.Success)
+
+	//Removing The Solution Removes Everything Under It
+	var solutionResp solutionsResponse
+	callAPI(t, router, "RemoveSolution", gin.H{"solutionId": solutionId, "confirmVersion": solutionVersion(t, router, solutionId)}, &solutionResp)
+	expectErrCode(t, "RemoveSolution", &solutionResp.apiResponse, common.Success)
+	if len(solutionResp.Solutions) != 0 {
+		t.Fatalf("the removed solution is still listed: %+v", solutionResp.Solutions)
+	}
+
+	callAPI(t, router, "ListAssets", gin.H{"assetSetId": keptAssetSetId}, &assetResp)
+	expectErrCode(t, "ListAssets", &assetResp.apiResponse, common.InvalidAssetSet)
+	callAPI(t, router, "ReadAsset", gin.H{"assetId": keptAssetId}, &assetResp)
+	expectErrCode(t, "ReadAsset", &assetResp.apiResponse, common.InvalidAsset)
+}
+
+type settingsSchemasResponse struct {
+	apiResponse
+	SettingsSchema  *common.SettingsSchemaItem  `json:"settingsSchema"`
+	SettingsSchemas []common.SettingsSchemaItem `json:"settingsSchemas"`
+}
+
+func TestSettingsSchemaRegistry(t *testing.T) {
+	router := newTestRouter()
+	solutionId := createTestSolution(t, router, "Solution")
+	registerReq := func(category string, schema string) gin.H {
+		return gin.H{"solutionId": solutionId, "category": category, "typeName": "MoveTo", "schema": json.RawMessage(schema)}
+	}
+
+	var resp settingsSchemasResponse
+	callAPI(t, router, "RegisterSettingsSchema", gin.H{"solutionId": "unknown", "category": "task", "typeName": "MoveTo", "schema": gin.H{}}, &resp)
+	expectErrCode(t, "RegisterSettingsSchema", &resp.apiResponse, common.InvalidSolution)
+	callAPI(t, router, "RegisterSettingsSchema", registerReq("decorator", `{"type":"object"}`), &resp)
+	expectErrCode(t, "RegisterSettingsSchema", &resp.apiResponse, common.InvalidSettingsSchemaCategory)
+	callAPI(t, router, "RegisterSettingsSchema", registerReq("task", `{"$ref":"https://example.com/move-to.json"}`), &resp)
+	expectErrCode(t, "RegisterSettingsSchema", &resp.apiResponse, common.InvalidSettingsSchema)
+
+	callAPI(t, router, "RegisterSettingsSchema", registerReq("task", `{"type":"object"}`), &resp)
+	expectErrCode(t, "RegisterSettingsSchema", &resp.apiResponse, common.Success)
+	schemaId := resp.SettingsSchema.SchemaId
+
+	//Registering The Same Type Again Replaces The Schema
+	resp = settingsSchemasResponse{}
+	callAPI(t, router, "RegisterSettingsSchema", registerReq("task", `{"type":"object","required":["speed"]}`), &resp)
+	expectErrCode(t, "RegisterSettingsSchema", &resp.apiResponse, common.Success)
+	if resp.SettingsSchema.SchemaId != schemaId {
+		t.Fatalf("the schema is registered again as %s instead of replacing %s", resp.SettingsSchema.SchemaId, schemaId)
+	}
+	resp = settingsSchemasResponse{}
+	callAPI(t, router, "ListSettingsSchemas", gin.H{"solutionId": solutionId}, &resp)
+	expectErrCode(t, "ListSettingsSchemas", &resp.apiResponse, common.Success)
+	if len(resp.SettingsSchemas) != 1 || string(resp.SettingsSchemas[0].Schema) != `{"type":"object","required":["speed"]}` {
+		t.Fatalf("the replaced schema isn't listed: %+v", resp.SettingsSchemas)
+	}
+
+	removeReq := gin.H{"solutionId": solutionId, "category": "task", "typeName": "MoveTo"}
+	callAPI(t, router, "RemoveSettingsSchema", removeReq, &resp)
+	expectErrCode(t, "RemoveSettingsSchema", &resp.apiResponse, common.Success)
+	callAPI(t, router, "RemoveSettingsSchema", removeReq, &resp)
+	expectErrCode(t, "RemoveSettingsSchema", &resp.apiResponse, common.UnregisteredSettingsSchema)
+	resp = settingsSchemasResponse{}
+	callAPI(t, router, "ListSettingsSchemas", gin.H{"solutionId": solutionId}, &resp)
+	if len(resp.SettingsSchemas) != 0 {
+		t.Fatalf("the removed schema is listed: %+v", resp.SettingsSchemas)
+	}
+}
+
+func TestRemoveAssetClosesSubscriptions(t *testing.T) {
+	router := newTestRouter()
+	asset_content.InitializeAssetManagement(router.Group("API/AssetContentModifier"))
+	server := httptest.NewServer(router)
+	defer func() {
+		//The Stream Left By A Failure Blocks Closing The Server
+		server.CloseClientConnections()
+		server.Close()
+	}()
+	assetSetId := createTestAssetSet(t, router, createTestSolution(t, router, "Solution"), "Set")
+	assetId := createTestAsset(t, router, assetSetId, "BehaviourTree", "Tree")
+
+	connected, closed := make(chan struct{}), make(chan error, 1)
+	go func() {
+		resp, err := http.Get(server.URL + "/API/AssetContentModifier/SubscribeBehaviourTreeModifications?assetId=" + assetId)
+		if err != nil {
+			closed <- err
+			return
+		}
+		defer resp.Body.Close()
+		close(connected)
+		_, err = io.Copy(io.Discard, resp.Body)
+		closed <- err
+	}()
+
+	//The Stream Starts With The First Event, So The Tree Is Modified Until The Subscription Is Connected
+	for i := 0; ; i++ {
+		if i == 100 {
+			t.Fatal("the subscription isn't connected")
+		}
+		body, err := json.Marshal(gin.H{"assetId": assetId, "currentVersion": assetVersion(t, router, assetId), "nodeType": content_modifier.Node_Sequence, "position": gin.H{"x": i + 1, "y": 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		modificationResp, err := http.Post(server.URL+"/API/AssetContentModifier/CreateBehaviourTreeNode", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var modificationBody apiResponse
+		err = json.NewDecoder(modificationResp.Body).Decode(&modificationBody)
+		modificationResp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectErrCode(t, "CreateBehaviourTreeNode", &modificationBody, common.Success)
+
+		select {
+		case <-connected:
+		case err = <-closed:
+			t.Fatalf("the subscription is closed before removing the asset: %v", err)
+		case <-time.After(20 * time.Millisecond):
+			continue
+		}
+		break
+	}
+
+	var resp assetsResponse
+	callAPI(t, router, "RemoveAsset", gin.H{"assetId": assetId, "currentVersion": assetVersion(t, router, assetId)}, &resp)
+	expectErrCode(t, "RemoveAsset", &resp.apiResponse, common.Success)
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription of the removed asset isn't closed")
+	}
+}
+
+func TestReorderSolutionsAndAssetSets(t *testing.T) {
+	router := newTestRouter()
+	firstId := createTestSolution(t, router, "First")
+	secondId := createTestSolution(t, router, "Second")
+	thirdId := createTestSolution(t, router, "Third")
+
+	//Every Solution Must Be Listed Once
+	var solutionResp solutionsResponse
+	for _, orderedIds := range [][]string{{thirdId, firstId}, {thirdId, firstId, secondId, secondId}, {thirdId, firstId, "unknown"}} {
+		callAPI(t, router, "ReorderSolutions", gin.H{"orderedSolutionIds": orderedIds}, &solutionResp)
+		expectErrCode(t, "ReorderSolutions", &solutionResp.apiResponse, common.ReorderSolutionsMismatch)
+	}
+	callAPI(t, router, "ReorderSolutions", gin.H{"orderedSolutionIds": []string{thirdId, firstId, secondId}}, &solutionResp)
+	expectErrCode(t, "ReorderSolutions", &solutionResp.apiResponse, common.Success)
+	solutionResp = solutionsResponse{}
+	callAPI(t, router, "ListSolutions", nil, &solutionResp)
+	if len(solutionResp.Solutions) != 3 || solutionResp.Solutions[0].SolutionId != thirdId || solutionResp.Solutions[1].SolutionId != firstId || solutionResp.Solutions[2].SolutionId != secondId {
+		t.Fatalf("the solutions aren't listed in the new order: %+v", solutionResp.Solutions)
+	}
+
+	firstSetId := createTestAssetSet(t, router, firstId, "First")
+	secondSetId := createTestAssetSet(t, router, firstId, "Second")
+	otherSetId := createTestAssetSet(t, router, secondId, "Other")
+
+	//Every Asset Set Of The Solution Must Be Listed Once, The Ones Of Other Solutions Are Refused
+	var assetSetResp assetSetsResponse
+	for _, orderedIds := range [][]string{{secondSetId}, {secondSetId, firstSetId, firstSetId}, {secondSetId, otherSetId}} {
+		callAPI(t, router, "ReorderAssetSets", gin.H{"solutionId": firstId, "orderedAssetSetIds": orderedIds}, &assetSetResp)
+		expectErrCode(t, "ReorderAssetSets", &assetSetResp.apiResponse, common.ReorderAssetSetsMismatch)
+	}
+	callAPI(t, router, "ReorderAssetSets", gin.H{"solutionId": firstId, "orderedAssetSetIds": []string{secondSetId, firstSetId}}, &assetSetResp)
+	expectErrCode(t, "ReorderAssetSets", &assetSetResp.apiResponse, common.Success)
+	assetSetResp = assetSetsResponse{}
+	callAPI(t, router, "ListAssetSets", gin.H{"solutionId": firstId}, &assetSetResp)
+	if len(assetSetResp.AssetSets) != 2 || assetSetResp.AssetSets[0].AssetSetId != secondSetId || assetSetResp.AssetSets[1].AssetSetId != firstSetId {
+		t.Fatalf("the asset sets aren't listed in the new order: %+v", assetSetResp.AssetSets)
+	}
+
+	//The New Asset Set Is Appended
+	thirdSetId := createTestAssetSet(t, router, firstId, "Third")
+	assetSetResp = assetSetsResponse{}
+	callAPI(t, router, "ListAssetSets", gin.H{"solutionId": firstId}, &assetSetResp)
+	if len(assetSetResp.AssetSets) != 3 || assetSetResp.AssetSets[0].AssetSetId != secondSetId || assetSetResp.AssetSets[2].AssetSetId != thirdSetId {
+		t.Fatalf("the new asset set isn't appended: %+v", assetSetResp.AssetSets)
+	}
+}
@@ -0,0 +1,178 @@
+package asset_organization
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxponline/messy-monster-ai-editor/asset_content/content_modifier"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"github.com/xxponline/messy-monster-ai-editor/db"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type RegisterSettingsSchemaReq struct {
+	SolutionId string          `json:"solutionId" binding:"required"`
+	Category   string          `json:"category" binding:"required"`
+	TypeName   string          `json:"typeName" binding:"required"`
+	Schema     json.RawMessage `json:"schema" binding:"required"`
+}
+
+type RemoveSettingsSchemaReq struct {
+	SolutionId string `json:"solutionId" binding:"required"`
+	Category   string `json:"category" binding:"required"`
+	TypeName   string `json:"typeName" binding:"required"`
+}
+
+type ListSettingsSchemasReq struct {
+	SolutionId string `json:"solutionId" binding:"required"`
+}
+
+// RegisterSettingsSchemaAPI registers the schema of a type, the exist one of the same type is replaced
+func RegisterSettingsSchemaAPI(context *gin.Context) {
+	var req RegisterSettingsSchemaReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	// Schema Checking Pass
+	if !content_modifier.IsValidSettingsSchemaCategory(req.Category) {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidSettingsSchemaCategory,
+			"errMessage": common.InvalidSettingsSchemaCategory.GetMsgFormat(req.Category),
+		})
+		return
+	}
+	errCode, errMsg, _ := content_modifier.CompileSettingsSchema(string(req.Schema))
+	if errCode != common.Success {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    errCode,
+			"errMessage": errMsg,
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		// Solution Checking Pass
+		_, err := querySolutionPass(context, repo, req.SolutionId)
+		if err != nil {
+			return err
+		}
+
+		// Upsert Pass
+		var schemaItem common.SettingsSchemaItem
+		{
+			var existItem *common.SettingsSchemaItem
+			existItem, err = repo.GetSettingsSchema(req.SolutionId, req.Category, req.TypeName)
+			if err == nil || errors.Is(err, db.ErrRecordNotFound) {
+				if err == nil {
+					schemaItem = *existItem
+				} else {
+					schemaItem = common.SettingsSchemaItem{SchemaId: uuid.New().String(), SolutionId: req.SolutionId, Category: req.Category, TypeName: req.TypeName}
+				}
+				schemaItem.Schema = req.Schema
+				schemaItem.UpdatedAt = time.Now().UnixMilli()
+				err = repo.SaveSettingsSchema(&schemaItem)
+			}
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		// All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":        common.Success,
+			"errMessage":     "",
+			"settingsSchema": schemaItem,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+func RemoveSettingsSchemaAPI(context *gin.Context) {
+	var req RemoveSettingsSchemaReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	_, err = querySolutionPass(context, db.Storage, req.SolutionId)
+	if err != nil {
+		zap.S().Error(err)
+		return
+	}
+
+	deleted, err := db.Storage.DeleteSettingsSchema(req.SolutionId, req.Category, req.TypeName)
+	if err != nil {
+		zap.S().Error(err)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.UnregisteredSettingsSchema,
+			"errMessage": common.UnregisteredSettingsSchema.GetMsgFormat(req.Category, req.TypeName),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":    common.Success,
+		"errMessage": "",
+	})
+}
+
+func ListSettingsSchemasAPI(context *gin.Context) {
+	var req ListSettingsSchemasReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	_, err = querySolutionPass(context, db.Storage, req.SolutionId)
+	if err != nil {
+		zap.S().Error(err)
+		return
+	}
+
+	schemaItems, err := db.Storage.ListSettingsSchemas(req.SolutionId)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":         common.Success,
+		"errMessage":      "",
+		"settingsSchemas": schemaItems,
+	})
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/xxponline/messy-monster-ai-editor/common"
 	"github.com/xxponline/messy-monster-ai-editor/db"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
+	"golang.org/x/exp/slices"
 	"net/http"
 )
 
@@ -27,13 +27,28 @@ type GetSolutionDetailReq struct {
 	SolutionId string `json:"solutionId" binding:"required"`
 }
 
+type RenameSolutionReq struct {
+	SolutionId     string `json:"solutionId" binding:"required"`
+	CurrentVersion string `json:"currentVersion" binding:"required"`
+	NewName        string `json:"newName" binding:"required"`
+}
+
+type ReorderSolutionsReq struct {
+	OrderedSolutionIds []string `json:"orderedSolutionIds" binding:"required"` // all the solutions in the new order
+}
+
+type RemoveSolutionReq struct {
+	SolutionId     string `json:"solutionId" binding:"required"`
+	ConfirmVersion string `json:"confirmVersion" binding:"required"` // must be the current solution version
+}
+
 func ListSolutionsAPI(context *gin.Context) {
 
 	var errCode common.ErrorCode
 	var errMsg string
 	var solutionInfos []common.SolutionSummaryInfoItem
 
-	err := db.GormDatabase.Find(&solutionInfos).Error
+	solutionInfos, err := db.Storage.ListSolutions()
 	if err == nil {
 		context.JSON(http.StatusOK, gin.H{
 			"errCode":    errCode,
@@ -67,38 +82,38 @@ func CreateSolutionAPI(context *gin.Context) {
 		return
 	}
 
-	err = db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var err error
+	err = db.Storage.Transaction(func(repo db.Repository) error {
 		// Duplicated Name Checking Pass
+		err := checkDuplicatedSolutionNamePass(context, repo, req.SolutionName, "")
+		if err != nil {
+			return err
+		}
+
+		//Create New Solution Pass, The New One Is Placed Last
+		newSolutionId := uuid.New().String()
 		{
-			var count int64
-			tx.Model(&common.SolutionSummaryInfoItem{}).Where("solutionName = ?", req.SolutionName).Count(&count)
-			if count > 0 {
-				errInfo := common.DuplicatedSolutionName.GetMsgFormat(req.SolutionName)
+			existSolutionInfos, err := repo.ListSolutions()
+			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.DuplicatedSolutionName,
-					"errMessage": errInfo,
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
 				})
-				return errors.New(errInfo)
+				return err
 			}
-		}
 
-		//Create New Solution Pass
-		newSolutionId := uuid.New().String()
-		{
 			newSolutionItem := common.SolutionDetailInfo{
 				SolutionId:      newSolutionId,
 				SolutionName:    req.SolutionName,
 				SolutionVersion: uuid.New().String(),
 				SolutionMeta:    json.RawMessage("{}"),
 			}
+			if len(existSolutionInfos) > 0 {
+				newSolutionItem.SortOrder = existSolutionInfos[len(existSolutionInfos)-1].SortOrder + 1
+			}
 
-			err = tx.Create(&newSolutionItem).Error
+			err = repo.CreateSolution(&newSolutionItem)
 			if err != nil {
-				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.DataBaseError,
-					"errMessage": err.Error(),
-				})
+				respondSavingError(context, err, common.DuplicatedSolutionName, req.SolutionName)
 				return err
 			}
 		}
@@ -106,7 +121,8 @@ func CreateSolutionAPI(context *gin.Context) {
 		//Query And Response Pass
 		var solutionInfos []common.SolutionSummaryInfoItem
 		{
-			err := tx.Find(&solutionInfos).Error
+			var err error
+			solutionInfos, err = repo.ListSolutions()
 			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
 					"errCode":    common.DataBaseError,
@@ -143,37 +159,17 @@ func SubmitSolutionMetaAPI(context *gin.Context) {
 		return
 	}
 
-	err = db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var err error
-		// Query exist solution item pass
-		var existSolutionItem common.SolutionDetailInfo
-		{
-			err = tx.Find(&existSolutionItem, "id = ?", req.SolutionId).Error
-			if err != nil {
-				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.InvalidSolution,
-					"errMessage": err.Error(),
-				})
-				return err
-			}
-		}
-
+	err = db.Storage.Transaction(func(repo db.Repository) error {
 		//Version checking pass
-		{
-			if existSolutionItem.SolutionVersion != req.CurrentVersion {
-				err = errors.New(common.InvalidSolutionVersion.GetMsgFormat(existSolutionItem.SolutionVersion, req.CurrentVersion))
-				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.InvalidSolutionVersion,
-					"errMessage": err.Error(),
-				})
-				return err
-			}
+		existSolutionItem, err := checkSolutionVersionPass(context, repo, req.SolutionId, req.CurrentVersion)
+		if err != nil {
+			return err
 		}
 
 		//Update pass
 		{
 			existSolutionItem.SolutionMeta = req.SolutionMeta
-			err = db.GormDatabase.Save(&existSolutionItem).Error
+			err = repo.SaveSolution(existSolutionItem)
 			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
 					"errCode":    common.DataBaseError,
@@ -210,15 +206,161 @@ func GetSolutionDetailAPI(context *gin.Context) {
 		return
 	}
 
-	err = db.GormDatabase.Transaction(func(tx *gorm.DB) error {
-		var err error
+	err = db.Storage.Transaction(func(repo db.Repository) error {
 		// Query exist solution item pass
-		var existSolutionItem common.SolutionDetailInfo
+		existSolutionItem, err := querySolutionPass(context, repo, req.SolutionId)
+		if err != nil {
+			return err
+		}
+
+		//All Done
 		{
-			err = tx.Find(&existSolutionItem, "id = ?", req.SolutionId).Error
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":        common.Success,
+				"errMessage":     "",
+				"solutionDetail": existSolutionItem,
+			})
+		}
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+// querySolutionPass queries the solution, the error response is written when failed
+func querySolutionPass(context *gin.Context, repo db.Repository, solutionId string) (*common.SolutionDetailInfo, error) {
+	existSolutionItem, err := repo.GetSolution(solutionId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		errMsg := common.InvalidSolution.GetMsgFormat(solutionId)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidSolution,
+			"errMessage": errMsg,
+		})
+		return nil, errors.New(errMsg)
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return nil, err
+	}
+	return existSolutionItem, nil
+}
+
+// checkSolutionVersionPass queries the solution for update and checks the version of the request, the error response is written when failed.
+// the solution is locked until the transaction ends, so the concurrent modifications of the same version can't both pass
+func checkSolutionVersionPass(context *gin.Context, repo db.Repository, solutionId string, currentVersion string) (*common.SolutionDetailInfo, error) {
+	existSolutionItem, err := repo.GetSolutionForUpdate(solutionId)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		errMsg := common.InvalidSolution.GetMsgFormat(solutionId)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidSolution,
+			"errMessage": errMsg,
+		})
+		return nil, errors.New(errMsg)
+	}
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return nil, err
+	}
+
+	if existSolutionItem.SolutionVersion != currentVersion {
+		errMsg := common.InvalidSolutionVersion.GetMsgFormat(existSolutionItem.SolutionVersion, currentVersion)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.InvalidSolutionVersion,
+			"errMessage": errMsg,
+		})
+		return nil, errors.New(errMsg)
+	}
+	return existSolutionItem, nil
+}
+
+// checkDuplicatedSolutionNamePass checks the name isn't used by the other solutions, the error response is written when failed
+func checkDuplicatedSolutionNamePass(context *gin.Context, repo db.Repository, solutionName string, excludedSolutionId string) error {
+	count, err := repo.CountSolutionsByName(solutionName, excludedSolutionId)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DataBaseError,
+			"errMessage": err.Error(),
+		})
+		return err
+	}
+	if count > 0 {
+		errMsg := common.DuplicatedSolutionName.GetMsgFormat(solutionName)
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.DuplicatedSolutionName,
+			"errMessage": errMsg,
+		})
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+// respondSavingError writes the error response of creating or saving a named record,
+// the name taken by a concurrent request after the checking is reported by the unique index as the duplicated name
+func respondSavingError(context *gin.Context, err error, duplicatedNameCode common.ErrorCode, name string) {
+	if errors.Is(err, db.ErrDuplicatedName) {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    duplicatedNameCode,
+			"errMessage": duplicatedNameCode.GetMsgFormat(name),
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"errCode":    common.DataBaseError,
+		"errMessage": err.Error(),
+	})
+}
+
+func RenameSolutionAPI(context *gin.Context) {
+	var req RenameSolutionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Version Checking Pass
+		existSolutionItem, err := checkSolutionVersionPass(context, repo, req.SolutionId, req.CurrentVersion)
+		if err != nil {
+			return err
+		}
+
+		// Duplicated Name Checking Pass
+		err = checkDuplicatedSolutionNamePass(context, repo, req.NewName, req.SolutionId)
+		if err != nil {
+			return err
+		}
+
+		//Update Pass
+		{
+			existSolutionItem.SolutionName = req.NewName
+			existSolutionItem.SolutionVersion = uuid.New().String()
+			err = repo.SaveSolution(existSolutionItem)
+			if err != nil {
+				respondSavingError(context, err, common.DuplicatedSolutionName, req.NewName)
+				return err
+			}
+		}
+
+		//Query And Response Pass
+		var solutionInfos []common.SolutionSummaryInfoItem
+		{
+			var err error
+			solutionInfos, err = repo.ListSolutions()
 			if err != nil {
 				context.JSON(http.StatusOK, gin.H{
-					"errCode":    common.InvalidSolution,
+					"errCode":    common.DataBaseError,
 					"errMessage": err.Error(),
 				})
 				return err
@@ -226,13 +368,149 @@ func GetSolutionDetailAPI(context *gin.Context) {
 		}
 
 		//All Done
-		{
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":        common.Success,
+			"errMessage":     "",
+			"solutions":      solutionInfos,
+			"solutionDetail": existSolutionItem,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+// ReorderSolutionsAPI arranges the order of listing the solutions, the versions of the solutions are kept since nothing in them is modified
+func ReorderSolutionsAPI(context *gin.Context) {
+	var req ReorderSolutionsReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Mismatch Checking Pass
+		existSolutionInfos, err := repo.ListSolutions()
+		if err != nil {
 			context.JSON(http.StatusOK, gin.H{
-				"errCode":        common.Success,
-				"errMessage":     "",
-				"solutionDetail": existSolutionItem,
+				"errCode":    common.DataBaseError,
+				"errMessage": err.Error(),
 			})
+			return err
+		}
+		mismatched := len(existSolutionInfos) != len(req.OrderedSolutionIds) || slices.ContainsFunc(existSolutionInfos, func(solutionInfo common.SolutionSummaryInfoItem) bool {
+			return !slices.Contains(req.OrderedSolutionIds, solutionInfo.SolutionId)
+		})
+		if mismatched {
+			errMsg := common.ReorderSolutionsMismatch.GetMsg()
+			context.JSON(http.StatusOK, gin.H{
+				"errCode":    common.ReorderSolutionsMismatch,
+				"errMessage": errMsg,
+			})
+			return errors.New(errMsg)
+		}
+
+		//Update Pass
+		for _, solutionInfo := range existSolutionInfos {
+			sortOrder := slices.Index(req.OrderedSolutionIds, solutionInfo.SolutionId)
+			if solutionInfo.SortOrder == sortOrder {
+				continue
+			}
+			err = repo.UpdateSolutionSortOrder(solutionInfo.SolutionId, sortOrder)
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//Query And Response Pass
+		var solutionInfos []common.SolutionSummaryInfoItem
+		{
+			var err error
+			solutionInfos, err = repo.ListSolutions()
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.Success,
+			"errMessage": "",
+			"solutions":  solutionInfos,
+		})
+		return nil
+	})
+
+	if err != nil {
+		zap.S().Error(err)
+	}
+}
+
+// RemoveSolutionAPI deletes the solution, all of its asset sets, assets and settings schemas are deleted by the foreign keys
+func RemoveSolutionAPI(context *gin.Context) {
+	var req RemoveSolutionReq
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.RequestBindError,
+			"errMessage": err.Error(),
+		})
+		return
+	}
+
+	err = db.Storage.Transaction(func(repo db.Repository) error {
+		//Confirmation Checking Pass
+		_, err := checkSolutionVersionPass(context, repo, req.SolutionId, req.ConfirmVersion)
+		if err != nil {
+			return err
+		}
+
+		//Cascade Deleting Pass
+		{
+			err = repo.DeleteSolution(req.SolutionId)
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
+		}
+
+		//Query And Response Pass
+		var solutionInfos []common.SolutionSummaryInfoItem
+		{
+			var err error
+			solutionInfos, err = repo.ListSolutions()
+			if err != nil {
+				context.JSON(http.StatusOK, gin.H{
+					"errCode":    common.DataBaseError,
+					"errMessage": err.Error(),
+				})
+				return err
+			}
 		}
+
+		//All Done
+		context.JSON(http.StatusOK, gin.H{
+			"errCode":    common.Success,
+			"errMessage": "",
+			"solutions":  solutionInfos,
+		})
 		return nil
 	})
 
@@ -13,6 +13,7 @@ type SolutionSummaryInfoItem struct {
 	SolutionId      string `json:"solutionId" binding:"required" gorm:"column:id;primaryKey"`
 	SolutionName    string `json:"solutionName" binding:"required" gorm:"column:solutionName"`
 	SolutionVersion string `json:"solutionVersion" binding:"required" gorm:"column:solutionVersion"`
+	SortOrder       int    `json:"sortOrder" binding:"required" gorm:"column:sortOrder"`
 }
 
 func (SolutionSummaryInfoItem) TableName() string {
@@ -44,6 +45,7 @@ type SolutionDetailInfo struct {
 	SolutionName    string          `json:"solutionName" binding:"required" gorm:"column:solutionName"`
 	SolutionVersion string          `json:"solutionVersion" binding:"required" gorm:"column:solutionVersion"`
 	SolutionMeta    json.RawMessage `json:"solutionMeta" binding:"required" gorm:"column:solutionMeta"`
+	SortOrder       int             `json:"sortOrder" binding:"required" gorm:"column:sortOrder"` // the solutions are listed in the ascending order
 }
 
 func (SolutionDetailInfo) TableName() string {
@@ -58,6 +60,7 @@ type AssetSetInfoItem struct {
 	AssetSetId   string `json:"assetSetId" binding:"required" gorm:"column:id;primaryKey"`
 	SolutionId   string `json:"solutionId" binding:"required" gorm:"column:solutionId"`
 	AssetSetName string `json:"assetSetName" binding:"required" gorm:"column:assetSetName"`
+	SortOrder    int    `json:"sortOrder" binding:"required" gorm:"column:sortOrder"` // the asset sets of a solution are listed in the ascending order
 }
 
 func (AssetSetInfoItem) TableName() string {
@@ -114,3 +117,85 @@ func (AssetDetailInfo) TableName() string {
 }
 
 //end of the AssetDetailInfo
+
+//start of the AssetModificationHistoryItem
+
+const (
+	HistoryOperation_Modify = "modify"
+	HistoryOperation_Undo   = "undo"
+	HistoryOperation_Redo   = "redo"
+
+	// UndoState is only meaningful for the "modify" records
+	HistoryUndoState_Applied   = "applied"
+	HistoryUndoState_Undone    = "undone"
+	HistoryUndoState_Discarded = "discarded" // the undone record is discarded by a new modification, or any record is discarded by restoring a version, it's no longer able to be undone or redone
+)
+
+type AssetModificationHistoryItem struct {
+	HistoryId       uint   `json:"historyId" binding:"required" gorm:"column:id;primaryKey;autoIncrement"`
+	AssetId         string `json:"assetId" binding:"required" gorm:"column:assetId"`
+	PrevVersion     string `json:"prevVersion" binding:"required" gorm:"column:prevVersion"`
+	NewVersion      string `json:"newVersion" binding:"required" gorm:"column:newVersion"`
+	Operation       string `json:"operation" binding:"required" gorm:"column:operation"`
+	UndoState       string `json:"undoState" binding:"required" gorm:"column:undoState"`
+	TargetHistoryId uint   `json:"targetHistoryId" binding:"required" gorm:"column:targetHistoryId"` // the record which is undone or redone
+	DiffInfos       string `json:"diffInfos" binding:"required" gorm:"column:diffInfos"`
+	CreatedAt       int64  `json:"createdAt" binding:"required" gorm:"column:createdAt"`
+}
+
+func (AssetModificationHistoryItem) TableName() string {
+	return "ai_asset_modification_histories"
+}
+
+//end of the AssetModificationHistoryItem
+
+//start of the SettingsSchemaItem
+
+type SettingsSchemaItem struct {
+	SchemaId   string          `json:"schemaId" binding:"required" gorm:"column:id;primaryKey"`
+	SolutionId string          `json:"solutionId" binding:"required" gorm:"column:solutionId"`
+	Category   string          `json:"category" binding:"required" gorm:"column:category"` // node, task, descriptor or service
+	TypeName   string          `json:"typeName" binding:"required" gorm:"column:typeName"`
+	Schema     json.RawMessage `json:"schema" binding:"required" gorm:"column:schema"`
+	UpdatedAt  int64           `json:"updatedAt" binding:"required" gorm:"column:updatedAt;autoUpdateTime:milli"`
+}
+
+func (SettingsSchemaItem) TableName() string {
+	return "ai_settings_schemas"
+}
+
+//end of the SettingsSchemaItem
+
+//start of the AssetVersionItem
+
+// AssetVersionSummaryItem is a committed version of the asset without the content, the versions are never modified
+type AssetVersionSummaryItem struct {
+	VersionId           uint   `json:"versionId" binding:"required" gorm:"column:id;primaryKey;autoIncrement"`
+	AssetId             string `json:"assetId" binding:"required" gorm:"column:assetId"`
+	AssetVersion        string `json:"assetVersion" binding:"required" gorm:"column:assetVersion"`
+	PrevVersion         string `json:"prevVersion" binding:"required" gorm:"column:prevVersion"`                 // empty for the creation of the asset
+	RestoredFromVersion string `json:"restoredFromVersion" binding:"required" gorm:"column:restoredFromVersion"` // the version restored by this one, empty for the modification
+	Author              string `json:"author" binding:"required" gorm:"column:author"`                           // empty when the editor didn't tell
+	CreatedAt           int64  `json:"createdAt" binding:"required" gorm:"column:createdAt"`
+}
+
+func (AssetVersionSummaryItem) TableName() string {
+	return "ai_asset_versions"
+}
+
+type AssetVersionDetailInfo struct {
+	VersionId           uint   `json:"versionId" binding:"required" gorm:"column:id;primaryKey;autoIncrement"`
+	AssetId             string `json:"assetId" binding:"required" gorm:"column:assetId"`
+	AssetVersion        string `json:"assetVersion" binding:"required" gorm:"column:assetVersion"`
+	PrevVersion         string `json:"prevVersion" binding:"required" gorm:"column:prevVersion"`
+	RestoredFromVersion string `json:"restoredFromVersion" binding:"required" gorm:"column:restoredFromVersion"`
+	Author              string `json:"author" binding:"required" gorm:"column:author"`
+	CreatedAt           int64  `json:"createdAt" binding:"required" gorm:"column:createdAt"`
+	AssetContent        string `json:"assetContent" binding:"required" gorm:"column:assetContent"`
+}
+
+func (AssetVersionDetailInfo) TableName() string {
+	return "ai_asset_versions"
+}
+
+//end of the AssetVersionItem
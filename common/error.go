@@ -15,23 +15,34 @@ const (
 
 	//Asset Organization Error
 
-	DuplicatedSolutionName ErrorCode = 20001
-	InvalidSolution        ErrorCode = 20002
-	InvalidSolutionVersion ErrorCode = 20003
+	DuplicatedSolutionName   ErrorCode = 20001
+	InvalidSolution          ErrorCode = 20002
+	InvalidSolutionVersion   ErrorCode = 20003
+	ReorderSolutionsMismatch ErrorCode = 20004
 
-	DuplicatedAssetSetName ErrorCode = 20010
-	InvalidAssetSet        ErrorCode = 20011
-	DuplicatedAssetName    ErrorCode = 20021
-	InvalidAssetType       ErrorCode = 20022
+	DuplicatedAssetSetName   ErrorCode = 20010
+	InvalidAssetSet          ErrorCode = 20011
+	ReorderAssetSetsMismatch ErrorCode = 20012
+	DuplicatedAssetName      ErrorCode = 20021
+	InvalidAssetType         ErrorCode = 20022
+	InvalidAsset             ErrorCode = 20023
+	MoveAssetAcrossSolutions ErrorCode = 20024
 
 	ArchiveAssetsInvalidAssetType  ErrorCode = 20031
 	ArchiveAssetsUnexpectAssetType ErrorCode = 20032
 
+	InvalidSettingsSchemaCategory ErrorCode = 20040
+	InvalidSettingsSchema         ErrorCode = 20041
+	UnregisteredSettingsSchema    ErrorCode = 20042
+
 	//Common Content
 
-	InvalidAssetVersion  ErrorCode = 30001
-	DeserializationError ErrorCode = 30010
-	SerializationError   ErrorCode = 30011
+	InvalidAssetVersion         ErrorCode = 30001
+	ConflictedAssetModification ErrorCode = 30002
+	MismatchedAssetType         ErrorCode = 30003
+	UnrecordedAssetVersion      ErrorCode = 30004
+	DeserializationError        ErrorCode = 30010
+	SerializationError          ErrorCode = 30011
 
 	//Behaviour Tree Content
 
@@ -44,27 +55,133 @@ const (
 	BtConnectInvalidRootForChild         ErrorCode = 31032
 	BtConnectInvalidTaskForParent        ErrorCode = 31033
 	BtInvalidDisconnectNodeWithoutParent ErrorCode = 31034
+	BtConnectCycleDetected               ErrorCode = 31035
+	BtConnectInvalidRunSubtreeForParent  ErrorCode = 31036
 
 	BtGetNodeInvalidNodeId        ErrorCode = 310040
 	BtUpdateSettingsInvalidNodeId ErrorCode = 310041
+
+	BtValidationFailed                 ErrorCode = 31050
+	BtValidateCycleDetected            ErrorCode = 31051
+	BtValidateUnknownParent            ErrorCode = 31052
+	BtValidateDuplicatedNodeId         ErrorCode = 31053
+	BtValidateCompositeWithoutChildren ErrorCode = 31054
+	BtValidateRootWithMultipleChildren ErrorCode = 31055
+	BtValidateInvalidRootCount         ErrorCode = 31056
+	BtValidateUnknownAttachTarget      ErrorCode = 31057
+
+	BtDescriptorInvalidAttachTarget ErrorCode = 31060
+	BtDescriptorIllegalAttachToRoot ErrorCode = 31061
+	BtDescriptorInvalidDescriptorId ErrorCode = 31062
+	BtReorderDescriptorsMismatch    ErrorCode = 31063
+
+	BtServiceInvalidAttachTarget ErrorCode = 31070
+	BtServiceIllegalAttachTarget ErrorCode = 31071
+	BtServiceInvalidServiceId    ErrorCode = 31072
+	BtReorderServicesMismatch    ErrorCode = 31073
+	BtServiceInvalidTickInterval ErrorCode = 31074
+
+	BtSimpleParallelInvalidFinishMode  ErrorCode = 31080
+	BtSimpleParallelTooManyChildren    ErrorCode = 31081
+	BtSimpleParallelInvalidMainTask    ErrorCode = 31082
+	BtValidateSimpleParallelIncomplete ErrorCode = 31083
+
+	BtHistoryNothingToUndo ErrorCode = 31090
+	BtHistoryNothingToRedo ErrorCode = 31091
+	BtHistoryConflict      ErrorCode = 31092
+
+	BtBatchInvalidOperation    ErrorCode = 31100
+	BtBatchUnknownReference    ErrorCode = 31101
+	BtBatchDuplicatedReference ErrorCode = 31102
+	BtBatchOperationFailed     ErrorCode = 31103
+
+	BtDuplicateInvalidNodeId            ErrorCode = 31110
+	BtDuplicateIllegalRoot              ErrorCode = 31111
+	BtDuplicateInvalidSourceAsset       ErrorCode = 31112
+	BtDuplicateSourceAssetOutOfSolution ErrorCode = 31113
+
+	BtRunSubtreeInvalidSettings      ErrorCode = 31120
+	BtValidateRunSubtreeWithoutAsset ErrorCode = 31121
+	BtValidateInvalidSubtreeAsset    ErrorCode = 31122
+	BtValidateSubtreeRecursion       ErrorCode = 31123
+
+	BtSettingsNotObject       ErrorCode = 31130
+	BtSettingsReservedKey     ErrorCode = 31131
+	BtSettingsSchemaViolation ErrorCode = 31132
+
+	BtLayoutInvalidOrientation ErrorCode = 31140
+	BtLayoutInvalidSpacing     ErrorCode = 31141
+
+	BtSimulationInvalidTicks      ErrorCode = 31150
+	BtSimulationInvalidTaskResult ErrorCode = 31151
+	BtSimulationInvalidCondition  ErrorCode = 31152
+
+	BtValidateInvalidBlackBoardAsset      ErrorCode = 31160
+	BtValidateUnknownBlackBoardKey        ErrorCode = 31161
+	BtValidateIncompatibleBlackBoardKey   ErrorCode = 31162
+	BtValidateIncompatibleBlackBoardValue ErrorCode = 31163
+
+	BbInvalidKeyName      ErrorCode = 32001
+	BbDuplicatedKeyName   ErrorCode = 32002
+	BbInvalidKeyType      ErrorCode = 32003
+	BbInvalidEnumValues   ErrorCode = 32004
+	BbInvalidDefaultValue ErrorCode = 32005
+	BbInvalidKey          ErrorCode = 32006
+
+	GoapInvalidFactName     ErrorCode = 33001
+	GoapDuplicatedFactName  ErrorCode = 33002
+	GoapInvalidFactType     ErrorCode = 33003
+	GoapInvalidEnumValues   ErrorCode = 33004
+	GoapInvalidDefaultValue ErrorCode = 33005
+	GoapInvalidFact         ErrorCode = 33006
+	GoapFactInUse           ErrorCode = 33007
+
+	GoapInvalidActionName    ErrorCode = 33010
+	GoapDuplicatedActionName ErrorCode = 33011
+	GoapInvalidActionId      ErrorCode = 33012
+	GoapInvalidActionCost    ErrorCode = 33013
+
+	GoapInvalidGoalName    ErrorCode = 33020
+	GoapDuplicatedGoalName ErrorCode = 33021
+	GoapInvalidGoalId      ErrorCode = 33022
+
+	GoapInvalidCondition ErrorCode = 33030
+	GoapInvalidEffect    ErrorCode = 33031
+
+	GoapPlanNotFound              ErrorCode = 33040
+	GoapPlanExpansionLimitReached ErrorCode = 33041
+	GoapPlanInvalidMaxExpansions  ErrorCode = 33042
+	GoapPlanInvalidInitialState   ErrorCode = 33043
+	GoapPlanInvalidGoal           ErrorCode = 33044
 )
 
 var errorMsg = map[ErrorCode]string{
-	InvalidSolution:        "Invalid Solution : SolutionId %s ",
-	DuplicatedSolutionName: "Duplicated Solution Name %s ",
-	InvalidSolutionVersion: "Invalid Solution Version For Modification Exist Version: %s Request Version: %s",
+	InvalidSolution:          "Invalid Solution : SolutionId %s ",
+	DuplicatedSolutionName:   "Duplicated Solution Name %s ",
+	InvalidSolutionVersion:   "Invalid Solution Version For Modification Exist Version: %s Request Version: %s",
+	ReorderSolutionsMismatch: "The Solution Ids For Reordering Are Mismatched With The Exist Solutions",
 
-	DuplicatedAssetSetName: "Duplicated AssetSet Name %s",
-	InvalidAssetSet:        "Invalid AssetSet : AssetSet Id %s ",
-	DuplicatedAssetName:    "Duplicated Asset Name: %s",
-	InvalidAssetType:       "Invalid Asset Type: %s",
+	DuplicatedAssetSetName:   "Duplicated AssetSet Name %s",
+	InvalidAssetSet:          "Invalid AssetSet : AssetSet Id %s ",
+	ReorderAssetSetsMismatch: "The AssetSet Ids For Reordering Are Mismatched With The AssetSets Of Solution Id: %s",
+	DuplicatedAssetName:      "Duplicated Asset Name: %s",
+	InvalidAssetType:         "Invalid Asset Type: %s",
+	InvalidAsset:             "Invalid Asset : Asset Id %s ",
+	MoveAssetAcrossSolutions: "Moving Asset To AssetSet Id: %s Of Another Solution Is Illegal",
 
 	ArchiveAssetsInvalidAssetType:  "Invalid Asset Type %s When Archive Asset Set",
 	ArchiveAssetsUnexpectAssetType: "Unexpect Asset Type %s When Archive Asset Set The Expectation Is %s",
 
-	InvalidAssetVersion:  "Invalid Asset Version For Modification Exist Version: %s Request Version: %s",
-	DeserializationError: "Deserialization Error",
-	SerializationError:   "Serialization Error",
+	InvalidSettingsSchemaCategory: "Invalid Settings Schema Category: %s, Only node, task, descriptor Or service Is Acceptable",
+	InvalidSettingsSchema:         "Invalid Settings Schema: %s",
+	UnregisteredSettingsSchema:    "There Is No Settings Schema Registered For Category: %s Type: %s",
+
+	InvalidAssetVersion:         "Invalid Asset Version For Modification Exist Version: %s Request Version: %s",
+	ConflictedAssetModification: "The Modification Based On Version: %s Conflicts With The Modifications Until Version: %s",
+	MismatchedAssetType:         "Asset Id: %s Is A %s, The Modification Is Only For %s",
+	UnrecordedAssetVersion:      "Asset Id: %s Has No Recorded Version: %s",
+	DeserializationError:        "Deserialization Error",
+	SerializationError:          "Serialization Error",
 
 	BtInvalidNodeType:               "Invalid Behaviour Tree Node Type: %s",
 	BtIllegalRemoveRoot:             "Remove The Root Node In Behaviour Tree Is Illegal",
@@ -75,9 +192,104 @@ var errorMsg = map[ErrorCode]string{
 	BtConnectInvalidRootForChild:         "Invalid Parent Id: %s Root Always Not Child",
 	BtConnectInvalidTaskForParent:        "Invalid Child Id: %s Task Always Not Parent",
 	BtInvalidDisconnectNodeWithoutParent: "Invalid Child Id: %s, Disconnect Node Without Parent",
+	BtConnectCycleDetected:               "Invalid Connection Parent Id: %s Child Id: %s, It Will Make A Cycle",
+	BtConnectInvalidRunSubtreeForParent:  "Invalid Parent Id: %s Run Subtree Always Not Parent",
 
 	BtGetNodeInvalidNodeId:        "Invalid Node Id :%s For Get BehaviourTree Node",
 	BtUpdateSettingsInvalidNodeId: "Invalid Node Id :%s For Update Node Settings",
+
+	BtValidationFailed:                 "Behaviour Tree Validation Failed With %d Issue(s), First: %s",
+	BtValidateCycleDetected:            "Node Id: %s Is Part Of A Cycle",
+	BtValidateUnknownParent:            "Node Id: %s Has Unknown Parent Id: %s",
+	BtValidateDuplicatedNodeId:         "Duplicated Node Id: %s",
+	BtValidateCompositeWithoutChildren: "Composite Node Id: %s Has No Children",
+	BtValidateRootWithMultipleChildren: "Root Node Id: %s Has %d Children, Only One Is Allowed",
+	BtValidateInvalidRootCount:         "Behaviour Tree Must Have Exactly One Root Node, Found %d",
+	BtValidateUnknownAttachTarget:      "Attachment Id: %s Is Attached To Unknown Node Id: %s",
+
+	BtDescriptorInvalidAttachTarget: "Invalid Node Id: %s For Attaching Descriptor",
+	BtDescriptorIllegalAttachToRoot: "Attaching Descriptor To The Root Node Is Illegal",
+	BtDescriptorInvalidDescriptorId: "Invalid Descriptor Id: %s",
+	BtReorderDescriptorsMismatch:    "The Descriptor Ids For Reordering Are Mismatched With The Descriptors Attached To Node Id: %s",
+
+	BtServiceInvalidAttachTarget: "Invalid Node Id: %s For Attaching Service",
+	BtServiceIllegalAttachTarget: "Attaching Service To The Node Type: %s Is Illegal, Only Composite Node Is Acceptable",
+	BtServiceInvalidServiceId:    "Invalid Service Id: %s",
+	BtReorderServicesMismatch:    "The Service Ids For Reordering Are Mismatched With The Services Attached To Node Id: %s",
+	BtServiceInvalidTickInterval: "Invalid Tick Interval: %g Or Random Deviation: %g, Interval Must Be Positive And Deviation Must Be In [0, Interval]",
+
+	BtSimpleParallelInvalidFinishMode:  "Invalid Finish Mode: %v For Simple Parallel, Only immediate Or delayed Is Acceptable",
+	BtSimpleParallelTooManyChildren:    "Simple Parallel Node Id: %s Only Accepts One Main Task And One Background Child",
+	BtSimpleParallelInvalidMainTask:    "The Main Child Id: %s Of Simple Parallel Node Id: %s Must Be A Task, The Main Task Is Placed Left Of The Background Child",
+	BtValidateSimpleParallelIncomplete: "Simple Parallel Node Id: %s Has %d Children, It Needs One Main Task And One Background Child",
+
+	BtHistoryNothingToUndo: "There Is Nothing To Undo For Asset Id: %s",
+	BtHistoryNothingToRedo: "There Is Nothing To Redo For Asset Id: %s",
+	BtHistoryConflict:      "The History Of %s Id: %s Conflicts With The Current Document",
+
+	BtBatchInvalidOperation:    "Invalid Operation: %s At Index: %d",
+	BtBatchUnknownReference:    "Unknown Node Reference: %s At Index: %d",
+	BtBatchDuplicatedReference: "Duplicated Node Reference: %s At Index: %d",
+	BtBatchOperationFailed:     "Operation At Index: %d (%s) Failed: %s",
+
+	BtDuplicateInvalidNodeId:            "Invalid Node Id: %s For Duplicating Subtree",
+	BtDuplicateIllegalRoot:              "Duplicating The Root Node In Behaviour Tree Is Illegal",
+	BtDuplicateInvalidSourceAsset:       "Invalid Source Asset Id: %s With Type: %s For Duplicating Subtree, It Must Be A BehaviourTree",
+	BtDuplicateSourceAssetOutOfSolution: "Source Asset Id: %s For Duplicating Subtree Is Not In The Solution Id: %s",
+
+	BtRunSubtreeInvalidSettings:      "Invalid Subtree Asset Id: %v For Run Subtree, It Must Be A String",
+	BtValidateRunSubtreeWithoutAsset: "Run Subtree Node Id: %s Has Not Referred To Any Asset",
+	BtValidateInvalidSubtreeAsset:    "Run Subtree Node Id: %s Refers To Asset Id: %s, Which Is Not A BehaviourTree In The Same Solution",
+	BtValidateSubtreeRecursion:       "Run Subtree Node Id: %s Makes A Recursion Through Assets: %s",
+
+	BtSettingsNotObject:       "The Settings Must Be A JSON Object: %s",
+	BtSettingsReservedKey:     "The Settings Key: %s Is Reserved By The Archive",
+	BtSettingsSchemaViolation: "The Settings Of %s Type: %s Violate The Registered Schema, First: %s %s",
+
+	BtLayoutInvalidOrientation: "Invalid Layout Orientation: %s, Only topDown Or leftRight Is Acceptable",
+	BtLayoutInvalidSpacing:     "Invalid Layout Sibling Spacing: %g Or Level Spacing: %g, They Must Be Positive",
+
+	BtSimulationInvalidTicks:      "Invalid Simulation Ticks: %d, It Must Be In [1, %d]",
+	BtSimulationInvalidTaskResult: "Invalid Task Result: %s For Node Id: %s, Only success, failure Or running Is Acceptable",
+	BtSimulationInvalidCondition:  "Invalid Blackboard Condition Of Descriptor Id: %s, %s",
+
+	BtValidateInvalidBlackBoardAsset:      "The Bound Asset Id: %s Is Not A BlackBoard In The Same Solution",
+	BtValidateUnknownBlackBoardKey:        "The Settings Field: %s Refers To Black Board Key: %s, Which Is Not In The Bound BlackBoard",
+	BtValidateIncompatibleBlackBoardKey:   "The Settings Field: %s Refers To Black Board Key: %s With Type: %s, Only %v Is Acceptable",
+	BtValidateIncompatibleBlackBoardValue: "The Compared Value: %s Is Incompatible With Black Board Key: %s With Type: %s",
+
+	BbInvalidKeyName:      "Invalid Black Board Key Name: %q",
+	BbDuplicatedKeyName:   "Duplicated Black Board Key Name: %s",
+	BbInvalidKeyType:      "Invalid Black Board Key Type: %s, Only bool, int, float, string, vector, object Or enum Is Acceptable",
+	BbInvalidEnumValues:   "Invalid Enum Values Of Black Board Key: %s, They Must Be Non-Empty And Unique",
+	BbInvalidDefaultValue: "Invalid Default Value: %s Of Black Board Key: %s With Type: %s",
+	BbInvalidKey:          "Invalid Black Board Key: %s",
+
+	GoapInvalidFactName:     "Invalid GOAP Fact Name: %q",
+	GoapDuplicatedFactName:  "Duplicated GOAP Fact Name: %s",
+	GoapInvalidFactType:     "Invalid GOAP Fact Type: %s, Only bool, int Or enum Is Acceptable",
+	GoapInvalidEnumValues:   "Invalid Enum Values Of GOAP Fact: %s, They Must Be Non-Empty And Unique",
+	GoapInvalidDefaultValue: "Invalid Default Value: %s Of GOAP Fact: %s With Type: %s",
+	GoapInvalidFact:         "Invalid GOAP Fact: %s",
+	GoapFactInUse:           "GOAP Fact: %s Is Still Referred By %s: %s",
+
+	GoapInvalidActionName:    "Invalid GOAP Action Name: %q",
+	GoapDuplicatedActionName: "Duplicated GOAP Action Name: %s",
+	GoapInvalidActionId:      "Invalid GOAP Action Id: %s",
+	GoapInvalidActionCost:    "Invalid Cost: %g Of GOAP Action: %s, It Must Be A Non-Negative Number",
+
+	GoapInvalidGoalName:    "Invalid GOAP Goal Name: %q",
+	GoapDuplicatedGoalName: "Duplicated GOAP Goal Name: %s",
+	GoapInvalidGoalId:      "Invalid GOAP Goal Id: %s",
+
+	GoapInvalidCondition: "Invalid GOAP Condition Of Fact: %s, %s",
+	GoapInvalidEffect:    "Invalid GOAP Effect Of Fact: %s, %s",
+
+	GoapPlanNotFound:              "There Is No Plan For The Goal, %d Unsatisfiable Condition(s) Found",
+	GoapPlanExpansionLimitReached: "No Plan Is Found Within %d Expansions",
+	GoapPlanInvalidMaxExpansions:  "Invalid Max Expansions: %d, It Must Be In [1, %d]",
+	GoapPlanInvalidInitialState:   "Invalid Initial State Of Fact: %s, %s",
+	GoapPlanInvalidGoal:           "Invalid Goal, Either The Goal Id Or The Goal Conditions Is Required, Goal Id: %s Is Unknown",
 }
 
 func (errCode ErrorCode) GetMsg() string {
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/slices"
+	"os"
+)
+
+// ServerConfig is loaded from the defaults, the optional config file, the environment variables and the flags,
+// the latter one overrides the former one
+type ServerConfig struct {
+	ListenAddress string `json:"listenAddress"`
+	DatabaseDSN   string `json:"databaseDsn"`
+	LogLevel      string `json:"logLevel"`  // debug, info, warn or error
+	LogFormat     string `json:"logFormat"` // console or json
+	GinMode       string `json:"ginMode"`   // debug, release or test
+}
+
+const environmentPrefix = "MESSY_MONSTER_"
+
+func defaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ListenAddress: "localhost:8000",
+		DatabaseDSN:   "./db/db.sqlite",
+		LogLevel:      "debug",
+		LogFormat:     "console",
+		GinMode:       "debug",
+	}
+}
+
+// the options shared by the config file, the environment variables and the flags
+type serverOption struct {
+	flagName       string
+	environmentKey string
+	usage          string
+	value          func(config *ServerConfig) *string
+}
+
+var serverOptions = []serverOption{
+	{"listen", "LISTEN_ADDRESS", "the address to listen", func(config *ServerConfig) *string { return &config.ListenAddress }},
+	{"dsn", "DATABASE_DSN", "the DSN of the database: the SQLite file, a postgres:// URL or memory://", func(config *ServerConfig) *string { return &config.DatabaseDSN }},
+	{"log-level", "LOG_LEVEL", "the log level: debug, info, warn or error", func(config *ServerConfig) *string { return &config.LogLevel }},
+	{"log-format", "LOG_FORMAT", "the log format: console or json", func(config *ServerConfig) *string { return &config.LogFormat }},
+	{"gin-mode", "GIN_MODE", "the mode of gin: debug, release or test", func(config *ServerConfig) *string { return &config.GinMode }},
+}
+
+// Load reads the config of the server from the arguments without the program name, the arguments after the flags are returned as the command
+func Load(arguments []string) (*ServerConfig, []string, error) {
+	serverConfig := defaultServerConfig()
+
+	flagSet := flag.NewFlagSet("messy-monster-ai-editor", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv(environmentPrefix+"CONFIG"), "the optional JSON config file")
+	flagValues := make([]string, len(serverOptions))
+	for i, option := range serverOptions {
+		flagSet.StringVar(&flagValues[i], option.flagName, "", fmt.Sprintf("%s (env %s%s, default %q)", option.usage, environmentPrefix, option.environmentKey, *option.value(&serverConfig)))
+	}
+	err := flagSet.Parse(arguments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//Config File
+	if *configFile != "" {
+		content, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = json.Unmarshal(content, &serverConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid config file %s: %w", *configFile, err)
+		}
+	}
+
+	//Environment Variables
+	for _, option := range serverOptions {
+		if value, found := os.LookupEnv(environmentPrefix + option.environmentKey); found {
+			*option.value(&serverConfig) = value
+		}
+	}
+
+	//Flags
+	flagSet.Visit(func(f *flag.Flag) {
+		for i, option := range serverOptions {
+			if option.flagName == f.Name {
+				*option.value(&serverConfig) = flagValues[i]
+			}
+		}
+	})
+
+	err = serverConfig.validate()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &serverConfig, flagSet.Args(), nil
+}
+
+func (config *ServerConfig) validate() error {
+	if config.ListenAddress == "" {
+		return errors.New("the listen address is empty")
+	}
+	if config.DatabaseDSN == "" {
+		return errors.New("the database DSN is empty")
+	}
+	if _, err := zapcore.ParseLevel(config.LogLevel); err != nil {
+		return fmt.Errorf("invalid log level %q", config.LogLevel)
+	}
+	if !slices.Contains([]string{"console", "json"}, config.LogFormat) {
+		return fmt.Errorf("invalid log format %q", config.LogFormat)
+	}
+	if !slices.Contains([]string{"debug", "release", "test"}, config.GinMode) {
+		return fmt.Errorf("invalid gin mode %q", config.GinMode)
+	}
+	return nil
+}
+
+// NewLogger builds the zap logger by the log level and the log format
+func (config *ServerConfig) NewLogger() (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var loggerConfig zap.Config
+	if config.LogFormat == "json" {
+		loggerConfig = zap.NewProductionConfig()
+	} else {
+		loggerConfig = zap.NewDevelopmentConfig()
+	}
+	loggerConfig.Level = zap.NewAtomicLevelAt(level)
+	return loggerConfig.Build()
+}
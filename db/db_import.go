@@ -0,0 +1,95 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"os"
+)
+
+const importBatchSize = 500
+
+// ImportSQLite copies all the records of the SQLite file into the migrated and empty database opened by Initialize,
+// it's the way to move an existing SQLite deployment to PostgreSQL.
+// the SQLite file is migrated to the latest schema before copying, and everything is copied in a single transaction,
+// so nothing is left in the database when the importing fails
+func ImportSQLite(sqlitePath string) error {
+	if GormDatabase == nil {
+		return errors.New("the records can't be imported into the in-memory repository")
+	}
+	if isPostgresDSN(sqlitePath) {
+		return fmt.Errorf("%s isn't a SQLite file", redactDSN(sqlitePath))
+	}
+	if _, err := os.Stat(sqlitePath); err != nil {
+		return err
+	}
+
+	source, err := openDatabase(sqlitePath)
+	if err != nil {
+		return err
+	}
+	if sourceDB, err := source.DB(); err == nil {
+		defer sourceDB.Close()
+	}
+	err = migrate(source)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", sqlitePath, err)
+	}
+
+	var solutionCount int64
+	err = GormDatabase.Model(&common.SolutionDetailInfo{}).Count(&solutionCount).Error
+	if err != nil {
+		return err
+	}
+	if solutionCount > 0 {
+		return errors.New("the database to import into isn't empty")
+	}
+
+	return GormDatabase.Transaction(func(tx *gorm.DB) error {
+		//the parents are copied before their children for the foreign keys
+		copyPasses := []func() error{
+			func() error { return copyRecords[common.SolutionDetailInfo](source, tx) },
+			func() error { return copyRecords[common.AssetSetInfoItem](source, tx) },
+			func() error { return copyRecords[common.AssetDetailInfo](source, tx) },
+			func() error { return copyRecords[common.AssetModificationHistoryItem](source, tx) },
+			func() error { return copyRecords[common.SettingsSchemaItem](source, tx) },
+			func() error { return copyRecords[common.AssetVersionDetailInfo](source, tx) },
+		}
+		for _, copyPass := range copyPasses {
+			err := copyPass()
+			if err != nil {
+				return err
+			}
+		}
+
+		//the identities of PostgreSQL aren't moved by the explicit ids
+		if tx.Dialector.Name() == dialectPostgres {
+			for _, table := range []string{"ai_asset_modification_histories", "ai_asset_versions"} {
+				err := tx.Exec(fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM %[1]s`, table)).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func copyRecords[T schema.Tabler](source *gorm.DB, target *gorm.DB) error {
+	var records []T
+	var count int
+	err := source.FindInBatches(&records, importBatchSize, func(batch *gorm.DB, _ int) error {
+		count += len(records)
+		return target.Create(&records).Error
+	}).Error
+
+	var table T
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", table.TableName(), err)
+	}
+	zap.S().Infof("%d records of %s are imported", count, table.TableName())
+	return nil
+}
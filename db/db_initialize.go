@@ -2,17 +2,92 @@ package db
 
 import (
 	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 var GormDatabase *gorm.DB
 
-func init() {
+// MemoryDSN selects the in-memory repository, everything is lost when the server exits
+const MemoryDSN = "memory://"
+
+// Initialize opens the database of the DSN and sets the Storage, the schema is created by Migrate.
+// a postgres:// or postgresql:// URL connects to PostgreSQL, otherwise the DSN is the SQLite file which is created when it doesn't exist
+func Initialize(dsn string) error {
+	if dsn == MemoryDSN {
+		Storage = NewMemoryRepository()
+		zap.S().Info("the in-memory repository is used")
+		return nil
+	}
+
 	var err error
-	GormDatabase, err = gorm.Open(sqlite.Open("./db/db.sqlite"), &gorm.Config{})
+	GormDatabase, err = openDatabase(dsn)
+	if err != nil {
+		return err
+	}
+	Storage = NewGormRepository(GormDatabase)
+	zap.S().Infof("database %s is connected", redactDSN(dsn))
+	return nil
+}
+
+func openDatabase(dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	if isPostgresDSN(dsn) {
+		dialector = postgres.Open(dsn)
+	} else {
+		err := createSQLiteDirectory(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the directory of the database: %w", err)
+		}
+		dialector = sqlite.Open(withForeignKeys(dsn))
+	}
+
+	database, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		panic("failed to connect database")
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+	return database, nil
+}
+
+// isPostgresDSN only accepts the URL form, the key/value form of libpq can't be told from the path of a SQLite file
+func isPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// redactDSN hides the password of the PostgreSQL URL for logging
+func redactDSN(dsn string) string {
+	if !isPostgresDSN(dsn) {
+		return dsn
+	}
+	dsnURL, err := url.Parse(dsn)
+	if err != nil {
+		return "the PostgreSQL database"
+	}
+	return dsnURL.Redacted()
+}
+
+// createSQLiteDirectory creates the parent directory of the SQLite file, SQLite only creates the file itself
+func createSQLiteDirectory(dsn string) error {
+	filePath, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	if filePath == "" || filePath == ":memory:" {
+		return nil
+	}
+	return os.MkdirAll(filepath.Dir(filePath), 0o755)
+}
+
+// withForeignKeys enables the foreign keys for every connection of the pool unless the DSN sets it explicitly
+func withForeignKeys(dsn string) string {
+	if strings.Contains(dsn, "_foreign_keys=") || strings.Contains(dsn, "_fk=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_foreign_keys=on"
 	}
-	fmt.Println("Database connection successful!")
+	return dsn + "?_foreign_keys=on"
 }
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the migrations are named as <version>_<name>.sql in the directory of the dialect, they are applied in the order of the version and never modified after released.
+// every dialect has the same versions, so the schema version means the same schema for all the databases.
+// a SQLite migration must be idempotent when it is applied to the database created before the migrations were introduced
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+const (
+	dialectSQLite   = "sqlite"
+	dialectPostgres = "postgres"
+)
+
+const createSchemaMigrationsStatement = `CREATE TABLE IF NOT EXISTS schema_migrations(
+    "version" INTEGER PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "appliedAt" BIGINT NOT NULL
+)`
+
+// migrationLockKey is the key of the PostgreSQL advisory lock, the servers sharing a database don't migrate at the same time
+const migrationLockKey = 0x6d6d6169
+
+// migrationReporters log the changes of the records made by the migrations beyond the schema,
+// they run in the transaction of the migration right before it's applied
+var migrationReporters = map[string]map[int]func(tx *gorm.DB) error{
+	dialectSQLite:   {4: reportOrphanedOrganizationRecords, 7: reportDuplicatedNames},
+	dialectPostgres: {7: reportDuplicatedNames},
+}
+
+// recordReport is the query of the record ids to log with the description of what the migration does to them
+type recordReport struct {
+	description string
+	query       string
+}
+
+type SchemaMigration struct {
+	Version   int    `gorm:"column:version;primaryKey"`
+	Name      string `gorm:"column:name"`
+	AppliedAt int64  `gorm:"column:appliedAt"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type embeddedMigration struct {
+	version    int
+	name       string
+	statements string
+}
+
+func loadEmbeddedMigrations(dialect string) ([]embeddedMigration, error) {
+	directory := path.Join("migrations", dialect)
+	entries, err := migrationFiles.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]embeddedMigration, 0, len(entries))
+	for _, entry := range entries {
+		fileName := entry.Name()
+		versionText, name, found := strings.Cut(strings.TrimSuffix(fileName, ".sql"), "_")
+		version, err := strconv.Atoi(versionText)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %s", fileName)
+		}
+		statements, err := migrationFiles.ReadFile(path.Join(directory, fileName))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, embeddedMigration{version, name, string(statements)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("duplicated migration version %d", migrations[i].version)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrate applies the pending migrations to the database opened by Initialize, nothing is done for the in-memory repository
+func Migrate() error {
+	if GormDatabase == nil {
+		return nil
+	}
+	return migrate(GormDatabase)
+}
+
+// migrate applies the pending migrations of the dialect, every migration is applied in its own transaction with its record in schema_migrations.
+// a single connection is held, SQLite disables the foreign keys on it while migrating, otherwise rebuilding a parent table deletes all of its children,
+// and the pragma only works for the current connection out of any transaction. PostgreSQL holds the advisory lock on it instead
+func migrate(database *gorm.DB) error {
+	dialect := database.Dialector.Name()
+	migrations, err := loadEmbeddedMigrations(dialect)
+	if err != nil {
+		return err
+	}
+
+	return database.Connection(func(conn *gorm.DB) error {
+		if dialect == dialectPostgres {
+			err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error
+			if err != nil {
+				return err
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+		}
+
+		err := conn.Exec(createSchemaMigrationsStatement).Error
+		if err != nil {
+			return err
+		}
+
+		var appliedVersions []int
+		err = conn.Model(&SchemaMigration{}).Order("version").Pluck("version", &appliedVersions).Error
+		if err != nil {
+			return err
+		}
+		if len(appliedVersions) > 0 && appliedVersions[len(appliedVersions)-1] > migrations[len(migrations)-1].version {
+			zap.S().Warnf("the schema version %d of the database is newer than the latest migration %d", appliedVersions[len(appliedVersions)-1], migrations[len(migrations)-1].version)
+		}
+
+		if dialect == dialectSQLite {
+			err = conn.Exec("PRAGMA foreign_keys = OFF").Error
+			if err != nil {
+				return err
+			}
+			defer conn.Exec("PRAGMA foreign_keys = ON")
+		}
+
+		for _, migration := range migrations {
+			if slices.Contains(appliedVersions, migration.version) {
+				continue
+			}
+
+			err = conn.Transaction(func(tx *gorm.DB) error {
+				if reporter, exist := migrationReporters[dialect][migration.version]; exist {
+					err := reporter(tx)
+					if err != nil {
+						return err
+					}
+				}
+
+				err := tx.Exec(migration.statements).Error
+				if err != nil {
+					return err
+				}
+
+				//PostgreSQL checks the foreign keys by itself
+				if dialect == dialectSQLite {
+					var violations []map[string]interface{}
+					err = tx.Raw("PRAGMA foreign_key_check").Scan(&violations).Error
+					if err != nil {
+						return err
+					}
+					if len(violations) > 0 {
+						return errors.New("foreign key violations are found after migrating")
+					}
+				}
+
+				return tx.Create(&SchemaMigration{Version: migration.version, Name: migration.name, AppliedAt: time.Now().UnixMilli()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply the migration %04d_%s: %w", migration.version, migration.name, err)
+			}
+			zap.S().Infof("the migration %04d_%s is applied", migration.version, migration.name)
+		}
+		return nil
+	})
+}
+
+// reportOrphanedOrganizationRecords reports the records referring to the missing parents, which are recovered or dropped
+// by the migration 0004 adding the foreign keys to the SQLite tables
+func reportOrphanedOrganizationRecords(tx *gorm.DB) error {
+	return reportRecords(tx, []recordReport{
+		{
+			description: `assets are moved into the recreated asset sets of the solution "Recovered Assets" since their asset sets are missing`,
+			query:       "SELECT id FROM ai_asset_documentations WHERE assetSetId NOT IN (SELECT id FROM ai_asset_sets)",
+		},
+		{
+			description: `asset sets are moved into the recreated solutions named "Recovered Solution <id>" since their solutions are missing`,
+			query:       "SELECT id FROM ai_asset_sets WHERE solutionId NOT IN (SELECT id FROM ai_solutions)",
+		},
+		{
+			description: `settings schemas are moved into the recreated solutions named "Recovered Solution <id>" since their solutions are missing`,
+			query:       "SELECT id FROM ai_settings_schemas WHERE solutionId NOT IN (SELECT id FROM ai_solutions)",
+		},
+		{
+			description: "modification histories are dropped since their assets are missing",
+			query:       "SELECT CAST(id AS TEXT) FROM ai_asset_modification_histories WHERE assetId NOT IN (SELECT id FROM ai_asset_documentations)",
+		},
+	})
+}
+
+// reportDuplicatedNames reports the records renamed by the migration 0007 creating the unique indexes of the names,
+// the identifiers are quoted for both of the dialects
+func reportDuplicatedNames(tx *gorm.DB) error {
+	return reportRecords(tx, []recordReport{
+		{
+			description: "solutions are renamed with their ids since their names are duplicated",
+			query: `SELECT "id" FROM ai_solutions WHERE EXISTS (
+				SELECT 1 FROM ai_solutions AS earlier WHERE earlier."solutionName" = ai_solutions."solutionName" AND earlier."id" < ai_solutions."id")`,
+		},
+		{
+			description: "asset sets are renamed with their ids since their names are duplicated in the solutions",
+			query: `SELECT "id" FROM ai_asset_sets WHERE EXISTS (
+				SELECT 1 FROM ai_asset_sets AS earlier
+				WHERE earlier."solutionId" = ai_asset_sets."solutionId" AND earlier."assetSetName" = ai_asset_sets."assetSetName" AND earlier."id" < ai_asset_sets."id")`,
+		},
+		{
+			description: "assets are renamed with their ids since their names are duplicated in the asset sets",
+			query: `SELECT "id" FROM ai_asset_documentations WHERE EXISTS (
+				SELECT 1 FROM ai_asset_documentations AS earlier
+				WHERE earlier."assetSetId" = ai_asset_documentations."assetSetId" AND earlier."assetName" = ai_asset_documentations."assetName" AND earlier."id" < ai_asset_documentations."id")`,
+		},
+	})
+}
+
+// reportRecords logs the ids found by every report as a warning
+func reportRecords(tx *gorm.DB, reports []recordReport) error {
+	for _, report := range reports {
+		var ids []string
+		err := tx.Raw(report.query).Scan(&ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			zap.S().Warnf("%d %s: %s", len(ids), report.description, strings.Join(ids, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// baselineSQLiteSchema is the schema of the SQLite file created before the migrations were introduced
+const baselineSQLiteSchema = `
+CREATE TABLE ai_solutions(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionName char(64) NOT NULL,
+    solutionMeta TEXT NOT NULL,
+    solutionVersion char(36) NOT NULL
+);
+CREATE TABLE ai_asset_sets(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionId char(36) NOT NULL,
+    assetSetName char(64) NOT NULL
+);
+CREATE TABLE ai_asset_documentations(
+    id char(36) PRIMARY KEY UNIQUE,
+    assetSetId char(36) NOT NULL,
+    assetType char(16) NOT NULL,
+    assetName char(64) NOT NULL,
+    assetContent TEXT NOT NULL,
+    assetVersion char(36) NOT NULL
+)`
+
+func openTestSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	database, err := openDatabase(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return database
+}
+
+// observeMigrationLogs collects the logs of the migrations and the reporters until the test ends
+func observeMigrationLogs(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.InfoLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(restore)
+	return logs
+}
+
+func expectAppliedMigrations(t *testing.T, database *gorm.DB) {
+	t.Helper()
+	migrations, err := loadEmbeddedMigrations(dialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var appliedVersions []int
+	if err = database.Model(&SchemaMigration{}).Order("version").Pluck("version", &appliedVersions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(appliedVersions) != len(migrations) {
+		t.Fatalf("%d migrations are recorded, expected %d", len(appliedVersions), len(migrations))
+	}
+	for i, migration := range migrations {
+		if appliedVersions[i] != migration.version {
+			t.Fatalf("the migration %d is recorded as %d", migration.version, appliedVersions[i])
+		}
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	logs := observeMigrationLogs(t)
+	database := openTestSQLite(t)
+	if err := migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	expectAppliedMigrations(t, database)
+	migrations, _ := loadEmbeddedMigrations(dialectSQLite)
+	if appliedLogs := logs.FilterMessageSnippet("is applied").Len(); appliedLogs != len(migrations) {
+		t.Fatalf("%d migrations are logged as applied, expected %d", appliedLogs, len(migrations))
+	}
+	if warnings := logs.FilterLevelExact(zapcore.WarnLevel).All(); len(warnings) != 0 {
+		t.Fatalf("the empty database is reported: %v", warnings)
+	}
+
+	//Migrating Again Is A No-op
+	logs.TakeAll()
+	if err := migrate(database); err != nil {
+		t.Fatalf("the applied migrations are applied again: %v", err)
+	}
+	expectAppliedMigrations(t, database)
+	if appliedLogs := logs.FilterMessageSnippet("is applied").Len(); appliedLogs != 0 {
+		t.Fatalf("%d migrations are applied again", appliedLogs)
+	}
+
+	//The Foreign Keys Are Enabled Again After Migrating
+	repo := NewGormRepository(database)
+	solution, _, asset := createTestOrganization(t, repo)
+	if err := repo.DeleteSolution(solution.SolutionId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetAsset(asset.AssetId); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("the asset isn't deleted with its solution: %v", err)
+	}
+}
+
+func TestSQLiteMigrationsUpgradeBaseline(t *testing.T) {
+	logs := observeMigrationLogs(t)
+	database := openTestSQLite(t)
+	if err := database.Exec(baselineSQLiteSchema).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	//The Baseline Didn't Check The Parents Or The Names, The Meta Was Written As A Blob By Gorm
+	records := []string{
+		`INSERT INTO ai_solutions VALUES('solution-1', 'Solution', CAST('{}' AS BLOB), 'version-s1')`,
+		`INSERT INTO ai_asset_sets VALUES('asset-set-1', 'solution-1', 'Set')`,
+		`INSERT INTO ai_asset_sets VALUES('asset-set-2', 'missing-solution', 'Orphaned Set')`,
+		`INSERT INTO ai_asset_documentations VALUES('asset-1', 'asset-set-1', 'BehaviourTree', 'Tree', '{}', 'version-a1')`,
+		`INSERT INTO ai_asset_documentations VALUES('asset-2', 'asset-set-1', 'BehaviourTree', 'Tree', '{}', 'version-a2')`,
+		`INSERT INTO ai_asset_documentations VALUES('asset-3', 'missing-asset-set', 'BehaviourTree', 'Orphaned Tree', '{}', 'version-a3')`,
+	}
+	for _, record := range records {
+		if err := database.Exec(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := migrate(database); err != nil {
+		t.Fatal(err)
+	}
+	expectAppliedMigrations(t, database)
+
+	//The Reporters Log The Records Changed By The Migrations
+	expectedWarnings := []string{
+		"1 assets are moved into the recreated asset sets of the solution \"Recovered Assets\" since their asset sets are missing: asset-3",
+		"1 asset sets are moved into the recreated solutions named \"Recovered Solution <id>\" since their solutions are missing: asset-set-2",
+		"1 assets are renamed with their ids since their names are duplicated in the asset sets: asset-2",
+	}
+	warnings := logs.FilterLevelExact(zapcore.WarnLevel).All()
+	if len(warnings) != len(expectedWarnings) {
+		t.Fatalf("%d records are reported, expected %d: %v", len(warnings), len(expectedWarnings), warnings)
+	}
+	for i, warning := range warnings {
+		if warning.Message != expectedWarnings[i] {
+			t.Errorf("the report is %q, expected %q", warning.Message, expectedWarnings[i])
+		}
+	}
+
+	//The Records Are Recovered, Renamed And Versioned
+	repo := NewGormRepository(database)
+	orphanedAsset, err := repo.GetAsset("asset-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphanedAssetSet, err := repo.GetAssetSet(orphanedAsset.AssetSetId)
+	if err != nil {
+		t.Fatalf("the asset set of the orphaned asset isn't recovered: %v", err)
+	}
+	if orphanedAssetSet.AssetSetName != "Recovered AssetSet missing-asset-set" {
+		t.Fatalf("the asset set is recovered as %q", orphanedAssetSet.AssetSetName)
+	}
+	for _, solutionId := range []string{orphanedAssetSet.SolutionId, "missing-solution"} {
+		if _, err = repo.GetSolution(solutionId); err != nil {
+			t.Fatalf("the solution %s isn't recovered: %v", solutionId, err)
+		}
+	}
+	renamedAsset, err := repo.GetAsset("asset-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(renamedAsset.AssetName, "Tree (") {
+		t.Fatalf("the duplicated asset is renamed as %q", renamedAsset.AssetName)
+	}
+	versionItems, err := repo.ListAssetVersions("asset-1", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versionItems) != 1 || versionItems[0].AssetVersion != "version-a1" {
+		t.Fatalf("the current content isn't recorded as the first version: %+v", versionItems)
+	}
+}
@@ -0,0 +1,23 @@
+-- the columns are quoted to keep the camel case names of the SQLite schema, the unquoted identifiers are folded to lower case by PostgreSQL.
+-- varchar is used instead of char since char pads the value with spaces
+CREATE TABLE IF NOT EXISTS ai_solutions(
+    "id" varchar(36) PRIMARY KEY,
+    "solutionName" varchar(64) NOT NULL,
+    "solutionMeta" json NOT NULL,
+    "solutionVersion" varchar(36) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_asset_sets(
+    "id" varchar(36) PRIMARY KEY,
+    "solutionId" varchar(36) NOT NULL,
+    "assetSetName" varchar(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_asset_documentations(
+    "id" varchar(36) PRIMARY KEY,
+    "assetSetId" varchar(36) NOT NULL,
+    "assetType" varchar(16) NOT NULL,
+    "assetName" varchar(64) NOT NULL,
+    "assetContent" TEXT NOT NULL,
+    "assetVersion" varchar(36) NOT NULL
+);
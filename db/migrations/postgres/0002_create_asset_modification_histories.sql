@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS ai_asset_modification_histories(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "assetId" varchar(36) NOT NULL,
+    "prevVersion" varchar(36) NOT NULL,
+    "newVersion" varchar(36) NOT NULL,
+    "operation" varchar(16) NOT NULL,
+    "undoState" varchar(16) NOT NULL,
+    "targetHistoryId" BIGINT NOT NULL DEFAULT 0,
+    "diffInfos" TEXT NOT NULL,
+    "createdAt" BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_ai_asset_modification_histories_assetId" ON ai_asset_modification_histories("assetId");
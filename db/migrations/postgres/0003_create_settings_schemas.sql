@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ai_settings_schemas(
+    "id" varchar(36) PRIMARY KEY,
+    "solutionId" varchar(36) NOT NULL,
+    "category" varchar(16) NOT NULL,
+    "typeName" varchar(64) NOT NULL,
+    "schema" json NOT NULL,
+    "updatedAt" BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_settings_schemas_type ON ai_settings_schemas("solutionId", "category", "typeName");
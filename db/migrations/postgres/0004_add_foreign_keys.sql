@@ -0,0 +1,11 @@
+-- the same foreign keys as the rebuilt SQLite tables, PostgreSQL is able to add the constraints to the exist tables
+
+ALTER TABLE ai_asset_sets ADD CONSTRAINT "fk_ai_asset_sets_solutionId" FOREIGN KEY ("solutionId") REFERENCES ai_solutions("id") ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS "idx_ai_asset_sets_solutionId" ON ai_asset_sets("solutionId");
+
+ALTER TABLE ai_asset_documentations ADD CONSTRAINT "fk_ai_asset_documentations_assetSetId" FOREIGN KEY ("assetSetId") REFERENCES ai_asset_sets("id") ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS "idx_ai_asset_documentations_assetSetId" ON ai_asset_documentations("assetSetId");
+
+ALTER TABLE ai_asset_modification_histories ADD CONSTRAINT "fk_ai_asset_modification_histories_assetId" FOREIGN KEY ("assetId") REFERENCES ai_asset_documentations("id") ON DELETE CASCADE;
+
+ALTER TABLE ai_settings_schemas ADD CONSTRAINT "fk_ai_settings_schemas_solutionId" FOREIGN KEY ("solutionId") REFERENCES ai_solutions("id") ON DELETE CASCADE;
@@ -0,0 +1,16 @@
+-- every committed content of the assets, the current content of the exist assets is recorded as their first version
+CREATE TABLE IF NOT EXISTS ai_asset_versions(
+    "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "assetId" varchar(36) NOT NULL REFERENCES ai_asset_documentations("id") ON DELETE CASCADE,
+    "assetVersion" varchar(36) NOT NULL,
+    "prevVersion" varchar(36) NOT NULL DEFAULT '',
+    "restoredFromVersion" varchar(36) NOT NULL DEFAULT '',
+    "author" varchar(64) NOT NULL DEFAULT '',
+    "createdAt" BIGINT NOT NULL,
+    "assetContent" TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_asset_versions_version ON ai_asset_versions("assetId", "assetVersion");
+
+INSERT INTO ai_asset_versions("assetId", "assetVersion", "createdAt", "assetContent")
+    SELECT "id", "assetVersion", (EXTRACT(EPOCH FROM now()) * 1000)::BIGINT, "assetContent" FROM ai_asset_documentations;
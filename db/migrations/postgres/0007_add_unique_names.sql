@@ -0,0 +1,20 @@
+-- the names were only checked by the handlers, so the concurrent creating and renaming could duplicate them.
+-- the duplicated ones except the earliest id are renamed with their ids before the unique indexes are created,
+-- the renamed records are reported by the log before migrating. the names are cut to fit varchar(64)
+UPDATE ai_solutions SET "solutionName" = left("solutionName", 53) || ' (' || left("id", 8) || ')'
+    WHERE EXISTS (SELECT 1 FROM ai_solutions AS earlier WHERE earlier."solutionName" = ai_solutions."solutionName" AND earlier."id" < ai_solutions."id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_solutions_name ON ai_solutions("solutionName");
+
+UPDATE ai_asset_sets SET "assetSetName" = left("assetSetName", 53) || ' (' || left("id", 8) || ')'
+    WHERE EXISTS (
+        SELECT 1 FROM ai_asset_sets AS earlier
+        WHERE earlier."solutionId" = ai_asset_sets."solutionId" AND earlier."assetSetName" = ai_asset_sets."assetSetName" AND earlier."id" < ai_asset_sets."id"
+    );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_asset_sets_name ON ai_asset_sets("solutionId", "assetSetName");
+
+UPDATE ai_asset_documentations SET "assetName" = left("assetName", 53) || ' (' || left("id", 8) || ')'
+    WHERE EXISTS (
+        SELECT 1 FROM ai_asset_documentations AS earlier
+        WHERE earlier."assetSetId" = ai_asset_documentations."assetSetId" AND earlier."assetName" = ai_asset_documentations."assetName" AND earlier."id" < ai_asset_documentations."id"
+    );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_asset_documentations_name ON ai_asset_documentations("assetSetId", "assetName");
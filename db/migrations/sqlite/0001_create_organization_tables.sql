@@ -0,0 +1,22 @@
+-- the tables of the solutions, the asset sets and the assets, they exist already in the database created before the migrations
+CREATE TABLE IF NOT EXISTS ai_solutions(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionName char(64) NOT NULL,
+    solutionMeta TEXT NOT NULL,
+    solutionVersion char(36) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_asset_sets(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionId char(36) NOT NULL,
+    assetSetName char(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ai_asset_documentations(
+    id char(36) PRIMARY KEY UNIQUE,
+    assetSetId char(36) NOT NULL,
+    assetType char(16) NOT NULL,
+    assetName char(64) NOT NULL,
+    assetContent TEXT NOT NULL,
+    assetVersion char(36) NOT NULL
+);
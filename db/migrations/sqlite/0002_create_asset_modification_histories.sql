@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS ai_asset_modification_histories(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    assetId char(36) NOT NULL,
+    prevVersion char(36) NOT NULL,
+    newVersion char(36) NOT NULL,
+    operation char(16) NOT NULL,
+    undoState char(16) NOT NULL,
+    targetHistoryId INTEGER NOT NULL DEFAULT 0,
+    diffInfos TEXT NOT NULL,
+    createdAt INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_asset_modification_histories_assetId ON ai_asset_modification_histories(assetId);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ai_settings_schemas(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionId char(36) NOT NULL,
+    category char(16) NOT NULL,
+    typeName char(64) NOT NULL,
+    schema TEXT NOT NULL,
+    updatedAt INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_settings_schemas_type ON ai_settings_schemas(solutionId, category, typeName);
@@ -0,0 +1,73 @@
+-- sqlite can't add a constraint to an exist table, so the tables are rebuilt with the foreign keys.
+-- the parent table is always rebuilt before its children.
+-- the assets were created without checking their asset sets, so the missing parents are recreated first instead of dropping the children:
+-- the missing solutions are recreated as "Recovered Solution <id>", the missing asset sets are recreated as "Recovered AssetSet <id>"
+-- in the solution "Recovered Assets". the modification histories of the missing assets are useless and dropped.
+-- all of them are reported by the log before migrating. the meta of the recreated solutions is a blob like the one written by gorm, a text one can't be scanned into json.RawMessage
+
+INSERT INTO ai_solutions(id, solutionName, solutionMeta, solutionVersion)
+    SELECT '00000000-0000-0000-0000-000000000004', 'Recovered Assets', CAST('{}' AS BLOB), lower(hex(randomblob(16)))
+    WHERE EXISTS (SELECT 1 FROM ai_asset_documentations WHERE assetSetId NOT IN (SELECT id FROM ai_asset_sets));
+INSERT INTO ai_asset_sets(id, solutionId, assetSetName)
+    SELECT DISTINCT assetSetId, '00000000-0000-0000-0000-000000000004', 'Recovered AssetSet ' || assetSetId FROM ai_asset_documentations
+    WHERE assetSetId NOT IN (SELECT id FROM ai_asset_sets);
+INSERT INTO ai_solutions(id, solutionName, solutionMeta, solutionVersion)
+    SELECT DISTINCT solutionId, 'Recovered Solution ' || solutionId, CAST('{}' AS BLOB), lower(hex(randomblob(16)))
+    FROM (SELECT solutionId FROM ai_asset_sets UNION SELECT solutionId FROM ai_settings_schemas)
+    WHERE solutionId NOT IN (SELECT id FROM ai_solutions);
+
+CREATE TABLE ai_asset_sets_rebuilding(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionId char(36) NOT NULL REFERENCES ai_solutions(id) ON DELETE CASCADE,
+    assetSetName char(64) NOT NULL
+);
+INSERT INTO ai_asset_sets_rebuilding(id, solutionId, assetSetName)
+    SELECT id, solutionId, assetSetName FROM ai_asset_sets;
+DROP TABLE ai_asset_sets;
+ALTER TABLE ai_asset_sets_rebuilding RENAME TO ai_asset_sets;
+CREATE INDEX IF NOT EXISTS idx_ai_asset_sets_solutionId ON ai_asset_sets(solutionId);
+
+CREATE TABLE ai_asset_documentations_rebuilding(
+    id char(36) PRIMARY KEY UNIQUE,
+    assetSetId char(36) NOT NULL REFERENCES ai_asset_sets(id) ON DELETE CASCADE,
+    assetType char(16) NOT NULL,
+    assetName char(64) NOT NULL,
+    assetContent TEXT NOT NULL,
+    assetVersion char(36) NOT NULL
+);
+INSERT INTO ai_asset_documentations_rebuilding(id, assetSetId, assetType, assetName, assetContent, assetVersion)
+    SELECT id, assetSetId, assetType, assetName, assetContent, assetVersion FROM ai_asset_documentations;
+DROP TABLE ai_asset_documentations;
+ALTER TABLE ai_asset_documentations_rebuilding RENAME TO ai_asset_documentations;
+CREATE INDEX IF NOT EXISTS idx_ai_asset_documentations_assetSetId ON ai_asset_documentations(assetSetId);
+
+CREATE TABLE ai_asset_modification_histories_rebuilding(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    assetId char(36) NOT NULL REFERENCES ai_asset_documentations(id) ON DELETE CASCADE,
+    prevVersion char(36) NOT NULL,
+    newVersion char(36) NOT NULL,
+    operation char(16) NOT NULL,
+    undoState char(16) NOT NULL,
+    targetHistoryId INTEGER NOT NULL DEFAULT 0,
+    diffInfos TEXT NOT NULL,
+    createdAt INTEGER NOT NULL
+);
+INSERT INTO ai_asset_modification_histories_rebuilding(id, assetId, prevVersion, newVersion, operation, undoState, targetHistoryId, diffInfos, createdAt)
+    SELECT id, assetId, prevVersion, newVersion, operation, undoState, targetHistoryId, diffInfos, createdAt FROM ai_asset_modification_histories WHERE assetId IN (SELECT id FROM ai_asset_documentations);
+DROP TABLE ai_asset_modification_histories;
+ALTER TABLE ai_asset_modification_histories_rebuilding RENAME TO ai_asset_modification_histories;
+CREATE INDEX IF NOT EXISTS idx_ai_asset_modification_histories_assetId ON ai_asset_modification_histories(assetId);
+
+CREATE TABLE ai_settings_schemas_rebuilding(
+    id char(36) PRIMARY KEY UNIQUE,
+    solutionId char(36) NOT NULL REFERENCES ai_solutions(id) ON DELETE CASCADE,
+    category char(16) NOT NULL,
+    typeName char(64) NOT NULL,
+    schema TEXT NOT NULL,
+    updatedAt INTEGER NOT NULL
+);
+INSERT INTO ai_settings_schemas_rebuilding(id, solutionId, category, typeName, schema, updatedAt)
+    SELECT id, solutionId, category, typeName, schema, updatedAt FROM ai_settings_schemas;
+DROP TABLE ai_settings_schemas;
+ALTER TABLE ai_settings_schemas_rebuilding RENAME TO ai_settings_schemas;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_settings_schemas_type ON ai_settings_schemas(solutionId, category, typeName);
@@ -0,0 +1,17 @@
+-- every committed content of the assets, the current content of the exist assets is recorded as their first version
+CREATE TABLE IF NOT EXISTS ai_asset_versions(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    assetId char(36) NOT NULL REFERENCES ai_asset_documentations(id) ON DELETE CASCADE,
+    assetVersion char(36) NOT NULL,
+    prevVersion char(36) NOT NULL DEFAULT '',
+    restoredFromVersion char(36) NOT NULL DEFAULT '',
+    author char(64) NOT NULL DEFAULT '',
+    createdAt INTEGER NOT NULL,
+    assetContent TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_asset_versions_version ON ai_asset_versions(assetId, assetVersion);
+
+INSERT INTO ai_asset_versions(assetId, assetVersion, createdAt, assetContent)
+    SELECT id, assetVersion, CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER), assetContent FROM ai_asset_documentations
+    WHERE NOT EXISTS (SELECT 1 FROM ai_asset_versions WHERE ai_asset_versions.assetId = ai_asset_documentations.id);
@@ -0,0 +1,10 @@
+-- the solutions and the asset sets are listed in the order arranged by the designers, the exist ones are ordered by their names
+ALTER TABLE ai_solutions ADD COLUMN sortOrder INTEGER NOT NULL DEFAULT 0;
+UPDATE ai_solutions SET sortOrder = (
+    SELECT COUNT(*) FROM ai_solutions AS earlier WHERE earlier.solutionName < ai_solutions.solutionName
+);
+
+ALTER TABLE ai_asset_sets ADD COLUMN sortOrder INTEGER NOT NULL DEFAULT 0;
+UPDATE ai_asset_sets SET sortOrder = (
+    SELECT COUNT(*) FROM ai_asset_sets AS earlier WHERE earlier.solutionId = ai_asset_sets.solutionId AND earlier.assetSetName < ai_asset_sets.assetSetName
+);
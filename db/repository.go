@@ -0,0 +1,77 @@
+package db
+
+import (
+	"errors"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+)
+
+// ErrRecordNotFound is returned by the Get and Find methods of the repository when the record doesn't exist
+var ErrRecordNotFound = errors.New("record not found")
+
+// ErrDuplicatedName is returned by the Create and Save methods of the solutions, the asset sets and the assets
+// when the name is used by another record of the same parent, the names are unique by the indexes of the database
+var ErrDuplicatedName = errors.New("duplicated name")
+
+// Repository is the storage of the solutions, the asset sets, the assets and the records about them.
+// the deleting cascades to the children like the foreign keys of the database
+type Repository interface {
+	// Transaction calls fc with a repository bound to the transaction, all the modifications are discarded when fc returns an error
+	Transaction(fc func(repo Repository) error) error
+
+	// ListSolutions returns the solutions ordered by the sort order and the name
+	ListSolutions() ([]common.SolutionSummaryInfoItem, error)
+	GetSolution(solutionId string) (*common.SolutionDetailInfo, error)
+	// GetSolutionForUpdate locks the solution until the transaction ends, so the modifications of the same solution are serialized from the version checking
+	GetSolutionForUpdate(solutionId string) (*common.SolutionDetailInfo, error)
+	CountSolutionsByName(solutionName string, excludedSolutionId string) (int64, error)
+	CreateSolution(solution *common.SolutionDetailInfo) error
+	SaveSolution(solution *common.SolutionDetailInfo) error
+	DeleteSolution(solutionId string) error
+	UpdateSolutionSortOrder(solutionId string, sortOrder int) error
+
+	// ListAssetSets returns the asset sets of the solution ordered by the sort order and the name
+	ListAssetSets(solutionId string) ([]common.AssetSetInfoItem, error)
+	GetAssetSet(assetSetId string) (*common.AssetSetInfoItem, error)
+	GetAssetSets(assetSetIds []string) ([]common.AssetSetInfoItem, error) // the missing ones are skipped
+	CountAssetSetsByName(solutionId string, assetSetName string, excludedAssetSetId string) (int64, error)
+	CreateAssetSet(assetSet *common.AssetSetInfoItem) error
+	SaveAssetSet(assetSet *common.AssetSetInfoItem) error
+	DeleteAssetSet(assetSetId string) error
+	UpdateAssetSetSortOrder(assetSetId string, sortOrder int) error
+
+	ListAssets(assetSetIds []string) ([]common.AssetSummaryInfoItem, error)
+	ListAssetDetails(assetSetIds []string) ([]common.AssetDetailInfo, error)
+	ListSolutionAssetDetails(solutionId string, assetType string) ([]common.AssetDetailInfo, error)
+	GetAsset(assetId string) (*common.AssetDetailInfo, error)
+	// GetAssetForUpdate locks the asset until the transaction ends, so the modifications of the same asset are serialized from the version checking
+	GetAssetForUpdate(assetId string) (*common.AssetDetailInfo, error)
+	CountAssetsByName(assetSetId string, assetName string, excludedAssetId string) (int64, error)
+	CreateAsset(asset *common.AssetDetailInfo) error
+	SaveAsset(asset *common.AssetDetailInfo) error
+	DeleteAsset(assetId string) error
+
+	CreateModificationHistory(historyItem *common.AssetModificationHistoryItem) error
+	// FindModificationHistory returns the latest or the earliest record in the state
+	FindModificationHistory(assetId string, operation string, undoState string, latest bool) (*common.AssetModificationHistoryItem, error)
+	CountModificationHistories(assetId string, operation string, undoState string) (int64, error)
+	// ListRecentModificationHistories returns the latest records, the latest one is the first
+	ListRecentModificationHistories(assetId string, limit int) ([]common.AssetModificationHistoryItem, error)
+	UpdateModificationHistoriesUndoState(assetId string, operation string, fromUndoState string, toUndoState string) error
+	// UpdateModificationHistoryUndoState returns false when the record is not in fromUndoState
+	UpdateModificationHistoryUndoState(historyId uint, fromUndoState string, toUndoState string) (bool, error)
+
+	CreateAssetVersion(versionItem *common.AssetVersionDetailInfo) error
+	// ListAssetVersions returns the versions earlier than beforeVersionId, or from the latest one when it's 0, the latest one is the first
+	ListAssetVersions(assetId string, beforeVersionId uint, limit int) ([]common.AssetVersionSummaryItem, error)
+	GetAssetVersion(assetId string, assetVersion string) (*common.AssetVersionDetailInfo, error)
+
+	// ListSettingsSchemas returns the schemas ordered by the category and the type name
+	ListSettingsSchemas(solutionId string) ([]common.SettingsSchemaItem, error)
+	GetSettingsSchema(solutionId string, category string, typeName string) (*common.SettingsSchemaItem, error)
+	SaveSettingsSchema(schemaItem *common.SettingsSchemaItem) error
+	// DeleteSettingsSchema returns false when the schema is not registered
+	DeleteSettingsSchema(solutionId string, category string, typeName string) (bool, error)
+}
+
+// Storage is the repository used by the handlers, it's set by Initialize
+var Storage Repository
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"errors"
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormRepository is the repository of SQLite and PostgreSQL, the cascade deleting relies on the foreign keys created by the migrations.
+// the camel case columns are quoted in the conditions since PostgreSQL folds the unquoted identifiers to lower case
+type GormRepository struct {
+	database *gorm.DB
+}
+
+func NewGormRepository(database *gorm.DB) *GormRepository {
+	return &GormRepository{database: database}
+}
+
+func first[T any](database *gorm.DB, conditions ...interface{}) (*T, error) {
+	var item T
+	err := database.First(&item, conditions...).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// translateDuplicatedName reports the violation of the unique indexes as ErrDuplicatedName, the names are the only unique columns
+// written by the Create and Save methods besides the primary keys
+func (repo *GormRepository) translateDuplicatedName(err error) error {
+	if translator, ok := repo.database.Dialector.(gorm.ErrorTranslator); err != nil && ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrDuplicatedName
+	}
+	return err
+}
+
+func (repo *GormRepository) Transaction(fc func(repo Repository) error) error {
+	return repo.database.Transaction(func(tx *gorm.DB) error {
+		return fc(&GormRepository{database: tx})
+	})
+}
+
+func (repo *GormRepository) ListSolutions() ([]common.SolutionSummaryInfoItem, error) {
+	var solutionInfos []common.SolutionSummaryInfoItem
+	err := repo.database.Order(`"sortOrder", "solutionName"`).Find(&solutionInfos).Error
+	return solutionInfos, err
+}
+
+func (repo *GormRepository) GetSolution(solutionId string) (*common.SolutionDetailInfo, error) {
+	return first[common.SolutionDetailInfo](repo.database, `"id" = ?`, solutionId)
+}
+
+// GetSolutionForUpdate selects the solution FOR UPDATE on PostgreSQL, the SQLite driver drops the locking clause like GetAssetForUpdate
+func (repo *GormRepository) GetSolutionForUpdate(solutionId string) (*common.SolutionDetailInfo, error) {
+	return first[common.SolutionDetailInfo](repo.database.Clauses(clause.Locking{Strength: "UPDATE"}), `"id" = ?`, solutionId)
+}
+
+func (repo *GormRepository) CountSolutionsByName(solutionName string, excludedSolutionId string) (int64, error) {
+	var count int64
+	err := repo.database.Model(&common.SolutionSummaryInfoItem{}).Where(`"solutionName" = ? AND "id" <> ?`, solutionName, excludedSolutionId).Count(&count).Error
+	return count, err
+}
+
+func (repo *GormRepository) CreateSolution(solution *common.SolutionDetailInfo) error {
+	return repo.translateDuplicatedName(repo.database.Create(solution).Error)
+}
+
+func (repo *GormRepository) SaveSolution(solution *common.SolutionDetailInfo) error {
+	return repo.translateDuplicatedName(repo.database.Save(solution).Error)
+}
+
+func (repo *GormRepository) DeleteSolution(solutionId string) error {
+	return repo.database.Delete(&common.SolutionDetailInfo{}, `"id" = ?`, solutionId).Error
+}
+
+func (repo *GormRepository) UpdateSolutionSortOrder(solutionId string, sortOrder int) error {
+	return repo.database.Model(&common.SolutionDetailInfo{}).Where(`"id" = ?`, solutionId).Update("sortOrder", sortOrder).Error
+}
+
+func (repo *GormRepository) ListAssetSets(solutionId string) ([]common.AssetSetInfoItem, error) {
+	var assetSetInfos []common.AssetSetInfoItem
+	err := repo.database.Order(`"sortOrder", "assetSetName"`).Find(&assetSetInfos, `"solutionId" = ?`, solutionId).Error
+	return assetSetInfos, err
+}
+
+func (repo *GormRepository) GetAssetSet(assetSetId string) (*common.AssetSetInfoItem, error) {
+	return first[common.AssetSetInfoItem](repo.database, `"id" = ?`, assetSetId)
+}
+
+func (repo *GormRepository) GetAssetSets(assetSetIds []string) ([]common.AssetSetInfoItem, error) {
+	var assetSetInfos []common.AssetSetInfoItem
+	err := repo.database.Find(&assetSetInfos, `"id" IN ?`, assetSetIds).Error
+	return assetSetInfos, err
+}
+
+func (repo *GormRepository) CountAssetSetsByName(solutionId string, assetSetName string, excludedAssetSetId string) (int64, error) {
+	var count int64
+	err := repo.database.Model(&common.AssetSetInfoItem{}).Where(`"solutionId" = ? AND "assetSetName" = ? AND "id" <> ?`, solutionId, assetSetName, excludedAssetSetId).Count(&count).Error
+	return count, err
+}
+
+func (repo *GormRepository) CreateAssetSet(assetSet *common.AssetSetInfoItem) error {
+	return repo.translateDuplicatedName(repo.database.Create(assetSet).Error)
+}
+
+func (repo *GormRepository) SaveAssetSet(assetSet *common.AssetSetInfoItem) error {
+	return repo.translateDuplicatedName(repo.database.Save(assetSet).Error)
+}
+
+func (repo *GormRepository) DeleteAssetSet(assetSetId string) error {
+	return repo.database.Delete(&common.AssetSetInfoItem{}, `"id" = ?`, assetSetId).Error
+}
+
+func (repo *GormRepository) UpdateAssetSetSortOrder(assetSetId string, sortOrder int) error {
+	return repo.database.Model(&common.AssetSetInfoItem{}).Where(`"id" = ?`, assetSetId).Update("sortOrder", sortOrder).Error
+}
+
+func (repo *GormRepository) ListAssets(assetSetIds []string) ([]common.AssetSummaryInfoItem, error) {
+	var assetItems []common.AssetSummaryInfoItem
+	err := repo.database.Find(&assetItems, `"assetSetId" IN ?`, assetSetIds).Error
+	return assetItems, err
+}
+
+func (repo *GormRepository) ListAssetDetails(assetSetIds []string) ([]common.AssetDetailInfo, error) {
+	var assetItems []common.AssetDetailInfo
+	err := repo.database.Find(&assetItems, `"assetSetId" IN ?`, assetSetIds).Error
+	return assetItems, err
+}
+
+func (repo *GormRepository) ListSolutionAssetDetails(solutionId string, assetType string) ([]common.AssetDetailInfo, error) {
+	var assetItems []common.AssetDetailInfo
+	err := repo.database.Where(`"assetType" = ? AND "assetSetId" IN (?)`, assetType, repo.database.Model(&common.AssetSetInfoItem{}).Select("id").Where(`"solutionId" = ?`, solutionId)).
+		Find(&assetItems).Error
+	return assetItems, err
+}
+
+func (repo *GormRepository) GetAsset(assetId string) (*common.AssetDetailInfo, error) {
+	return first[common.AssetDetailInfo](repo.database, `"id" = ?`, assetId)
+}
+
+// GetAssetForUpdate selects the asset FOR UPDATE on PostgreSQL, the SQLite driver drops the locking clause since the whole database is locked by the writing transaction
+func (repo *GormRepository) GetAssetForUpdate(assetId string) (*common.AssetDetailInfo, error) {
+	return first[common.AssetDetailInfo](repo.database.Clauses(clause.Locking{Strength: "UPDATE"}), `"id" = ?`, assetId)
+}
+
+func (repo *GormRepository) CountAssetsByName(assetSetId string, assetName string, excludedAssetId string) (int64, error) {
+	var count int64
+	err := repo.database.Model(&common.AssetDetailInfo{}).Where(`"assetSetId" = ? AND "assetName" = ? AND "id" <> ?`, assetSetId, assetName, excludedAssetId).Count(&count).Error
+	return count, err
+}
+
+func (repo *GormRepository) CreateAsset(asset *common.AssetDetailInfo) error {
+	return repo.translateDuplicatedName(repo.database.Create(asset).Error)
+}
+
+func (repo *GormRepository) SaveAsset(asset *common.AssetDetailInfo) error {
+	return repo.translateDuplicatedName(repo.database.Save(asset).Error)
+}
+
+func (repo *GormRepository) DeleteAsset(assetId string) error {
+	return repo.database.Delete(&common.AssetDetailInfo{}, `"id" = ?`, assetId).Error
+}
+
+func (repo *GormRepository) CreateModificationHistory(historyItem *common.AssetModificationHistoryItem) error {
+	return repo.database.Create(historyItem).Error
+}
+
+func (repo *GormRepository) FindModificationHistory(assetId string, operation string, undoState string, latest bool) (*common.AssetModificationHistoryItem, error) {
+	order := "id ASC"
+	if latest {
+		order = "id DESC"
+	}
+	var historyItems []common.AssetModificationHistoryItem
+	err := repo.database.Where(`"assetId" = ? AND "operation" = ? AND "undoState" = ?`, assetId, operation, undoState).Order(order).Limit(1).Find(&historyItems).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(historyItems) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return &historyItems[0], nil
+}
+
+func (repo *GormRepository) CountModificationHistories(assetId string, operation string, undoState string) (int64, error) {
+	var count int64
+	err := repo.database.Model(&common.AssetModificationHistoryItem{}).
+		Where(`"assetId" = ? AND "operation" = ? AND "undoState" = ?`, assetId, operation, undoState).
+		Count(&count).Error
+	return count, err
+}
+
+func (repo *GormRepository) ListRecentModificationHistories(assetId string, limit int) ([]common.AssetModificationHistoryItem, error) {
+	var historyItems []common.AssetModificationHistoryItem
+	err := repo.database.Where(`"assetId" = ?`, assetId).Order("id DESC").Limit(limit).Find(&historyItems).Error
+	return historyItems, err
+}
+
+func (repo *GormRepository) UpdateModificationHistoriesUndoState(assetId string, operation string, fromUndoState string, toUndoState string) error {
+	return repo.database.Model(&common.AssetModificationHistoryItem{}).
+		Where(`"assetId" = ? AND "operation" = ? AND "undoState" = ?`, assetId, operation, fromUndoState).
+		Update("undoState", toUndoState).Error
+}
+
+func (repo *GormRepository) UpdateModificationHistoryUndoState(historyId uint, fromUndoState string, toUndoState string) (bool, error) {
+	result := repo.database.Model(&common.AssetModificationHistoryItem{}).
+		Where(`"id" = ? AND "undoState" = ?`, historyId, fromUndoState).
+		Update("undoState", toUndoState)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (repo *GormRepository) CreateAssetVersion(versionItem *common.AssetVersionDetailInfo) error {
+	return repo.database.Create(versionItem).Error
+}
+
+func (repo *GormRepository) ListAssetVersions(assetId string, beforeVersionId uint, limit int) ([]common.AssetVersionSummaryItem, error) {
+	var versionItems []common.AssetVersionSummaryItem
+	// only the columns of the summary are selected, the contents are skipped
+	query := repo.database.Session(&gorm.Session{QueryFields: true}).Where(`"assetId" = ?`, assetId)
+	if beforeVersionId > 0 {
+		query = query.Where(`"id" < ?`, beforeVersionId)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&versionItems).Error
+	return versionItems, err
+}
+
+func (repo *GormRepository) GetAssetVersion(assetId string, assetVersion string) (*common.AssetVersionDetailInfo, error) {
+	return first[common.AssetVersionDetailInfo](repo.database, `"assetId" = ? AND "assetVersion" = ?`, assetId, assetVersion)
+}
+
+func (repo *GormRepository) ListSettingsSchemas(solutionId string) ([]common.SettingsSchemaItem, error) {
+	var schemaItems []common.SettingsSchemaItem
+	err := repo.database.Where(`"solutionId" = ?`, solutionId).Order(`"category", "typeName"`).Find(&schemaItems).Error
+	return schemaItems, err
+}
+
+func (repo *GormRepository) GetSettingsSchema(solutionId string, category string, typeName string) (*common.SettingsSchemaItem, error) {
+	return first[common.SettingsSchemaItem](repo.database, `"solutionId" = ? AND "category" = ? AND "typeName" = ?`, solutionId, category, typeName)
+}
+
+func (repo *GormRepository) SaveSettingsSchema(schemaItem *common.SettingsSchemaItem) error {
+	return repo.database.Save(schemaItem).Error
+}
+
+func (repo *GormRepository) DeleteSettingsSchema(solutionId string, category string, typeName string) (bool, error) {
+	result := repo.database.Where(`"solutionId" = ? AND "category" = ? AND "typeName" = ?`, solutionId, category, typeName).Delete(&common.SettingsSchemaItem{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,520 @@
+package db
+
+import (
+	"github.com/xxponline/messy-monster-ai-editor/common"
+	"golang.org/x/exp/slices"
+	"sort"
+	"sync"
+)
+
+// memoryTables keeps the records in the order of creation like the rowid of the database
+type memoryTables struct {
+	solutions       []common.SolutionDetailInfo
+	assetSets       []common.AssetSetInfoItem
+	assets          []common.AssetDetailInfo
+	histories       []common.AssetModificationHistoryItem
+	settingsSchemas []common.SettingsSchemaItem
+	assetVersions   []common.AssetVersionDetailInfo
+	lastHistoryId   uint
+	lastVersionId   uint
+}
+
+func (tables *memoryTables) clone() memoryTables {
+	return memoryTables{
+		solutions:       slices.Clone(tables.solutions),
+		assetSets:       slices.Clone(tables.assetSets),
+		assets:          slices.Clone(tables.assets),
+		histories:       slices.Clone(tables.histories),
+		settingsSchemas: slices.Clone(tables.settingsSchemas),
+		assetVersions:   slices.Clone(tables.assetVersions),
+		lastHistoryId:   tables.lastHistoryId,
+		lastVersionId:   tables.lastVersionId,
+	}
+}
+
+type memoryStore struct {
+	mutex  sync.Mutex
+	tables memoryTables
+}
+
+// MemoryRepository keeps everything in the process, it's for running the server ephemerally and testing the handlers.
+// the transactions are serialized, the tables are copied when a transaction begins and restored when it fails
+type MemoryRepository struct {
+	store         *memoryStore
+	inTransaction bool
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{store: &memoryStore{}}
+}
+
+// lock returns the tables and the function to unlock, the store has been locked by the transaction already
+func (repo *MemoryRepository) lock() (*memoryTables, func()) {
+	if repo.inTransaction {
+		return &repo.store.tables, func() {}
+	}
+	repo.store.mutex.Lock()
+	return &repo.store.tables, repo.store.mutex.Unlock
+}
+
+func (repo *MemoryRepository) Transaction(fc func(repo Repository) error) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	snapshot := tables.clone()
+	err := fc(&MemoryRepository{store: repo.store, inTransaction: true})
+	if err != nil {
+		*tables = snapshot
+	}
+	return err
+}
+
+func filterRecords[T any](records []T, matched func(record *T) bool) []T {
+	filtered := make([]T, 0)
+	for i := range records {
+		if matched(&records[i]) {
+			filtered = append(filtered, records[i])
+		}
+	}
+	return filtered
+}
+
+func findRecord[T any](records []T, matched func(record *T) bool) (*T, error) {
+	for i := range records {
+		if matched(&records[i]) {
+			record := records[i]
+			return &record, nil
+		}
+	}
+	return nil, ErrRecordNotFound
+}
+
+// saveRecord replaces the record of the same id or appends it when it doesn't exist
+func saveRecord[T any](records []T, record *T, sameId func(record *T) bool) []T {
+	for i := range records {
+		if sameId(&records[i]) {
+			records[i] = *record
+			return records
+		}
+	}
+	return append(records, *record)
+}
+
+func toAssetSummary(asset *common.AssetDetailInfo) common.AssetSummaryInfoItem {
+	return common.AssetSummaryInfoItem{
+		AssetId:      asset.AssetId,
+		AssetSetId:   asset.AssetSetId,
+		AssetType:    asset.AssetType,
+		AssetName:    asset.AssetName,
+		AssetVersion: asset.AssetVersion,
+	}
+}
+
+func (repo *MemoryRepository) ListSolutions() ([]common.SolutionSummaryInfoItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	solutionInfos := make([]common.SolutionSummaryInfoItem, 0, len(tables.solutions))
+	for _, solution := range tables.solutions {
+		solutionInfos = append(solutionInfos, common.SolutionSummaryInfoItem{
+			SolutionId:      solution.SolutionId,
+			SolutionName:    solution.SolutionName,
+			SolutionVersion: solution.SolutionVersion,
+			SortOrder:       solution.SortOrder,
+		})
+	}
+	sort.SliceStable(solutionInfos, func(i, j int) bool {
+		if solutionInfos[i].SortOrder != solutionInfos[j].SortOrder {
+			return solutionInfos[i].SortOrder < solutionInfos[j].SortOrder
+		}
+		return solutionInfos[i].SolutionName < solutionInfos[j].SolutionName
+	})
+	return solutionInfos, nil
+}
+
+func (repo *MemoryRepository) GetSolution(solutionId string) (*common.SolutionDetailInfo, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return findRecord(tables.solutions, func(solution *common.SolutionDetailInfo) bool { return solution.SolutionId == solutionId })
+}
+
+// GetSolutionForUpdate doesn't lock anything more, the transactions are serialized already
+func (repo *MemoryRepository) GetSolutionForUpdate(solutionId string) (*common.SolutionDetailInfo, error) {
+	return repo.GetSolution(solutionId)
+}
+
+func (repo *MemoryRepository) CountSolutionsByName(solutionName string, excludedSolutionId string) (int64, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return int64(len(filterRecords(tables.solutions, func(solution *common.SolutionDetailInfo) bool {
+		return solution.SolutionName == solutionName && solution.SolutionId != excludedSolutionId
+	}))), nil
+}
+
+func (repo *MemoryRepository) CreateSolution(solution *common.SolutionDetailInfo) error {
+	return repo.SaveSolution(solution)
+}
+
+func (repo *MemoryRepository) SaveSolution(solution *common.SolutionDetailInfo) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	if slices.ContainsFunc(tables.solutions, func(record common.SolutionDetailInfo) bool {
+		return record.SolutionName == solution.SolutionName && record.SolutionId != solution.SolutionId
+	}) {
+		return ErrDuplicatedName
+	}
+	tables.solutions = saveRecord(tables.solutions, solution, func(record *common.SolutionDetailInfo) bool { return record.SolutionId == solution.SolutionId })
+	return nil
+}
+
+func (repo *MemoryRepository) DeleteSolution(solutionId string) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	tables.solutions = filterRecords(tables.solutions, func(solution *common.SolutionDetailInfo) bool { return solution.SolutionId != solutionId })
+	tables.settingsSchemas = filterRecords(tables.settingsSchemas, func(schemaItem *common.SettingsSchemaItem) bool { return schemaItem.SolutionId != solutionId })
+	for _, assetSet := range filterRecords(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool { return assetSet.SolutionId == solutionId }) {
+		tables.deleteAssetSet(assetSet.AssetSetId)
+	}
+	return nil
+}
+
+func (repo *MemoryRepository) UpdateSolutionSortOrder(solutionId string, sortOrder int) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	for i := range tables.solutions {
+		if tables.solutions[i].SolutionId == solutionId {
+			tables.solutions[i].SortOrder = sortOrder
+		}
+	}
+	return nil
+}
+
+func (repo *MemoryRepository) ListAssetSets(solutionId string) ([]common.AssetSetInfoItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	assetSetInfos := filterRecords(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool { return assetSet.SolutionId == solutionId })
+	sort.SliceStable(assetSetInfos, func(i, j int) bool {
+		if assetSetInfos[i].SortOrder != assetSetInfos[j].SortOrder {
+			return assetSetInfos[i].SortOrder < assetSetInfos[j].SortOrder
+		}
+		return assetSetInfos[i].AssetSetName < assetSetInfos[j].AssetSetName
+	})
+	return assetSetInfos, nil
+}
+
+func (repo *MemoryRepository) GetAssetSet(assetSetId string) (*common.AssetSetInfoItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return findRecord(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool { return assetSet.AssetSetId == assetSetId })
+}
+
+func (repo *MemoryRepository) GetAssetSets(assetSetIds []string) ([]common.AssetSetInfoItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return filterRecords(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool { return slices.Contains(assetSetIds, assetSet.AssetSetId) }), nil
+}
+
+func (repo *MemoryRepository) CountAssetSetsByName(solutionId string, assetSetName string, excludedAssetSetId string) (int64, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return int64(len(filterRecords(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool {
+		return assetSet.SolutionId == solutionId && assetSet.AssetSetName == assetSetName && assetSet.AssetSetId != excludedAssetSetId
+	}))), nil
+}
+
+func (repo *MemoryRepository) CreateAssetSet(assetSet *common.AssetSetInfoItem) error {
+	return repo.SaveAssetSet(assetSet)
+}
+
+func (repo *MemoryRepository) SaveAssetSet(assetSet *common.AssetSetInfoItem) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	if slices.ContainsFunc(tables.assetSets, func(record common.AssetSetInfoItem) bool {
+		return record.SolutionId == assetSet.SolutionId && record.AssetSetName == assetSet.AssetSetName && record.AssetSetId != assetSet.AssetSetId
+	}) {
+		return ErrDuplicatedName
+	}
+	tables.assetSets = saveRecord(tables.assetSets, assetSet, func(record *common.AssetSetInfoItem) bool { return record.AssetSetId == assetSet.AssetSetId })
+	return nil
+}
+
+func (repo *MemoryRepository) DeleteAssetSet(assetSetId string) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	tables.deleteAssetSet(assetSetId)
+	return nil
+}
+
+func (repo *MemoryRepository) UpdateAssetSetSortOrder(assetSetId string, sortOrder int) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	for i := range tables.assetSets {
+		if tables.assetSets[i].AssetSetId == assetSetId {
+			tables.assetSets[i].SortOrder = sortOrder
+		}
+	}
+	return nil
+}
+
+func (tables *memoryTables) deleteAssetSet(assetSetId string) {
+	tables.assetSets = filterRecords(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool { return assetSet.AssetSetId != assetSetId })
+	for _, asset := range filterRecords(tables.assets, func(asset *common.AssetDetailInfo) bool { return asset.AssetSetId == assetSetId }) {
+		tables.deleteAsset(asset.AssetId)
+	}
+}
+
+func (repo *MemoryRepository) ListAssets(assetSetIds []string) ([]common.AssetSummaryInfoItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	assetItems := make([]common.AssetSummaryInfoItem, 0)
+	for i := range tables.assets {
+		if slices.Contains(assetSetIds, tables.assets[i].AssetSetId) {
+			assetItems = append(assetItems, toAssetSummary(&tables.assets[i]))
+		}
+	}
+	return assetItems, nil
+}
+
+func (repo *MemoryRepository) ListAssetDetails(assetSetIds []string) ([]common.AssetDetailInfo, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return filterRecords(tables.assets, func(asset *common.AssetDetailInfo) bool { return slices.Contains(assetSetIds, asset.AssetSetId) }), nil
+}
+
+func (repo *MemoryRepository) ListSolutionAssetDetails(solutionId string, assetType string) ([]common.AssetDetailInfo, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	assetSets := filterRecords(tables.assetSets, func(assetSet *common.AssetSetInfoItem) bool { return assetSet.SolutionId == solutionId })
+	return filterRecords(tables.assets, func(asset *common.AssetDetailInfo) bool {
+		return asset.AssetType == assetType && slices.ContainsFunc(assetSets, func(assetSet common.AssetSetInfoItem) bool { return assetSet.AssetSetId == asset.AssetSetId })
+	}), nil
+}
+
+func (repo *MemoryRepository) GetAsset(assetId string) (*common.AssetDetailInfo, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return findRecord(tables.assets, func(asset *common.AssetDetailInfo) bool { return asset.AssetId == assetId })
+}
+
+// GetAssetForUpdate doesn't lock anything more, the transactions are serialized already
+func (repo *MemoryRepository) GetAssetForUpdate(assetId string) (*common.AssetDetailInfo, error) {
+	return repo.GetAsset(assetId)
+}
+
+func (repo *MemoryRepository) CountAssetsByName(assetSetId string, assetName string, excludedAssetId string) (int64, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return int64(len(filterRecords(tables.assets, func(asset *common.AssetDetailInfo) bool {
+		return asset.AssetSetId == assetSetId && asset.AssetName == assetName && asset.AssetId != excludedAssetId
+	}))), nil
+}
+
+func (repo *MemoryRepository) CreateAsset(asset *common.AssetDetailInfo) error {
+	return repo.SaveAsset(asset)
+}
+
+func (repo *MemoryRepository) SaveAsset(asset *common.AssetDetailInfo) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	if slices.ContainsFunc(tables.assets, func(record common.AssetDetailInfo) bool {
+		return record.AssetSetId == asset.AssetSetId && record.AssetName == asset.AssetName && record.AssetId != asset.AssetId
+	}) {
+		return ErrDuplicatedName
+	}
+	tables.assets = saveRecord(tables.assets, asset, func(record *common.AssetDetailInfo) bool { return record.AssetId == asset.AssetId })
+	return nil
+}
+
+func (repo *MemoryRepository) DeleteAsset(assetId string) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	tables.deleteAsset(assetId)
+	return nil
+}
+
+func (tables *memoryTables) deleteAsset(assetId string) {
+	tables.assets = filterRecords(tables.assets, func(asset *common.AssetDetailInfo) bool { return asset.AssetId != assetId })
+	tables.histories = filterRecords(tables.histories, func(historyItem *common.AssetModificationHistoryItem) bool { return historyItem.AssetId != assetId })
+	tables.assetVersions = filterRecords(tables.assetVersions, func(versionItem *common.AssetVersionDetailInfo) bool { return versionItem.AssetId != assetId })
+}
+
+func (repo *MemoryRepository) CreateModificationHistory(historyItem *common.AssetModificationHistoryItem) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	tables.lastHistoryId++
+	historyItem.HistoryId = tables.lastHistoryId
+	tables.histories = append(tables.histories, *historyItem)
+	return nil
+}
+
+func (repo *MemoryRepository) FindModificationHistory(assetId string, operation string, undoState string, latest bool) (*common.AssetModificationHistoryItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	historyItems := filterRecords(tables.histories, func(historyItem *common.AssetModificationHistoryItem) bool {
+		return historyItem.AssetId == assetId && historyItem.Operation == operation && historyItem.UndoState == undoState
+	})
+	if len(historyItems) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	if latest {
+		return &historyItems[len(historyItems)-1], nil
+	}
+	return &historyItems[0], nil
+}
+
+func (repo *MemoryRepository) CountModificationHistories(assetId string, operation string, undoState string) (int64, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return int64(len(filterRecords(tables.histories, func(historyItem *common.AssetModificationHistoryItem) bool {
+		return historyItem.AssetId == assetId && historyItem.Operation == operation && historyItem.UndoState == undoState
+	}))), nil
+}
+
+func (repo *MemoryRepository) ListRecentModificationHistories(assetId string, limit int) ([]common.AssetModificationHistoryItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	historyItems := filterRecords(tables.histories, func(historyItem *common.AssetModificationHistoryItem) bool { return historyItem.AssetId == assetId })
+	slices.Reverse(historyItems)
+	if len(historyItems) > limit {
+		historyItems = historyItems[:limit]
+	}
+	return historyItems, nil
+}
+
+func (repo *MemoryRepository) UpdateModificationHistoriesUndoState(assetId string, operation string, fromUndoState string, toUndoState string) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	for i := range tables.histories {
+		historyItem := &tables.histories[i]
+		if historyItem.AssetId == assetId && historyItem.Operation == operation && historyItem.UndoState == fromUndoState {
+			historyItem.UndoState = toUndoState
+		}
+	}
+	return nil
+}
+
+func (repo *MemoryRepository) UpdateModificationHistoryUndoState(historyId uint, fromUndoState string, toUndoState string) (bool, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	for i := range tables.histories {
+		historyItem := &tables.histories[i]
+		if historyItem.HistoryId == historyId && historyItem.UndoState == fromUndoState {
+			historyItem.UndoState = toUndoState
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (repo *MemoryRepository) CreateAssetVersion(versionItem *common.AssetVersionDetailInfo) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	tables.lastVersionId++
+	versionItem.VersionId = tables.lastVersionId
+	tables.assetVersions = append(tables.assetVersions, *versionItem)
+	return nil
+}
+
+func (repo *MemoryRepository) ListAssetVersions(assetId string, beforeVersionId uint, limit int) ([]common.AssetVersionSummaryItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	versionItems := filterRecords(tables.assetVersions, func(versionItem *common.AssetVersionDetailInfo) bool {
+		return versionItem.AssetId == assetId && (beforeVersionId == 0 || versionItem.VersionId < beforeVersionId)
+	})
+	slices.Reverse(versionItems)
+	if len(versionItems) > limit {
+		versionItems = versionItems[:limit]
+	}
+
+	summaryItems := make([]common.AssetVersionSummaryItem, 0, len(versionItems))
+	for _, versionItem := range versionItems {
+		summaryItems = append(summaryItems, common.AssetVersionSummaryItem{
+			VersionId:           versionItem.VersionId,
+			AssetId:             versionItem.AssetId,
+			AssetVersion:        versionItem.AssetVersion,
+			PrevVersion:         versionItem.PrevVersion,
+			RestoredFromVersion: versionItem.RestoredFromVersion,
+			Author:              versionItem.Author,
+			CreatedAt:           versionItem.CreatedAt,
+		})
+	}
+	return summaryItems, nil
+}
+
+func (repo *MemoryRepository) GetAssetVersion(assetId string, assetVersion string) (*common.AssetVersionDetailInfo, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return findRecord(tables.assetVersions, func(versionItem *common.AssetVersionDetailInfo) bool {
+		return versionItem.AssetId == assetId && versionItem.AssetVersion == assetVersion
+	})
+}
+
+func (repo *MemoryRepository) ListSettingsSchemas(solutionId string) ([]common.SettingsSchemaItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	schemaItems := filterRecords(tables.settingsSchemas, func(schemaItem *common.SettingsSchemaItem) bool { return schemaItem.SolutionId == solutionId })
+	sort.SliceStable(schemaItems, func(i, j int) bool {
+		if schemaItems[i].Category != schemaItems[j].Category {
+			return schemaItems[i].Category < schemaItems[j].Category
+		}
+		return schemaItems[i].TypeName < schemaItems[j].TypeName
+	})
+	return schemaItems, nil
+}
+
+func (repo *MemoryRepository) GetSettingsSchema(solutionId string, category string, typeName string) (*common.SettingsSchemaItem, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	return findRecord(tables.settingsSchemas, func(schemaItem *common.SettingsSchemaItem) bool {
+		return schemaItem.SolutionId == solutionId && schemaItem.Category == category && schemaItem.TypeName == typeName
+	})
+}
+
+func (repo *MemoryRepository) SaveSettingsSchema(schemaItem *common.SettingsSchemaItem) error {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	tables.settingsSchemas = saveRecord(tables.settingsSchemas, schemaItem, func(record *common.SettingsSchemaItem) bool { return record.SchemaId == schemaItem.SchemaId })
+	return nil
+}
+
+func (repo *MemoryRepository) DeleteSettingsSchema(solutionId string, category string, typeName string) (bool, error) {
+	tables, unlock := repo.lock()
+	defer unlock()
+
+	count := len(tables.settingsSchemas)
+	tables.settingsSchemas = filterRecords(tables.settingsSchemas, func(schemaItem *common.SettingsSchemaItem) bool {
+		return schemaItem.SolutionId != solutionId || schemaItem.Category != category || schemaItem.TypeName != typeName
+	})
+	return len(tables.settingsSchemas) < count, nil
+}
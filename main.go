@@ -1,26 +1,72 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/xxponline/messy-monster-ai-editor/asset_content"
 	"github.com/xxponline/messy-monster-ai-editor/asset_organization"
+	"github.com/xxponline/messy-monster-ai-editor/config"
+	"github.com/xxponline/messy-monster-ai-editor/db"
 	"go.uber.org/zap"
+	"os"
+	"strings"
 )
 
 func main() {
+	//Load Config
+	serverConfig, command, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
 	//Initialize Zap Log System
-	developmentLogger, err := zap.NewDevelopment()
+	logger, err := serverConfig.NewLogger()
 	if err != nil {
 		panic(err.Error())
-		return
 	}
-	zap.ReplaceGlobals(developmentLogger)
+	zap.ReplaceGlobals(logger)
+
+	//The Log Is Synced Before Exiting, Even When The Startup Fails
+	err = run(serverConfig, command)
+	if err != nil {
+		zap.S().Error(err)
+	}
+	_ = logger.Sync()
+	if err != nil {
+		os.Exit(1)
+	}
+}
 
-	/*db.ServerDatabase = &db.SqliteDataBase{}
-	errCode, errMsg := db.ServerDatabase.Initialize("./db/db.sqlite")
-	if errCode != 0 {
-		panic(errMsg)
-	}*/
+// run initializes the database and runs the command, or serves the APIs until the server fails
+func run(serverConfig *config.ServerConfig, command []string) error {
+	//Initialize Database
+	err := db.Initialize(serverConfig.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	err = db.Migrate()
+	if err != nil {
+		return err
+	}
+
+	//Commands, "migrate" only applies the pending migrations without serving,
+	//"import-sqlite <file>" copies the records of the SQLite file into the database
+	if len(command) > 0 {
+		switch {
+		case command[0] == "migrate":
+			return nil
+		case command[0] == "import-sqlite" && len(command) == 2:
+			return db.ImportSQLite(command[1])
+		default:
+			return fmt.Errorf("unknown command %q, the commands are: migrate, import-sqlite <file>", strings.Join(command, " "))
+		}
+	}
 
 	//var items []common.SolutionInfoItem
 	//errCode, errMsg, items = solutionMgr.ListSolutions()
@@ -31,11 +77,12 @@ func main() {
 	//uuid := uuid.New()
 	//fmt.Println(uuid.String())
 
+	gin.SetMode(serverConfig.GinMode)
 	r := gin.Default()
 	APIRout := r.Group("API")
 	asset_organization.InitializeAssetManagement(APIRout.Group("AssetManagement"))
 	asset_content.InitializeAssetManagement(APIRout.Group("AssetContentModifier"))
-	r.Run("localhost:8000") // listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
+	return r.Run(serverConfig.ListenAddress)
 }
 
 //type FOO struct {